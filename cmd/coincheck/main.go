@@ -1,18 +1,35 @@
 package main
 
 import (
+	"context"
 	_ "embed"
+	"flag"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/coincheck"
 	"github.com/vultisig/airdrop-registry/internal/common"
 	"github.com/vultisig/airdrop-registry/internal/models"
 	"github.com/vultisig/airdrop-registry/internal/services"
 	"github.com/vultisig/airdrop-registry/internal/tokens"
+	"github.com/vultisig/airdrop-registry/internal/upstream"
 )
 
 func main() {
+	fix := flag.Bool("fix", false, "write the corrected cmc ids, decimals, tickers and logos to the coins in a single transaction")
+	dryRun := flag.Bool("dry-run", false, "print the changes --fix would write without writing them")
+	reportFormat := flag.String("report", "", "report format: json or csv, no report when empty")
+	out := flag.String("out", "", "report file, defaults to stdout")
+	flag.Parse()
+	if *reportFormat != "" && *reportFormat != "json" && *reportFormat != "csv" {
+		logrus.Fatalf("Invalid report format %s", *reportFormat)
+	}
+
 	logrus.SetFormatter(&logrus.TextFormatter{
 		ForceColors:      true,
 		FullTimestamp:    true,
@@ -25,8 +42,16 @@ func main() {
 	if err != nil {
 		logrus.WithError(err).Fatalf("Failed to load config")
 	}
+	upstream.Configure(cfg.Upstream)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	storage, err := services.NewStorage(cfg)
+	// only --fix writes, the other runs neither migrate nor seed the database
+	newStorage := services.NewReadOnlyStorage
+	if *fix && !*dryRun {
+		newStorage = services.NewStorage
+	}
+	storage, err := newStorage(cfg)
 	if err != nil {
 		logrus.WithError(err).Fatalf("Failed to initialize storage")
 	}
@@ -53,21 +78,28 @@ func main() {
 		if !oneInchService.IsChainSupported(chain) { 
 			continue
 		}
-		err := oneInchService.LoadOneInchTokens(chain)
+		err := oneInchService.LoadOneInchTokens(ctx, chain)
 		if err != nil {
 			logrus.WithError(err).Fatalf("Failed to load oneInch service")
 		}
 		discoveryServices[chain] = tokens.NewERC20DiscoveryService(oneInchService, cmcService)
 	}
 
-	predefinedService := tokens.NewPredefinedTokenService()
+	assets, err := storage.GetAssetRegistry()
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to get tracked assets")
+	}
+	checker := coincheck.NewChecker(assets, tokens.NewPredefinedTokenService(), discoveryServices)
 	const batchSize = 1000
 	var currentID uint64
+	var checked int
+	var findings []coincheck.Finding
+	var fixes []models.CoinFix
 
 	for {
 		coins, err := storage.GetCoinsWithPage(currentID, batchSize)
 		if err != nil {
-			logrus.WithError(err).Errorf("Failed to fetch coins")
+			logrus.WithError(err).Fatalf("Failed to fetch coins")
 		}
 		if len(coins) == 0 {
 			logrus.Infof("No more coins to process")
@@ -76,45 +108,63 @@ func main() {
 		currentID = uint64(coins[len(coins)-1].ID)
 
 		for _, coin := range coins {
-			if coin.CMCId == 0 || coin.Decimals == 0 {
-				logrus.Warnf("Invalid coin data - CMCId: %d, Decimals: %d, Chain: %s, Address: %s",
-					coin.CMCId, coin.Decimals, coin.Chain, coin.ContractAddress)
-				continue
-			}
-
-			coinBase := models.CoinBase{
-				Chain:           coin.Chain,
-				Address:         coin.Address,
-				ContractAddress: coin.ContractAddress,
+			if ctx.Err() != nil {
+				logrus.Fatalf("Interrupted, nothing was written")
 			}
-
-			predefinedCoin, err := predefinedService.Search(coinBase)
-			if err == nil {
-				if predefinedCoin.CMCId != coin.CMCId || predefinedCoin.Decimals != coin.Decimals {
-					logrus.Warnf("Coin data mismatch - System(CMCId: %d, Decimals: %d) vs User(CMCId: %d, Decimals: %d) for contract address: %s on %s",
-						coin.CMCId, coin.Decimals, predefinedCoin.CMCId, predefinedCoin.Decimals, coin.ContractAddress, coin.Chain)
+			checked++
+			coinFindings, coinFix := checker.Check(ctx, coin)
+			for _, finding := range coinFindings {
+				if finding.Fixable() {
+					logrus.Warnf("Coin %d %s on %s: %s is %q, expected %q from %s",
+						finding.CoinID, finding.Ticker, finding.Chain, finding.Type, finding.Current, finding.Expected, finding.Source)
 				} else {
-					logrus.Infof("Coin data matches in predefined tokens - CMCId: %d, Decimals: %d for %s on %s",
-						coin.CMCId, coin.Decimals, coin.ContractAddress, coin.Chain)
-				}
-			} else {
-				discoveryService, exists := discoveryServices[coin.Chain]
-				if !exists {
-					logrus.Warnf("No discovery service found for chain: %s", coin.Chain)
-					continue
-				}
-				coinData, err := discoveryService.Search(coinBase)
-				if err != nil {
-					logrus.Errorf("Error searching contract address %s on chain %s: %v", coin.ContractAddress, coin.Chain, err)
-				}
-				if coinData.CMCId == coin.CMCId && coinData.Decimals == coin.Decimals {
-					logrus.Infof("Coin data matches - CMCId: %d, Decimals: %d for %s on %s",
-						coin.CMCId, coin.Decimals, coin.ContractAddress, coin.Chain)
-				} else {
-					logrus.Warnf("Coin data mismatch - System(CMCId: %d, Decimals: %d) vs User(CMCId: %d, Decimals: %d) for contract address: %s on %s",
-						coin.CMCId, coin.Decimals, coinData.CMCId, coinData.Decimals, coin.ContractAddress, coin.Chain)
+					logrus.Warnf("Coin %d %s %s on %s: %s %s", finding.CoinID, finding.Ticker, finding.ContractAddress, finding.Chain, finding.Type, finding.Detail)
 				}
 			}
+			findings = append(findings, coinFindings...)
+			if coinFix != nil {
+				fixes = append(fixes, *coinFix)
+			}
 		}
 	}
+	logrus.Infof("Checked %d coins, %d findings, %d coins to fix", checked, len(findings), len(fixes))
+
+	report := coincheck.NewReport(checked, findings)
+	report.DryRun = *dryRun
+	if *dryRun {
+		if err := coincheck.WriteDiff(os.Stderr, findings); err != nil {
+			logrus.WithError(err).Fatalf("Failed to write diff")
+		}
+	} else if *fix && len(fixes) > 0 {
+		keptTicker, err := storage.FixCoins(fixes)
+		if err != nil {
+			logrus.WithError(err).Fatalf("Failed to fix coins")
+		}
+		for _, coinID := range keptTicker {
+			logrus.Warnf("Kept the ticker of coin %d, the address already has a coin with the expected ticker", coinID)
+		}
+		report.Fixed = len(fixes)
+		logrus.Infof("Fixed %d coins", len(fixes))
+	}
+
+	if *reportFormat == "" {
+		return
+	}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			logrus.WithError(err).Fatalf("Failed to create report file")
+		}
+		defer f.Close()
+		w = f
+	}
+	if *reportFormat == "json" {
+		err = report.WriteJSON(w)
+	} else {
+		err = report.WriteCSV(w)
+	}
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to write report")
+	}
 }
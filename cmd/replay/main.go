@@ -0,0 +1,168 @@
+// replay recomputes the points and ranks of a season from the point_events ledger under an alternative
+// formula or season config and writes the difference to the current scoring. It only reads from the database.
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/replay"
+	"github.com/vultisig/airdrop-registry/internal/scoring"
+	"github.com/vultisig/airdrop-registry/internal/services"
+)
+
+const pageSize = 10000
+
+func main() {
+	seasonID := flag.Uint("season", 0, "season to replay, defaults to the current season")
+	aggregation := flag.String("aggregation", "", "aggregation of the alternative formula: sqrt, linear or log, defaults to the formula of the season")
+	seasonConfig := flag.String("season-config", "", "yaml file with an alternative season config (formula, token multipliers and milestones)")
+	format := flag.String("format", "csv", "report format: csv or json")
+	out := flag.String("out", "", "report file, defaults to stdout")
+	top := flag.Int("top", 20, "number of top gainers and losers in the report")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to load config")
+	}
+	season := cfg.GetCurrentSeason()
+	if *seasonID != 0 {
+		var ok bool
+		if season, ok = cfg.GetSeason(*seasonID); !ok {
+			logrus.Fatalf("Season %d not found", *seasonID)
+		}
+	}
+	if *format != "csv" && *format != "json" {
+		logrus.Fatalf("Invalid format %s", *format)
+	}
+	baselineFormula, err := scoring.NewFormula(season)
+	if err != nil {
+		logrus.WithError(err).Fatalf("Invalid season formula")
+	}
+	alternativeSeason := season
+	if *seasonConfig != "" {
+		if alternativeSeason, err = config.LoadSeasonConfig(*seasonConfig); err != nil {
+			logrus.WithError(err).Fatalf("Failed to load season config")
+		}
+	}
+	if *aggregation != "" {
+		alternativeSeason.Formula.Aggregation = *aggregation
+	}
+	alternativeFormula, err := scoring.NewFormula(alternativeSeason)
+	if err != nil {
+		logrus.WithError(err).Fatalf("Invalid alternative formula")
+	}
+	baseline := replay.Scenario{Formula: baselineFormula}
+	alternative := replay.Scenario{Formula: alternativeFormula, RescoreTokens: *seasonConfig != ""}
+
+	storage, err := services.NewReadOnlyStorage(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to initialize storage")
+	}
+	defer func() {
+		if err := storage.Close(); err != nil {
+			logrus.WithError(err).Errorf("Failed to close storage")
+		}
+	}()
+
+	end := season.End
+	if end.IsZero() || end.After(time.Now()) {
+		end = time.Now()
+	}
+	jobs, err := storage.GetSuccessfulJobs(season.Start, end)
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to get jobs")
+	}
+	logrus.Infof("Replaying %d jobs of season %d", len(jobs), season.ID)
+
+	r := replay.NewReplay(baseline, alternative)
+	coins := make(map[uint]models.CoinDBModel)
+	for _, job := range jobs {
+		events, err := loadJobEvents(storage, job.ID, coins)
+		if err != nil {
+			logrus.WithError(err).Fatalf("Failed to load events of job %d", job.ID)
+		}
+		r.AddJob(events, coins)
+		logrus.Infof("Replayed job %s with %d events", job.Date(), len(events))
+	}
+
+	var vaults []models.Vault
+	startID := uint(0)
+	for {
+		page, err := storage.GetVaultsWithPage(startID, pageSize)
+		if err != nil {
+			logrus.WithError(err).Fatalf("Failed to get vaults")
+		}
+		if len(page) == 0 {
+			break
+		}
+		for _, vault := range page {
+			startID = vault.ID
+			if vault.JoinAirdrop {
+				vaults = append(vaults, vault)
+			}
+		}
+	}
+
+	report := r.Report(season.ID, vaults, *top)
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			logrus.WithError(err).Fatalf("Failed to create report file")
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "json" {
+		err = report.WriteJSON(w)
+	} else {
+		err = report.WriteCSV(w)
+	}
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to write report")
+	}
+	for _, d := range report.TopGainers {
+		logrus.Infof("gainer vault %d %s: rank %d -> %d", d.VaultID, d.Name, d.BaselineRank, d.AlternativeRank)
+	}
+	for _, d := range report.TopLosers {
+		logrus.Infof("loser vault %d %s: rank %d -> %d", d.VaultID, d.Name, d.BaselineRank, d.AlternativeRank)
+	}
+}
+
+// loadJobEvents returns all events of the job and adds the coins they reference to coins
+func loadJobEvents(storage *services.Storage, jobID uint, coins map[uint]models.CoinDBModel) ([]models.PointEvent, error) {
+	var events []models.PointEvent
+	startID := uint(0)
+	for {
+		page, err := storage.GetPointEventsByJob(jobID, startID, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			return events, nil
+		}
+		var missing []uint
+		for _, event := range page {
+			startID = event.ID
+			if _, ok := coins[event.CoinID]; event.CoinID != 0 && !ok {
+				missing = append(missing, event.CoinID)
+			}
+		}
+		found, err := storage.GetCoinsByIDs(missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, coin := range found {
+			coins[coin.ID] = coin
+		}
+		events = append(events, page...)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/vultisig/airdrop-registry/config"
 	"github.com/vultisig/airdrop-registry/internal/handlers"
 	"github.com/vultisig/airdrop-registry/internal/services"
+	"github.com/vultisig/airdrop-registry/internal/upstream"
 )
 
 func main() {
@@ -13,6 +14,7 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	upstream.Configure(cfg.Upstream)
 
 	storage, err := services.NewStorage(cfg)
 	if err != nil {
@@ -0,0 +1,60 @@
+// vaultaddresses backfills the vault_addresses table with the addresses of the vaults registered before
+// it existed, vaults whose addresses are already stored on every chain are skipped
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/services"
+)
+
+const pageSize = 1000
+
+func main() {
+	startID := flag.Uint("start-id", 0, "backfill the vaults with an id above this one")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to load config")
+	}
+	storage, err := services.NewStorage(cfg)
+	if err != nil {
+		logrus.WithError(err).Fatalf("Failed to initialize storage")
+	}
+	defer func() {
+		if err := storage.Close(); err != nil {
+			logrus.WithError(err).Errorf("Failed to close storage")
+		}
+	}()
+
+	vaultID := *startID
+	var count, failed int
+	for {
+		vaults, err := storage.GetVaultsWithPage(vaultID, pageSize)
+		if err != nil {
+			logrus.WithError(err).Fatalf("Failed to get vaults")
+		}
+		if len(vaults) == 0 {
+			break
+		}
+		for i, vault := range vaults {
+			vaultID = vault.ID
+			if _, err := storage.EnsureVaultAddresses(&vaults[i]); err != nil {
+				if !errors.Is(err, models.ErrDeriveAddress) {
+					logrus.WithError(err).Fatalf("Failed to store the addresses of vault %d", vault.ID)
+				}
+				logrus.WithError(err).Errorf("Failed to derive the addresses of vault %d", vault.ID)
+				failed++
+			}
+			count++
+		}
+		logrus.Infof("Backfilled %d vaults up to vault %d", count, vaultID)
+	}
+	logrus.Infof("Backfilled %d vaults, %d with chains failing to derive", count, failed)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -9,7 +10,11 @@ import (
 
 	"github.com/vultisig/airdrop-registry/config"
 	"github.com/vultisig/airdrop-registry/internal/balance"
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/handlers"
 	"github.com/vultisig/airdrop-registry/internal/services"
+	"github.com/vultisig/airdrop-registry/internal/tokens"
+	"github.com/vultisig/airdrop-registry/internal/upstream"
 	"github.com/vultisig/airdrop-registry/internal/volume"
 )
 
@@ -18,6 +23,7 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	upstream.Configure(cfg.Upstream)
 	storage, err := services.NewStorage(cfg)
 	if err != nil {
 		panic(err)
@@ -32,7 +38,8 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	balanceResolver, err := balance.NewBalanceResolver()
+	priceResolver.SetPriceHistory(storage)
+	balanceResolver, err := balance.NewBalanceResolver(cfg)
 	if err != nil {
 		panic(err)
 	}
@@ -40,10 +47,28 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
-	pointWorker, err := services.NewPointWorker(cfg, storage, priceResolver, balanceResolver, volumeTracker, referralResolver)
+	var discoveryServices map[common.Chain]tokens.AutoDiscoveryService
+	if cfg.Worker.Discovery.Enabled {
+		cmcService, err := tokens.NewCMCService()
+		if err != nil {
+			panic(err)
+		}
+		discoveryServices, err = tokens.NewAutoDiscoveryServices(context.Background(), cmcService)
+		if err != nil {
+			panic(err)
+		}
+	}
+	pointWorker, err := services.NewPointWorker(cfg, storage, priceResolver, balanceResolver, volumeTracker, referralResolver, discoveryServices)
 	if err != nil {
 		panic(err)
 	}
+	if cfg.Worker.Admin.Listen != "" {
+		adminApi, err := handlers.NewWorkerAdminApi(cfg, storage, pointWorker)
+		if err != nil {
+			panic(err)
+		}
+		pointWorker.Serve(cfg.Worker.Admin.Listen, adminApi.Handler())
+	}
 	if err := pointWorker.Run(); err != nil {
 		panic(err)
 	}
@@ -16,6 +16,8 @@ type Config struct {
 	Server struct {
 		Host string `mapstructure:"host"`
 		Port int    `mapstructure:"port"`
+		// ips or CIDRs of the proxies in front of the api, their forwarding headers give the client ip
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	}
 	MySQL struct {
 		Database string `mapstructure:"database"`
@@ -23,11 +25,25 @@ type Config struct {
 		Password string `mapstructure:"password"`
 		Host     string `mapstructure:"host"`
 		Port     int    `mapstructure:"port"`
+		// user of the tools that only read, such as cmd/replay, the user above when empty
+		ReadOnlyUser     string `mapstructure:"read_only_user"`
+		ReadOnlyPassword string `mapstructure:"read_only_password"`
 	}
 	Worker struct {
 		StartID int64 `mapstructure:"start_id"`
 		// we will have 2x concurrency workers (for active position and balance)
 		Concurrency int64 `mapstructure:"concurrency"`
+		// instances sharing a database split each job into shards of ShardSize ids
+		InstanceID   string `mapstructure:"instance_id"` // defaults to hostname and pid
+		ShardSize    int64  `mapstructure:"shard_size"`
+		LeaseSeconds int64  `mapstructure:"lease_seconds"` // shards not renewed within the lease can be taken over
+		// coins of the same EVM chain are sent to a balance worker in batches fetched through Multicall3
+		BalanceBatchSize int64 `mapstructure:"balance_batch_size"`
+		Admin            struct {
+			Listen string `mapstructure:"listen"` // address of the admin api, empty disables it
+			Token  string `mapstructure:"token"`  // bearer token the admin api requires
+		} `mapstructure:"admin"`
+		Discovery Discovery `mapstructure:"discovery"`
 	}
 	OpenSea struct {
 		APIKey string `mapstructure:"api_key"`
@@ -45,6 +61,137 @@ type Config struct {
 		TCMidgardXClientID string   `mapstructure:"tcmidgard_xclient_id"`
 		MayaMidgardBaseURL string   `mapstructure:"mayamidgard_base_url"`
 	}
+	Upstream      Upstream      `mapstructure:"upstream"`
+	Chains        []ChainConfig `mapstructure:"chains"` // endpoints and denoms replacing the built-in ones of a chain
+	StaleBalance  StaleBalance  `mapstructure:"stale_balance"`
+	AddressLookup AddressLookup `mapstructure:"address_lookup"`
+	Quests        []Quest       `mapstructure:"quests"` // partner quests, a quest stored in the quests table replaces the one with its slug
+	Pricing       Pricing       `mapstructure:"pricing"`
+}
+
+// Pricing routes the price update of every coin to its price sources, see the pricing package
+type Pricing struct {
+	DefaultSources []string     `mapstructure:"default_sources"` // sources of coins without a route, asked for the cmc id of the coin
+	Tolerance      float64      `mapstructure:"tolerance"`       // relative distance from the median within which prices agree
+	Routes         []PriceRoute `mapstructure:"routes"`
+	Guard          PriceGuard   `mapstructure:"guard"`
+}
+
+// PriceGuard keeps implausible prices out of the points. A price moving more than max_change from the last
+// good price of its asset is quarantined until an admin approves it, and a coin priced by its cmc id only
+// counts with the minimum market cap or 24h volume. Zero disables a check.
+type PriceGuard struct {
+	MaxChange    float64 `mapstructure:"max_change"`     // relative change, 0.5 for 50%
+	MinMarketCap float64 `mapstructure:"min_market_cap"` // USD
+	MinVolume    float64 `mapstructure:"min_volume"`     // 24h USD volume
+}
+
+// PriceRoute prices the coins with the ticker on the chain. The sources are tried in order until one
+// returns a price, with median all of them are asked and the median of the prices that agree is taken.
+type PriceRoute struct {
+	Chain   string             `mapstructure:"chain"`
+	Ticker  string             `mapstructure:"ticker"`
+	Median  bool               `mapstructure:"median"`
+	Sources []PriceRouteSource `mapstructure:"sources"`
+}
+
+type PriceRouteSource struct {
+	Source string `mapstructure:"source"` // cmc, coingecko, lifi, midgard, maya_midgard or fixed
+	ID     string `mapstructure:"id"`     // id of the asset at the source, the price itself for fixed
+}
+
+// Discovery configures the worker phase adding the tokens found on vault addresses to their coins
+type Discovery struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	MinValue     float64  `mapstructure:"min_value"`      // USD value of the holding
+	MinVolume    float64  `mapstructure:"min_volume"`     // 24h USD volume of the token
+	MinMarketCap float64  `mapstructure:"min_market_cap"` // USD market cap of the token
+	Allowlist    []string `mapstructure:"allowlist"`      // chain:contract tokens added whatever their market, besides the predefined tokens
+}
+
+// Quest is a partner campaign with its own verification endpoint, see models.Quest
+type Quest struct {
+	Slug             string    `mapstructure:"slug"`              // path segment of the verification endpoint
+	Rule             string    `mapstructure:"rule"`              // registered, registered_before, token_balance or swap_volume
+	Chain            string    `mapstructure:"chain"`             // chain of the verified addresses, Ethereum by default
+	Ticker           string    `mapstructure:"ticker"`            // token of the token_balance rule
+	ContractAddress  string    `mapstructure:"contract_address"`  // token of the token_balance rule, preferred over the ticker
+	MinAmount        float64   `mapstructure:"min_amount"`        // token amount or USD swap volume
+	RegisteredBefore time.Time `mapstructure:"registered_before"` // date of the registered_before rule
+	APIKeys          []string  `mapstructure:"api_keys"`          // callers need one of the keys or an allowed ip, open when both are empty and no points are awarded
+	AllowedIPs       []string  `mapstructure:"allowed_ips"`       // ips or CIDRs
+	Response         string    `mapstructure:"response"`          // text/template of the JSON response
+	Points           float64   `mapstructure:"points"`            // awarded once per vault on its first valid verification
+}
+
+const (
+	LookupPrivacyBoolean = "boolean" // a lookup only tells whether the address belongs to a vault
+	LookupPrivacyHashed  = "hashed"  // a lookup returns a keyed hash of the vault id
+	LookupPrivacyNone    = "none"    // a lookup returns the vault id
+)
+
+// AddressLookup is what the address lookup api reveals about the vault of an address
+type AddressLookup struct {
+	Privacy    string `mapstructure:"privacy"`     // boolean, hashed or none
+	HashSecret string `mapstructure:"hash_secret"` // key of the hashed vault ids, required by the hashed privacy
+}
+
+const (
+	StaleActionStop = "stop" // a stale value stops accruing points
+	StaleActionFlag = "flag" // a stale value keeps accruing points and flags its vault
+)
+
+// StaleBalance limits how long balances and positions that fail to refresh keep accruing points on their
+// last fetched value
+type StaleBalance struct {
+	MaxDays int    `mapstructure:"max_days"` // 0 to accrue on the last fetched value forever
+	Action  string `mapstructure:"action"`   // stop or flag once a value is stale for more than max_days
+}
+
+// Exceeded reports whether a value stale for the days is past the policy
+func (s StaleBalance) Exceeded(staleDays int) bool {
+	return s.MaxDays > 0 && staleDays > s.MaxDays
+}
+
+// Accrues reports whether a value stale for the days still accrues points
+func (s StaleBalance) Accrues(staleDays int) bool {
+	return !s.Exceeded(staleDays) || s.Action == StaleActionFlag
+}
+
+// ChainConfig lists the endpoints of a chain in the order they are tried, see the endpoints package,
+// and the denoms of a cosmos chain
+type ChainConfig struct {
+	Chain     string     `mapstructure:"chain"`     // chain name, e.g. Ethereum, BSC or THORChain
+	Endpoints []Endpoint `mapstructure:"endpoints"` // empty to keep the built-in endpoints
+	Denoms    []Denom    `mapstructure:"denoms"`
+}
+
+type Endpoint struct {
+	URL     string            `mapstructure:"url"`     // base url the paths of the chain's api are appended to
+	Headers map[string]string `mapstructure:"headers"` // sent with every request, e.g. an api key
+}
+
+// Denom is the metadata of a cosmos bank denom
+type Denom struct {
+	Denom    string `mapstructure:"denom"`    // e.g. ukuji, ibc/... or factory/...
+	Ticker   string `mapstructure:"ticker"`   // ticker of the native coins holding the denom, e.g. KUJI
+	Decimals int    `mapstructure:"decimals"` // 0 falls back to the decimals of the coin
+}
+
+// Upstream paces the calls to third party apis per host, see the upstream package
+type Upstream struct {
+	Default UpstreamHost   `mapstructure:"default"`
+	Hosts   []UpstreamHost `mapstructure:"hosts"` // hosts with their own limits, unset fields fall back to the default
+}
+
+type UpstreamHost struct {
+	Host               string  `mapstructure:"host"`                 // host name without port, e.g. api.etherscan.io
+	RequestsPerSecond  float64 `mapstructure:"requests_per_second"`  // 0 for no limit
+	Burst              int     `mapstructure:"burst"`                // requests sent at once before the rate applies, defaults to 1
+	MaxConcurrent      int     `mapstructure:"max_concurrent"`       // requests in flight, 0 for no cap
+	FailureThreshold   int     `mapstructure:"failure_threshold"`    // consecutive failures opening the circuit, 0 disables the breaker
+	OpenSeconds        int     `mapstructure:"open_seconds"`         // how long an open circuit rejects calls before letting one through
+	RateLimitedSeconds int     `mapstructure:"rate_limited_seconds"` // pause of the host after a 429 without Retry-After
 }
 
 type NFT struct {
@@ -66,6 +213,37 @@ type AirdropSeason struct {
 	Milestones []Milestone `mapstructure:"milestones" json:"milestones"` // list of vulti milestones
 	NFTs       []NFT       `mapstructure:"nfts" json:"nfts"`             // list of boosting NFTs
 	Tokens     []Token     `mapstructure:"tokens" json:"tokens"`         // list of boosting tokens
+	Formula    Formula     `mapstructure:"formula" json:"formula"`       // scoring formula, empty keeps the default
+	Sampling   Sampling    `mapstructure:"sampling" json:"sampling"`     // intra-day balance samples, empty keeps the single read of the job
+}
+
+// Sampling reads the balances of all coins at randomized times of every day besides the read of the job,
+// a job credits the time weighted average or the minimum of the samples taken since the previous job
+type Sampling struct {
+	Samples     int    `mapstructure:"samples" json:"samples"`         // samples per day, 0 to credit the read of the job only
+	Aggregation string `mapstructure:"aggregation" json:"aggregation"` // twap (default) or min
+}
+
+// Formula describes how a season turns vault values into points, see the scoring package
+type Formula struct {
+	Aggregation string         `mapstructure:"aggregation" json:"aggregation"` // sqrt (default), linear or log
+	Weights     FormulaWeights `mapstructure:"weights" json:"weights"`
+	Caps        FormulaCaps    `mapstructure:"caps" json:"caps"`
+}
+
+// FormulaWeights scale the components of the points, a missing weight defaults to 1
+type FormulaWeights struct {
+	Balance  *float64 `mapstructure:"balance" json:"balance,omitempty"`
+	LP       *float64 `mapstructure:"lp" json:"lp,omitempty"`
+	NFT      *float64 `mapstructure:"nft" json:"nft,omitempty"`
+	Volume   *float64 `mapstructure:"volume" json:"volume,omitempty"`     // scales the bonus of the swap volume multiplier
+	Referral *float64 `mapstructure:"referral" json:"referral,omitempty"` // scales the bonus of the referral multiplier
+}
+
+type FormulaCaps struct {
+	DailyValue         float64  `mapstructure:"daily_value" json:"daily_value"`                           // max weighted value a vault accrues per job, 0 for no cap
+	ReferralMultiplier *float64 `mapstructure:"referral_multiplier" json:"referral_multiplier,omitempty"` // defaults to 2
+	VolumeMultiplier   float64  `mapstructure:"volume_multiplier" json:"volume_multiplier"`               // 0 for no cap
 }
 type Milestone struct {
 	Minimum int `mapstructure:"minimum" json:"minimum"` // minimum amount of vulti to reach this milestone
@@ -82,6 +260,7 @@ func LoadConfig() (*Config, error) {
 
 	viper.SetDefault("server.port", 8080)
 	viper.SetDefault("server.host", "localhost")
+	viper.SetDefault("server.trusted_proxies", []string{})
 	viper.SetDefault("mysql.database", "airdrop")
 	viper.SetDefault("mysql.user", "root")
 	viper.SetDefault("mysql.password", "password")
@@ -89,6 +268,37 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("mysql.port", 3301)
 	viper.SetDefault("worker.start_id", 0)
 	viper.SetDefault("worker.concurrency", 10)
+	viper.SetDefault("worker.instance_id", "")
+	viper.SetDefault("worker.shard_size", 5000)
+	viper.SetDefault("worker.lease_seconds", 120)
+	viper.SetDefault("worker.balance_batch_size", 100)
+	viper.SetDefault("worker.admin.listen", "")
+	viper.SetDefault("worker.admin.token", "")
+	viper.SetDefault("upstream.default.requests_per_second", 0)
+	viper.SetDefault("upstream.default.burst", 1)
+	viper.SetDefault("upstream.default.max_concurrent", 0)
+	viper.SetDefault("upstream.default.failure_threshold", 10)
+	viper.SetDefault("upstream.default.open_seconds", 60)
+	viper.SetDefault("upstream.default.rate_limited_seconds", 30)
+	viper.SetDefault("upstream.hosts", []map[string]any{
+		{"host": "midgard.ninerealms.com", "requests_per_second": 1},
+		{"host": "midgard.mayachain.info", "requests_per_second": 1},
+	})
+	viper.SetDefault("pricing.default_sources", []string{"cmc"})
+	viper.SetDefault("pricing.tolerance", 0.05)
+	viper.SetDefault("pricing.guard.max_change", 0.5)
+	viper.SetDefault("pricing.routes", []map[string]any{
+		{"chain": "MayaChain", "ticker": "CACAO", "sources": []map[string]any{{"source": "maya_midgard"}}},
+		{"chain": "MayaChain", "ticker": "MAYA", "sources": []map[string]any{{"source": "fixed", "id": "40"}}},
+		{"chain": "Solana", "ticker": "KWEEN", "sources": []map[string]any{{"source": "coingecko", "id": "kween"}}},
+		{"chain": "Ethereum", "ticker": "vTHOR", "sources": []map[string]any{{"source": "lifi", "id": "eth:0x815C23eCA83261b6Ec689b60Cc4a58b54BC24D8D"}}},
+		{"chain": "THORChain", "ticker": "THOR.TCY", "sources": []map[string]any{{"source": "midgard", "id": "THOR.TCY"}}},
+		{"chain": "THORChain", "ticker": "RUJIRA", "sources": []map[string]any{{"source": "coingecko", "id": "rujira"}}},
+	})
+	viper.SetDefault("stale_balance.max_days", 7)
+	viper.SetDefault("stale_balance.action", StaleActionStop)
+	viper.SetDefault("address_lookup.privacy", LookupPrivacyBoolean)
+	viper.SetDefault("address_lookup.hash_secret", "")
 	viper.SetDefault("vultiref.api_key", "")
 	viper.SetDefault("vultiref.base_address", "")
 	viper.SetDefault("season.swap_multiplier", 1.6)
@@ -110,6 +320,18 @@ func LoadConfig() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("unable to decode into struct, %w", err)
 	}
+	if cfg.StaleBalance.Action != StaleActionStop && cfg.StaleBalance.Action != StaleActionFlag {
+		return nil, fmt.Errorf("invalid stale_balance.action %q, expected %s or %s", cfg.StaleBalance.Action, StaleActionStop, StaleActionFlag)
+	}
+	switch cfg.AddressLookup.Privacy {
+	case LookupPrivacyBoolean, LookupPrivacyNone:
+	case LookupPrivacyHashed:
+		if cfg.AddressLookup.HashSecret == "" {
+			return nil, fmt.Errorf("address_lookup.hash_secret is required by the %s privacy", LookupPrivacyHashed)
+		}
+	default:
+		return nil, fmt.Errorf("invalid address_lookup.privacy %q, expected %s, %s or %s", cfg.AddressLookup.Privacy, LookupPrivacyBoolean, LookupPrivacyHashed, LookupPrivacyNone)
+	}
 	return &cfg, nil
 }
 
@@ -123,3 +345,30 @@ func (cfg *Config) GetCurrentSeason() AirdropSeason {
 	}
 	return currentSeason
 }
+
+// GetSeason returns the season with the given id
+func (cfg *Config) GetSeason(id uint) (AirdropSeason, bool) {
+	for _, season := range cfg.Seasons {
+		if season.ID == id {
+			return season, true
+		}
+	}
+	return AirdropSeason{}, false
+}
+
+// LoadSeasonConfig reads a single season from a yaml file laid out like an entry of `seasons`
+func LoadSeasonConfig(path string) (AirdropSeason, error) {
+	var season AirdropSeason
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return season, fmt.Errorf("failed to read season config %s: %w", path, err)
+	}
+	err := v.Unmarshal(&season, func(dc *mapstructure.DecoderConfig) {
+		dc.DecodeHook = mapstructure.StringToTimeHookFunc(time.RFC3339)
+	})
+	if err != nil {
+		return season, fmt.Errorf("unable to decode season config, %w", err)
+	}
+	return season, nil
+}
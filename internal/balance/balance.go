@@ -1,172 +1,198 @@
 package balance
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
-	"time"
+	"sync/atomic"
 
 	"github.com/sirupsen/logrus"
 
+	"github.com/vultisig/airdrop-registry/config"
 	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/endpoints"
 	"github.com/vultisig/airdrop-registry/internal/models"
 )
 
-const (
-	maxRetries     = 10
-	initialBackoff = time.Second
-)
+// maxRetries bounds the attempts of a rate limited balance, the upstream limiter pauses the host in between
+const maxRetries = 10
 
 // BalanceResolver is to fetch address balances
 type BalanceResolver struct {
-	logger                   *logrus.Logger
-	thorchainBondProviders   *sync.Map
-	thorchainRuneProviders   *sync.Map
-	thornodeBaseAddress      string
-	tonBalanceBaseAddress    string
-	tronBalanceBaseAddress   string
-	xrpBalanceBaseAddress    string
-	kujiraBalanceBaseAddress string
-	vultisigApiProxy         string
-	whitelistNFTCollection   []models.NFTCollection
-	whiteListSPLToken        map[string]string
-	whiteListTRC20Token      map[string]int
+	logger                 *logrus.Logger
+	thorchainBondProviders *sync.Map
+	thorchainRuneProviders *sync.Map
+	chains                 *endpoints.Registry
+	denoms                 map[common.Chain][]config.Denom // cosmos denoms of the chains config
+	cosmosBalances         cosmosBalanceCache
+	assets                 *atomic.Pointer[models.AssetRegistry] // tracked assets, shared with the forks
 }
 
-func NewBalanceResolver() (*BalanceResolver, error) {
+func NewBalanceResolver(cfg *config.Config) (*BalanceResolver, error) {
+	chains, err := endpoints.NewRegistry(cfg.Chains, defaultEndpoints)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create chain endpoints: %w", err)
+	}
+	denoms, err := newCosmosDenoms(cfg.Chains)
+	if err != nil {
+		return nil, err
+	}
 	return &BalanceResolver{
-		logger:                   logrus.WithField("module", "balance_resolver").Logger,
-		thorchainBondProviders:   &sync.Map{},
-		thorchainRuneProviders:   &sync.Map{},
-		thornodeBaseAddress:      "https://thornode.ninerealms.com",
-		tonBalanceBaseAddress:    "https://api.vultisig.com/ton/v3/addressInformation",
-		tronBalanceBaseAddress:   "https://api.trongrid.io",
-		xrpBalanceBaseAddress:    "https://xrplcluster.com",
-		kujiraBalanceBaseAddress: "https://kujira-rest.publicnode.com/cosmos/bank/v1beta1/balances",
-		vultisigApiProxy:         "https://api.vultisig.com",
-		whitelistNFTCollection: []models.NFTCollection{
-			{
-				Chain:             common.Ethereum,
-				CollectionAddress: "0xa98b29a8f5a247802149c268ecf860b8308b7291",
-				CollectionSlug:    "thorguards",
-			},
-		},
-		whiteListSPLToken: map[string]string{
-			"DEf93bSt8dx58gDFCcz4CwbjYZzjwaRBYAciJYLfdCA9": "KWEEN",
-			"rndrizKT3MK1iimdxRdWabcF7Zg7AR5T4nud4EkHBof":  "RENDER",
-			"EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v": "USDC",
-			"Es9vMFrzaCERmJfrF4H2FYD4KCoNkY11McCe8BenwNYB": "USDT",
-			"JUPyiwrYJFskUPiHa7hkeR8VUtAeFoSYbKedZNsDvCN":  "JUP",
-			"FgWto1nfArQTpg3o74sYkti753caPfHNXHG8CkedDpMg": "DORITO",
-		},
-		whiteListTRC20Token: map[string]int{
-			"TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t": 6, // USDT
-		},
+		logger:                 logrus.WithField("module", "balance_resolver").Logger,
+		thorchainBondProviders: &sync.Map{},
+		thorchainRuneProviders: &sync.Map{},
+		chains:                 chains,
+		denoms:                 denoms,
+		assets:                 &atomic.Pointer[models.AssetRegistry]{},
 	}, nil
 }
 
-func (b *BalanceResolver) GetBalanceWithRetry(coin models.CoinDBModel) (float64, error) {
+func (b *BalanceResolver) GetBalanceWithRetry(ctx context.Context, coin models.CoinDBModel) (float64, error) {
 	var balance float64
-	var err error
+	err := b.withRetry(ctx, fmt.Sprintf("%s balance of %s", coin.Chain, coin.Address), func() error {
+		var err error
+		balance, err = b.GetBalance(ctx, coin)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return balance, nil
+}
 
+// GetBalancesWithRetry fetches the balances of the coins in their order. The native, ERC-20 and ERC-721
+// balances on an EVM chain are fetched together through Multicall3, the other coins one by one.
+func (b *BalanceResolver) GetBalancesWithRetry(ctx context.Context, coins []models.CoinDBModel) []BalanceResult {
+	results := make([]BalanceResult, len(coins))
+	evmIndexes := make(map[common.Chain][]int)
+	var evmChains []common.Chain
+	for i, coin := range coins {
+		if !slices.Contains(common.EVMChains, coin.Chain) {
+			balance, err := b.GetBalanceWithRetry(ctx, coin)
+			results[i] = BalanceResult{Coin: coin, Balance: balance, Err: err}
+			continue
+		}
+		if _, ok := evmIndexes[coin.Chain]; !ok {
+			evmChains = append(evmChains, coin.Chain)
+		}
+		evmIndexes[coin.Chain] = append(evmIndexes[coin.Chain], i)
+	}
+	for _, chain := range evmChains {
+		indexes := evmIndexes[chain]
+		chainCoins := make([]models.CoinDBModel, len(indexes))
+		for j, i := range indexes {
+			chainCoins[j] = coins[i]
+		}
+		var chainResults []BalanceResult
+		err := b.withRetry(ctx, fmt.Sprintf("%d balances on %s", len(chainCoins), chain), func() error {
+			var err error
+			chainResults, err = b.FetchEvmBalances(ctx, chain, chainCoins)
+			return err
+		})
+		for j, i := range indexes {
+			if err != nil {
+				results[i] = BalanceResult{Coin: coins[i], Err: err}
+				continue
+			}
+			results[i] = chainResults[j]
+		}
+	}
+	return results
+}
+
+// withRetry calls fn again while it is rate limited, the upstream limiter pauses the host in between
+func (b *BalanceResolver) withRetry(ctx context.Context, what string, fn func() error) error {
+	var err error
 	for i := 0; i < maxRetries; i++ {
-		balance, err = b.GetBalance(coin)
+		err = fn()
 		if err == nil {
-			return balance, nil
+			return nil
 		}
-
 		if !errors.Is(err, ErrRateLimited) {
-			return 0, err
+			return err
 		}
+		b.logger.Warnf("Rate limited fetching %s, attempt %d", what, i+1)
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("failed to get balance: %w", err)
+		}
+	}
+	return fmt.Errorf("failed to get balance after %d retries: %w", maxRetries, err)
+}
+
+// SetAssets replaces the tracked assets of the resolver and of its forks
+func (b *BalanceResolver) SetAssets(assets *models.AssetRegistry) {
+	b.assets.Store(assets)
+}
 
-		backoffDuration := initialBackoff * time.Duration(i)
-		b.logger.Warnf("Rate limited. Retrying in %s...", backoffDuration)
-		time.Sleep(backoffDuration)
+// registry returns the tracked assets, nil before they are set
+func (b *BalanceResolver) registry() *models.AssetRegistry {
+	if b.assets == nil {
+		return nil
 	}
+	return b.assets.Load()
+}
 
-	return 0, fmt.Errorf("failed to get balance after %d retries: %w", maxRetries, err)
+// isNFTCollection reports whether the contract is a tracked ERC-721 collection
+func (b *BalanceResolver) isNFTCollection(chain common.Chain, contractAddress string) bool {
+	return b.registry().IsNFTCollection(chain, contractAddress)
 }
 
-func (b *BalanceResolver) GetBalance(coin models.CoinDBModel) (float64, error) {
+func (b *BalanceResolver) GetBalance(ctx context.Context, coin models.CoinDBModel) (float64, error) {
+	if b.registry().Disabled(coin.Chain, coin.ContractAddress) {
+		return 0, nil
+	}
 	switch coin.Chain {
 	case common.Bitcoin, common.BitcoinCash, common.Litecoin, common.Dogecoin, common.Dash, common.Zcash:
-		balance, _, err := b.FetchUtxoBalanceOfAddress(coin.Address, coin.Chain)
+		balance, _, err := b.FetchUtxoBalanceOfAddress(ctx, coin.Address, coin.Chain)
 		return balance, err
 	case common.Arbitrum, common.Ethereum, common.Zksync, common.Optimism, common.Polygon, common.BscChain, common.Avalanche, common.Base, common.Blast, common.CronosChain:
 		if coin.ContractAddress != "" {
-			for _, nft := range b.whitelistNFTCollection {
-				if strings.EqualFold(coin.ContractAddress, nft.CollectionAddress) {
-					return b.fetchERC721TokenBalance(coin.Chain, coin.ContractAddress, coin.Address)
-				}
+			if b.isNFTCollection(coin.Chain, coin.ContractAddress) {
+				return b.fetchERC721TokenBalance(ctx, coin.Chain, coin.ContractAddress, coin.Address)
 			}
-			return b.fetchERC20TokenBalance(coin.Chain, coin.ContractAddress, coin.Address, int64(coin.Decimals))
+			return b.fetchERC20TokenBalance(ctx, coin.Chain, coin.ContractAddress, coin.Address, int64(coin.Decimals))
 		} else {
-			return b.FetchEvmBalanceOfAddress(coin.Chain, coin.Address)
+			return b.FetchEvmBalanceOfAddress(ctx, coin.Chain, coin.Address)
 		}
 	case common.THORChain:
-		return b.FetchThorchainBalanceOfAddress(coin.Address)
-	case common.MayaChain:
-		if strings.EqualFold(coin.Ticker, "maya") {
-			return b.FetchMayachainMayaBalanceOfAddress(coin.Address)
-		} else if strings.EqualFold(coin.Ticker, "cacao") {
-			return b.FetchMayachainCacoBalanceOfAddress(coin.Address)
-		}
-	case common.GaiaChain:
-		return b.FetchCosmosBalanceOfAddress(coin.Address)
-	case common.Dydx:
-		return b.FetchDydxBalanceOfAddress(coin.Address)
-	case common.Terra:
-		return b.FetchTerraBalanceOfAddress(coin.Address)
-	case common.TerraClassic:
-		return b.FetchTerraClassicBalanceOfAddress(coin.Address)
-	case common.Noble:
-		if strings.EqualFold(coin.Ticker, "USDC") { //  We only support USDC on Noble for now
-			return b.FetchNobleBalanceOfAddress(coin.Address)
+		if coin.ContractAddress == "" && strings.EqualFold(coin.Ticker, "rune") {
+			// pooled and bonded rune count as well
+			return b.FetchThorchainBalanceOfAddress(ctx, coin.Address)
 		}
-	case common.Kujira:
-		if coin.IsNative {
-			return b.FetchKujiraBalanceOfAddress(coin.Address, coin.Ticker, coin.Decimals)
-		} else {
-			return b.FetchKujiraBalanceOfAddress(coin.Address, coin.ContractAddress, coin.Decimals)
-		}
-	case common.Osmosis:
-		return b.FetchOsmosisBalanceOfAddress(coin.Address)
-	case common.Akash:
-		return b.FetchAkashBalanceOfAddress(coin.Address)
+		return b.FetchCosmosCoinBalance(ctx, coin)
+	case common.MayaChain, common.GaiaChain, common.Dydx, common.Terra, common.TerraClassic, common.Noble, common.Kujira, common.Osmosis, common.Akash:
+		return b.FetchCosmosCoinBalance(ctx, coin)
 	case common.Solana:
-		//ignore none native coins (spl tokens)
 		if coin.ContractAddress == "" {
-			return b.FetchSolanaBalanceOfAddress(coin.Address)
-		} else {
-			for addr, _ := range b.whiteListSPLToken {
-				if strings.EqualFold(coin.ContractAddress, addr) {
-					return b.FetchSPLBalanceOfAddress(coin.Address, coin.ContractAddress)
-				}
-			}
+			return b.FetchSolanaBalanceOfAddress(ctx, coin.Address)
+		}
+		// spl tokens count only while tracked
+		if !b.registry().Tracks(coin.Chain, coin.ContractAddress) {
 			return 0, nil
 		}
+		return b.FetchSPLBalanceOfAddress(ctx, coin.Address, coin.ContractAddress)
 	case common.Polkadot:
-		return b.FetchPolkadotBalanceOfAddress(coin.Address)
+		return b.FetchPolkadotBalanceOfAddress(ctx, coin.Address)
 	case common.Sui:
-		return b.FetchSuiBalanceOfAddress(coin.Address)
+		return b.FetchSuiBalanceOfAddress(ctx, coin.Address)
 	case common.Ton:
-		return b.FetchTonBalanceOfAddress(coin.Address)
+		return b.FetchTonBalanceOfAddress(ctx, coin.Address)
 	case common.XRP:
-		return b.FetchXRPBalanceOfAddress(coin.Address)
+		return b.FetchXRPBalanceOfAddress(ctx, coin.Address)
 	case common.Tron:
 		if coin.ContractAddress == "" { // TRX token
-			return b.FetchTronBalanceOfAddress(coin.Address, "", 6)
-		} else {
-			for addr, decimal := range b.whiteListTRC20Token {
-				if coin.ContractAddress == addr {
-					return b.FetchTronBalanceOfAddress(coin.Address, coin.ContractAddress, decimal)
-				}
-			}
+			return b.FetchTronBalanceOfAddress(ctx, coin.Address, "", 6)
+		}
+		// trc-20 tokens count only while tracked
+		asset, ok := b.registry().Lookup(coin.Chain, coin.ContractAddress)
+		if !ok || !asset.Enabled {
 			return 0, nil
 		}
+		return b.FetchTronBalanceOfAddress(ctx, coin.Address, coin.ContractAddress, asset.Decimals)
 	default:
 		return 0, fmt.Errorf("chain: %s doesn't support", coin.Chain)
 	}
-	return 0, nil
 }
@@ -1,68 +1,84 @@
 package balance
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/vultisig/airdrop-registry/config"
 	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/endpoints"
+	"github.com/vultisig/airdrop-registry/internal/models"
 )
 
+// mockEndpoints creates the endpoints of the chains pointing at the mock server
+func mockEndpoints(t *testing.T, url string, chains ...common.Chain) *endpoints.Registry {
+	urls := make(map[common.Chain][]string, len(chains))
+	for _, chain := range chains {
+		urls[chain] = []string{url}
+	}
+	registry, err := endpoints.NewRegistry(nil, urls)
+	assert.NoError(t, err)
+	return registry
+}
+
 func TestGetUtxoBalances(t *testing.T) {
 	t.Skip()
-	b, err := NewBalanceResolver()
+	b, err := NewBalanceResolver(&config.Config{})
 	assert.Nil(t, err)
-	result, err := b.FetchSolanaBalanceOfAddress("H7FmBYGBi5EmbJaKA88yBgmyGm7eSFdkzCtigwkeaXxb")
+	result, err := b.FetchSolanaBalanceOfAddress(context.Background(), "H7FmBYGBi5EmbJaKA88yBgmyGm7eSFdkzCtigwkeaXxb")
 	assert.Nil(t, err)
 	fmt.Println("Solana balance H7FmBYGBi5EmbJaKA88yBgmyGm7eSFdkzCtigwkeaXxb:", result)
 
-	result, err = b.FetchSuiBalanceOfAddress("0x156e6f6a3f8615008b79dd4871f658ec0da6d70a8540d9dd4d12023b8017e638")
+	result, err = b.FetchSuiBalanceOfAddress(context.Background(), "0x156e6f6a3f8615008b79dd4871f658ec0da6d70a8540d9dd4d12023b8017e638")
 	assert.Nil(t, err)
 	fmt.Println("SUI balance for 0x156e6f6a3f8615008b79dd4871f658ec0da6d70a8540d9dd4d12023b8017e638:", result)
 
-	result, err = b.FetchEvmBalanceOfAddress(common.Ethereum, "0x07773707BdA78aC4052f736544928b15dD31c5cc")
+	result, err = b.FetchEvmBalanceOfAddress(context.Background(), common.Ethereum, "0x07773707BdA78aC4052f736544928b15dD31c5cc")
 	assert.Nil(t, err)
 	fmt.Println("ETH balance for 0x07773707BdA78aC4052f736544928b15dD31c5cc:", result)
 
-	result, err = b.fetchERC20TokenBalance(common.Ethereum,
+	result, err = b.fetchERC20TokenBalance(context.Background(), common.Ethereum,
 		"0xdac17f958d2ee523a2206206994597c13d831ec7",
 		"0x07773707BdA78aC4052f736544928b15dD31c5cc", 6)
 	assert.Nil(t, err)
 	fmt.Println("USDT balance for 0x07773707BdA78aC4052f736544928b15dD31c5cc:", result)
-	balance, balanceUSD, err := b.FetchUtxoBalanceOfAddress("bc1qxpeg8k8xrygj9ae8q6pkzj29sf7w8e7krm4v5f", common.Bitcoin)
+	balance, balanceUSD, err := b.FetchUtxoBalanceOfAddress(context.Background(), "bc1qxpeg8k8xrygj9ae8q6pkzj29sf7w8e7krm4v5f", common.Bitcoin)
 	fmt.Println(balance)
 	fmt.Println(balanceUSD)
 
-	balance, err = b.FetchThorchainBalanceOfAddress("thor1tgxm5jw6hrlvslrd6lqpk4jwuu4g29dxytrean")
+	balance, err = b.FetchThorchainBalanceOfAddress(context.Background(), "thor1tgxm5jw6hrlvslrd6lqpk4jwuu4g29dxytrean")
 	assert.Nil(t, err)
 	fmt.Println("thor1tgxm5jw6hrlvslrd6lqpk4jwuu4g29dxytrean:", balance)
 
-	balance, err = b.FetchThorchainBalanceOfAddress("thor13amyx54c7z8vfhtd4fhghl30rz2v4t0hdsuk6w")
+	balance, err = b.FetchThorchainBalanceOfAddress(context.Background(), "thor13amyx54c7z8vfhtd4fhghl30rz2v4t0hdsuk6w")
 	assert.Nil(t, err)
 	fmt.Println("thor13amyx54c7z8vfhtd4fhghl30rz2v4t0hdsuk6w:", balance)
 
-	balance, err = b.FetchMayachainCacoBalanceOfAddress("maya1h5rlf94hqkvvkyzyhmmgw0hdtw200nqjmaymqc")
+	balance, err = b.FetchMayachainCacoBalanceOfAddress(context.Background(), "maya1h5rlf94hqkvvkyzyhmmgw0hdtw200nqjmaymqc")
 	assert.Nil(t, err)
 	fmt.Println("maya1h5rlf94hqkvvkyzyhmmgw0hdtw200nqjmaymqc:", balance)
 
-	balance, err = b.FetchMayachainCacoBalanceOfAddress("maya1vzltn37rqccwk95tny657au9j2z072dhg845dr")
+	balance, err = b.FetchMayachainCacoBalanceOfAddress(context.Background(), "maya1vzltn37rqccwk95tny657au9j2z072dhg845dr")
 	assert.Nil(t, err)
 	fmt.Println("maya1vzltn37rqccwk95tny657au9j2z072dhg845dr:", balance)
 
-	balance, err = b.FetchCosmosBalanceOfAddress("cosmos1jl8v454zpnjz76djzdydeq8gwk9364gjked53g")
+	balance, err = b.FetchCosmosBalanceOfAddress(context.Background(), "cosmos1jl8v454zpnjz76djzdydeq8gwk9364gjked53g")
 	assert.Nil(t, err)
 	fmt.Println("cosmos1jl8v454zpnjz76djzdydeq8gwk9364gjked53g:", balance)
 
-	balance, err = b.FetchDydxBalanceOfAddress("dydx1jl8v454zpnjz76djzdydeq8gwk9364gjlqrs3l")
+	balance, err = b.FetchDydxBalanceOfAddress(context.Background(), "dydx1jl8v454zpnjz76djzdydeq8gwk9364gjlqrs3l")
 	assert.Nil(t, err)
 	fmt.Println("dydx1jl8v454zpnjz76djzdydeq8gwk9364gjlqrs3l:", balance)
 
-	balance, err = b.FetchKujiraBalanceOfAddress("kujira153nnvyxz66sj4ywldvy0uexhdnwpfw9fyf4nkz", "ukuji", 6)
+	balance, err = b.FetchKujiraBalanceOfAddress(context.Background(), "kujira153nnvyxz66sj4ywldvy0uexhdnwpfw9fyf4nkz", "ukuji", 6)
 	assert.Nil(t, err)
 	fmt.Println("kujira153nnvyxz66sj4ywldvy0uexhdnwpfw9fyf4nkz", balance)
 }
@@ -138,11 +154,11 @@ func TestFetchUtxoBalances(t *testing.T) {
 			defer mockServer.Close()
 
 			resolver := &BalanceResolver{
-				logger:           logrus.WithField("module", "balance_resolver_test").Logger,
-				vultisigApiProxy: mockServer.URL,
+				logger: logrus.WithField("module", "balance_resolver_test").Logger,
+				chains: mockEndpoints(t, mockServer.URL, tt.chain),
 			}
 
-			balance, balanceUSD, err := resolver.FetchUtxoBalanceOfAddress(tt.address, tt.chain)
+			balance, balanceUSD, err := resolver.FetchUtxoBalanceOfAddress(context.Background(), tt.address, tt.chain)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -155,3 +171,24 @@ func TestFetchUtxoBalances(t *testing.T) {
 		})
 	}
 }
+
+func TestGetBalanceTrackedAssets(t *testing.T) {
+	b := &BalanceResolver{
+		logger: logrus.WithField("module", "balance_resolver_test").Logger,
+		assets: &atomic.Pointer[models.AssetRegistry]{},
+	}
+	b.SetAssets(models.NewAssetRegistry([]models.TrackedAsset{
+		{Chain: common.Solana, ContractAddress: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v", Kind: models.AssetKindToken, Decimals: 6},
+		{Chain: common.Ethereum, ContractAddress: "0xdac17f958d2ee523a2206206994597c13d831ec7", Kind: models.AssetKindToken, Decimals: 6},
+	}))
+	for _, coin := range []models.CoinBase{
+		{Chain: common.Solana, Address: "H7FmBYGBi5EmbJaKA88yBgmyGm7eSFdkzCtigwkeaXxb", ContractAddress: "JUPyiwrYJFskUPiHa7hkeR8VUtAeFoSYbKedZNsDvCN"},
+		{Chain: common.Solana, Address: "H7FmBYGBi5EmbJaKA88yBgmyGm7eSFdkzCtigwkeaXxb", ContractAddress: "EPjFWdd5AufqSSqeM2qN1xzybapC8G4wEGGkZwyTDt1v"},
+		{Chain: common.Tron, Address: "TNrTj7SizyxBd4G48cLhZeBvJtZgUaCq2D", ContractAddress: "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"},
+		{Chain: common.Ethereum, Address: "0x07773707BdA78aC4052f736544928b15dD31c5cc", ContractAddress: "0xdAC17F958D2ee523a2206206994597C13D831ec7", Decimals: 6},
+	} {
+		balance, err := b.GetBalance(context.Background(), models.CoinDBModel{CoinBase: coin})
+		assert.NoError(t, err)
+		assert.Zero(t, balance, "%s on %s", coin.ContractAddress, coin.Chain)
+	}
+}
@@ -1,20 +1,22 @@
 package balance
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
 	"net/http"
 	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
 )
 
-func (b *BalanceResolver) FetchThorchainBalanceOfAddress(address string) (float64, error) {
-	if address == "" {
-		return 0, fmt.Errorf("address cannot be empty")
-	}
-	url := fmt.Sprintf("%s/cosmos/bank/v1beta1/balances/%s", b.thornodeBaseAddress, address)
-	runeBalance, err := b.fetchSpecificCosmosBalance(url, "rune", 8)
+func (b *BalanceResolver) FetchThorchainBalanceOfAddress(ctx context.Context, address string) (float64, error) {
+	runeBalance, err := b.fetchNativeCosmosBalance(ctx, common.THORChain, address, "RUNE")
 	if err != nil {
 		return 0, fmt.Errorf("error fetching thorchain balance: %w", err)
 	}
@@ -53,17 +55,16 @@ type THORNode struct {
 }
 
 // GetTHORChainBondProviders fetches the bond providers from THORChain
-func (b *BalanceResolver) GetTHORChainBondProviders() error {
-	url := "https://thornode.ninerealms.com/thorchain/nodes"
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("error fetching bond providers from %s: %w", url, err)
-	}
-
-	defer b.closer(resp.Body)
+func (b *BalanceResolver) GetTHORChainBondProviders(ctx context.Context) error {
 	var nodes []THORNode
-	if err := json.NewDecoder(resp.Body).Decode(&nodes); err != nil {
-		return fmt.Errorf("error unmarshalling response: %w", err)
+	err := b.get(ctx, common.THORChain, "/thorchain/nodes", func(resp *http.Response) error {
+		if err := json.NewDecoder(resp.Body).Decode(&nodes); err != nil {
+			return fmt.Errorf("error unmarshalling response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error fetching bond providers: %w", err)
 	}
 	if len(nodes) == 0 {
 		return nil
@@ -104,17 +105,16 @@ type THORNodeRuneProviderResponse struct {
 	Value       int64  `json:"value,string"`
 }
 
-func (b *BalanceResolver) GetTHORChainRuneProviders() error {
-	url := fmt.Sprintf("%s/thorchain/rune_providers", b.thornodeBaseAddress)
-	resp, err := http.Get(url)
-	if err != nil {
-		return fmt.Errorf("error fetching bond providers from %s: %w", url, err)
-	}
-
-	defer b.closer(resp.Body)
+func (b *BalanceResolver) GetTHORChainRuneProviders(ctx context.Context) error {
 	var runeProviders []THORNodeRuneProviderResponse
-	if err := json.NewDecoder(resp.Body).Decode(&runeProviders); err != nil {
-		return fmt.Errorf("error unmarshalling response: %w", err)
+	err := b.get(ctx, common.THORChain, "/thorchain/rune_providers", func(resp *http.Response) error {
+		if err := json.NewDecoder(resp.Body).Decode(&runeProviders); err != nil {
+			return fmt.Errorf("error unmarshalling response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error fetching rune providers: %w", err)
 	}
 	//clear all the existing rune providers
 	b.thorchainRuneProviders.Range(func(k, v interface{}) bool {
@@ -142,53 +142,122 @@ func (b *BalanceResolver) GetLP(address string) (float64, error) {
 	return 0.0, nil
 }
 
-func (b *BalanceResolver) FetchMayachainCacoBalanceOfAddress(address string) (float64, error) {
-	url := fmt.Sprintf("https://mayanode.mayachain.info/cosmos/bank/v1beta1/balances/%s", address)
-	return b.fetchSpecificCosmosBalance(url, "cacao", 10)
+func (b *BalanceResolver) FetchMayachainCacoBalanceOfAddress(ctx context.Context, address string) (float64, error) {
+	return b.fetchNativeCosmosBalance(ctx, common.MayaChain, address, "CACAO")
 }
-func (b *BalanceResolver) FetchMayachainMayaBalanceOfAddress(address string) (float64, error) {
-	url := fmt.Sprintf("https://mayanode.mayachain.info/cosmos/bank/v1beta1/balances/%s", address)
-	return b.fetchSpecificCosmosBalance(url, "maya", 4)
+func (b *BalanceResolver) FetchMayachainMayaBalanceOfAddress(ctx context.Context, address string) (float64, error) {
+	return b.fetchNativeCosmosBalance(ctx, common.MayaChain, address, "MAYA")
 }
 
-func (b *BalanceResolver) FetchCosmosBalanceOfAddress(address string) (float64, error) {
-	url := fmt.Sprintf("https://cosmos-rest.publicnode.com/cosmos/bank/v1beta1/balances/%s", address)
-	return b.fetchSpecificCosmosBalance(url, "uatom", 6)
+func (b *BalanceResolver) FetchCosmosBalanceOfAddress(ctx context.Context, address string) (float64, error) {
+	return b.fetchNativeCosmosBalance(ctx, common.GaiaChain, address, "ATOM")
 }
 
-func (b *BalanceResolver) FetchKujiraBalanceOfAddress(address string, denom string, decimals int) (float64, error) {
-	url := fmt.Sprintf("%s/%s", b.kujiraBalanceBaseAddress, address)
-	return b.fetchSpecificCosmosBalance(url, denom, decimals)
+func (b *BalanceResolver) FetchKujiraBalanceOfAddress(ctx context.Context, address string, denom string, decimals int) (float64, error) {
+	return b.fetchSpecificCosmosBalance(ctx, common.Kujira, address, denom, decimals)
 }
 
-func (b *BalanceResolver) FetchOsmosisBalanceOfAddress(address string) (float64, error) {
-	url := fmt.Sprintf("https://osmosis-rest.publicnode.com/cosmos/bank/v1beta1/balances/%s", address)
-	return b.fetchSpecificCosmosBalance(url, "uosmo", 6)
+func (b *BalanceResolver) FetchOsmosisBalanceOfAddress(ctx context.Context, address string) (float64, error) {
+	return b.fetchNativeCosmosBalance(ctx, common.Osmosis, address, "OSMO")
 }
 
-func (b *BalanceResolver) FetchDydxBalanceOfAddress(address string) (float64, error) {
-	url := fmt.Sprintf("https://dydx-rest.publicnode.com/cosmos/bank/v1beta1/balances/%s", address)
-	return b.fetchSpecificCosmosBalance(url, "adydx", 18)
+func (b *BalanceResolver) FetchDydxBalanceOfAddress(ctx context.Context, address string) (float64, error) {
+	return b.fetchNativeCosmosBalance(ctx, common.Dydx, address, "DYDX")
 }
 
-func (b *BalanceResolver) FetchTerraBalanceOfAddress(address string) (float64, error) {
-	url := fmt.Sprintf("https://terra-lcd.publicnode.com/cosmos/bank/v1beta1/spendable_balances/%s", address)
-	return b.fetchSpecificCosmosBalance(url, "uluna", 6)
+func (b *BalanceResolver) FetchTerraBalanceOfAddress(ctx context.Context, address string) (float64, error) {
+	return b.fetchNativeCosmosBalance(ctx, common.Terra, address, "LUNA")
 }
 
-func (b *BalanceResolver) FetchTerraClassicBalanceOfAddress(address string) (float64, error) {
-	url := fmt.Sprintf("https://terra-classic-lcd.publicnode.com/cosmos/bank/v1beta1/spendable_balances/%s", address)
-	return b.fetchSpecificCosmosBalance(url, "uluna", 6)
+func (b *BalanceResolver) FetchTerraClassicBalanceOfAddress(ctx context.Context, address string) (float64, error) {
+	return b.fetchNativeCosmosBalance(ctx, common.TerraClassic, address, "LUNC")
 }
 
-func (b *BalanceResolver) FetchNobleBalanceOfAddress(address string) (float64, error) {
-	url := fmt.Sprintf("https://noble-api.polkachu.com/cosmos/bank/v1beta1/balances/%s", address)
-	return b.fetchSpecificCosmosBalance(url, "uusdc", 6)
+func (b *BalanceResolver) FetchNobleBalanceOfAddress(ctx context.Context, address string) (float64, error) {
+	return b.fetchNativeCosmosBalance(ctx, common.Noble, address, "USDC")
 }
 
-func (b *BalanceResolver) FetchAkashBalanceOfAddress(address string) (float64, error) {
-	url := fmt.Sprintf("https://akash-rest.publicnode.com/cosmos/bank/v1beta1/balances/%s", address)
-	return b.fetchSpecificCosmosBalance(url, "uakt", 6)
+func (b *BalanceResolver) FetchAkashBalanceOfAddress(ctx context.Context, address string) (float64, error) {
+	return b.fetchNativeCosmosBalance(ctx, common.Akash, address, "AKT")
+}
+
+// fetchNativeCosmosBalance returns the balance of the native coin of the chain with the ticker
+func (b *BalanceResolver) fetchNativeCosmosBalance(ctx context.Context, chain common.Chain, address, ticker string) (float64, error) {
+	return b.FetchCosmosCoinBalance(ctx, models.CoinDBModel{
+		CoinBase: models.CoinBase{Chain: chain, Address: address, Ticker: ticker},
+	})
+}
+
+// defaultDenoms are the native denoms of the cosmos chains, the denoms of the chains config come first
+var defaultDenoms = map[common.Chain][]config.Denom{
+	common.THORChain:    {{Denom: "rune", Ticker: "RUNE", Decimals: 8}},
+	common.MayaChain:    {{Denom: "cacao", Ticker: "CACAO", Decimals: 10}, {Denom: "maya", Ticker: "MAYA", Decimals: 4}},
+	common.GaiaChain:    {{Denom: "uatom", Ticker: "ATOM", Decimals: 6}},
+	common.Kujira:       {{Denom: "ukuji", Ticker: "KUJI", Decimals: 6}},
+	common.Osmosis:      {{Denom: "uosmo", Ticker: "OSMO", Decimals: 6}},
+	common.Dydx:         {{Denom: "adydx", Ticker: "DYDX", Decimals: 18}},
+	common.Terra:        {{Denom: "uluna", Ticker: "LUNA", Decimals: 6}},
+	common.TerraClassic: {{Denom: "uluna", Ticker: "LUNC", Decimals: 6}},
+	common.Noble:        {{Denom: "uusdc", Ticker: "USDC", Decimals: 6}},
+	common.Akash:        {{Denom: "uakt", Ticker: "AKT", Decimals: 6}},
+}
+
+// newCosmosDenoms reads the denoms of the chains config
+func newCosmosDenoms(chains []config.ChainConfig) (map[common.Chain][]config.Denom, error) {
+	denoms := make(map[common.Chain][]config.Denom)
+	for _, item := range chains {
+		if len(item.Denoms) == 0 {
+			continue
+		}
+		chain, err := common.ParseChain(item.Chain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chains config: %w", err)
+		}
+		for _, denom := range item.Denoms {
+			if denom.Denom == "" {
+				return nil, fmt.Errorf("invalid chains config: empty denom for chain %s", item.Chain)
+			}
+			denoms[chain] = append(denoms[chain], denom)
+		}
+	}
+	return denoms, nil
+}
+
+// findDenom returns the first configured or built-in denom of the chain matching
+func (b *BalanceResolver) findDenom(chain common.Chain, match func(denom config.Denom) bool) (config.Denom, bool) {
+	for _, denoms := range [][]config.Denom{b.denoms[chain], defaultDenoms[chain]} {
+		for _, denom := range denoms {
+			if match(denom) {
+				return denom, true
+			}
+		}
+	}
+	return config.Denom{}, false
+}
+
+// cosmosDenom returns the bank denom of the coin and its decimals. The denom of a token is its contract
+// address, a native coin is matched by ticker. Denoms without decimals in the chains config take the
+// decimals of the coin.
+func (b *BalanceResolver) cosmosDenom(coin models.CoinDBModel) (string, int) {
+	denom := coin.ContractAddress
+	if denom == "" {
+		denom = coin.Ticker
+		if native, ok := b.findDenom(coin.Chain, func(d config.Denom) bool { return strings.EqualFold(d.Ticker, coin.Ticker) }); ok {
+			denom = native.Denom
+		}
+	}
+	metadata, ok := b.findDenom(coin.Chain, func(d config.Denom) bool { return strings.EqualFold(d.Denom, denom) })
+	if ok && metadata.Decimals > 0 {
+		return metadata.Denom, metadata.Decimals
+	}
+	return denom, coin.Decimals
+}
+
+// FetchCosmosCoinBalance returns the balance of any bank denom of a cosmos chain, native, IBC or factory,
+// from the balances of its address
+func (b *BalanceResolver) FetchCosmosCoinBalance(ctx context.Context, coin models.CoinDBModel) (float64, error) {
+	denom, decimals := b.cosmosDenom(coin)
+	return b.fetchSpecificCosmosBalance(ctx, coin.Chain, coin.Address, denom, decimals)
 }
 
 type CosmosData struct {
@@ -198,43 +267,127 @@ type CosmosData struct {
 	} `json:"balances"`
 }
 
-func (b *BalanceResolver) fetchSpecificCosmosBalance(url, denom string, decimals int) (float64, error) {
-	if url == "" {
-		return 0, fmt.Errorf("url cannot be empty")
+func cosmosBalancesPath(chain common.Chain, address string) string {
+	switch chain {
+	case common.Terra, common.TerraClassic:
+		return "/cosmos/bank/v1beta1/spendable_balances/" + address
+	default:
+		return "/cosmos/bank/v1beta1/balances/" + address
+	}
+}
+
+func (b *BalanceResolver) fetchSpecificCosmosBalance(ctx context.Context, chain common.Chain, address, denom string, decimals int) (float64, error) {
+	if address == "" {
+		return 0, fmt.Errorf("address cannot be empty")
 	}
 	if denom == "" {
 		return 0, fmt.Errorf("denom cannot be empty")
 	}
-	resp, err := http.Get(url)
+	balances, err := b.cosmosBalances.get(ctx, chain.String()+"/"+address, func() (map[string]float64, error) {
+		return b.fetchCosmosBalances(ctx, chain, address)
+	})
 	if err != nil {
-		return 0, fmt.Errorf("error fetching balance from %s: %w", url, err)
-	}
-	defer b.closer(resp.Body)
-	if resp.StatusCode == http.StatusTooManyRequests {
-		// rate limited, need to backoff and then retry
-		return 0, ErrRateLimited
-	}
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("error fetching balance from %s: %s", url, resp.Status)
+		return 0, err
 	}
+	return balances[strings.ToLower(denom)] / math.Pow10(decimals), nil
+}
+
+// fetchCosmosBalances fetches the amounts of every denom the address holds
+func (b *BalanceResolver) fetchCosmosBalances(ctx context.Context, chain common.Chain, address string) (map[string]float64, error) {
 	var result CosmosData
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("error unmarshalling response: %w", err)
+	err := b.get(ctx, chain, cosmosBalancesPath(chain, address), func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			// rate limited, need to backoff and then retry
+			return ErrRateLimited
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("error fetching balance from %s: %s", resp.Request.URL, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("error unmarshalling response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s balance: %w", chain, err)
+	}
+	balances := make(map[string]float64, len(result.Balances))
+	for _, b := range result.Balances {
+		amount, err := strconv.ParseFloat(b.Amount, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error converting balance to float: %v", err)
+		}
+		balances[strings.ToLower(b.Denom)] = amount
 	}
+	return balances, nil
+}
+
+// cosmosBalanceCache holds the balances of the cosmos addresses fetched during the current job, so the
+// coins of an address share one call
+type cosmosBalanceCache struct {
+	mu      sync.Mutex
+	entries map[string]*cosmosBalanceEntry
+}
+
+type cosmosBalanceEntry struct {
+	done     chan struct{}
+	balances map[string]float64 // amounts by lower case denom
+	err      error
+}
 
-	var balance float64
-	if len(result.Balances) == 0 {
-		return 0, nil
+// get returns the balances of the key, concurrent callers wait for a single fetch. A failed fetch is not
+// kept, the next caller fetches again.
+func (c *cosmosBalanceCache) get(ctx context.Context, key string, fetch func() (map[string]float64, error)) (map[string]float64, error) {
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[string]*cosmosBalanceEntry)
 	}
-	for _, b := range result.Balances {
-		if strings.EqualFold(b.Denom, denom) {
-			balance, err = strconv.ParseFloat(b.Amount, 64)
-			if err != nil {
-				return 0, fmt.Errorf("error converting balance to float: %v", err)
-			}
-			break
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cosmosBalanceEntry{done: make(chan struct{})}
+		c.entries[key] = entry
+	}
+	c.mu.Unlock()
+	if ok {
+		select {
+		case <-entry.done:
+			return entry.balances, entry.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
 	}
+	entry.balances, entry.err = fetch()
+	if entry.err != nil {
+		c.mu.Lock()
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+	}
+	close(entry.done)
+	return entry.balances, entry.err
+}
 
-	return balance / math.Pow10(decimals), nil
+func (c *cosmosBalanceCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = nil
+}
+
+// ResetCosmosBalances drops the cosmos balances fetched during the previous job
+func (b *BalanceResolver) ResetCosmosBalances() {
+	b.cosmosBalances.reset()
+}
+
+// Fork returns a resolver sharing the endpoints and thorchain providers with its own cosmos balances, so
+// balances sampled between jobs neither reuse nor replace the balances fetched by the job
+func (b *BalanceResolver) Fork() *BalanceResolver {
+	return &BalanceResolver{
+		logger:                 b.logger,
+		thorchainBondProviders: b.thorchainBondProviders,
+		thorchainRuneProviders: b.thorchainRuneProviders,
+		chains:                 b.chains,
+		denoms:                 b.denoms,
+		assets:                 b.assets,
+	}
 }
@@ -1,14 +1,17 @@
 package balance
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/vultisig/airdrop-registry/config"
 	"github.com/vultisig/airdrop-registry/internal/common"
 	"github.com/vultisig/airdrop-registry/internal/models"
 )
@@ -37,23 +40,25 @@ func TestFetchThorchainBalanceOfAddress(t *testing.T) {
 	// Create a LiquidityPositionResolver instance
 	balanceResolver := &BalanceResolver{
 		logger:                 logrus.WithField("module", "balance_resolver_test").Logger,
-		thornodeBaseAddress:    mockServer.URL,
+		chains:                 mockEndpoints(t, mockServer.URL, common.THORChain),
 		thorchainRuneProviders: &sync.Map{},
 		thorchainBondProviders: &sync.Map{},
 	}
 	balanceResolver.thorchainRuneProviders.Store("thor2rjxghep6g3j3z0k3jwz3wzrj3z0k3jwz3wzrj", int64(1200000000))
 	balanceResolver.thorchainBondProviders.Store("thor2rjxghep6g3j3z0k3jwz3wzrj3z0k3jwz3wzrj", "2000000000")
-	balance, err := balanceResolver.FetchThorchainBalanceOfAddress("thor2rjxghep6g3j3z0k3jwz3wzrj3z0k3jwz3wzrj")
+	balance, err := balanceResolver.FetchThorchainBalanceOfAddress(context.Background(), "thor2rjxghep6g3j3z0k3jwz3wzrj3z0k3jwz3wzrj")
 	assert.NoErrorf(t, err, "Failed to get thorchain rune providers: %v", err)
 	assert.Equal(t, float64(57), balance)
 
-	balance, err = balanceResolver.FetchThorchainBalanceOfAddress("thor2")
+	balance, err = balanceResolver.FetchThorchainBalanceOfAddress(context.Background(), "thor2")
 	assert.NoErrorf(t, err, "Failed to get thorchain rune providers: %v", err)
 	assert.Equal(t, float64(25), balance)
 }
 
 func TestFetchKujiraBalanceOfAddress(t *testing.T) {
+	var requests atomic.Int64
 	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
 		response := map[string]interface{}{
 			"balances": []map[string]interface{}{
 				{
@@ -62,11 +67,14 @@ func TestFetchKujiraBalanceOfAddress(t *testing.T) {
 				}, {
 					"denom":  "ukuji",
 					"amount": "3000000",
+				}, {
+					"denom":  "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2",
+					"amount": "1500000",
 				},
 			},
 			"pagination": map[string]interface{}{
 				"next_key": nil,
-				"total":    "2",
+				"total":    "3",
 			},
 		}
 		w.WriteHeader(http.StatusOK)
@@ -75,10 +83,19 @@ func TestFetchKujiraBalanceOfAddress(t *testing.T) {
 
 	defer mockServer.Close()
 
-	balanceResolver, err := NewBalanceResolver()
+	balanceResolver, err := NewBalanceResolver(&config.Config{
+		Chains: []config.ChainConfig{
+			{
+				Chain:     "Kujira",
+				Endpoints: []config.Endpoint{{URL: mockServer.URL}},
+				Denoms: []config.Denom{
+					{Denom: "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", Decimals: 6},
+				},
+			},
+		},
+	})
 	assert.NoError(t, err, "Failed to create balance resolver")
-	balanceResolver.kujiraBalanceBaseAddress = mockServer.URL
-	balance, err := balanceResolver.GetBalance(models.CoinDBModel{
+	balance, err := balanceResolver.GetBalance(context.Background(), models.CoinDBModel{
 		CoinBase: models.CoinBase{
 			Chain:           common.Kujira,
 			Ticker:          "uusk",
@@ -91,7 +108,7 @@ func TestFetchKujiraBalanceOfAddress(t *testing.T) {
 	assert.NoErrorf(t, err, "Failed to get kujira balance: %v", err)
 	assert.Equal(t, float64(0.24), balance, "Balance does not match expected value")
 
-	balance, err = balanceResolver.GetBalance(models.CoinDBModel{
+	balance, err = balanceResolver.GetBalance(context.Background(), models.CoinDBModel{
 		CoinBase: models.CoinBase{
 			Chain:    common.Kujira,
 			Ticker:   "ukuji",
@@ -102,6 +119,54 @@ func TestFetchKujiraBalanceOfAddress(t *testing.T) {
 	})
 	assert.NoErrorf(t, err, "Failed to get kujira balance: %v", err)
 	assert.Equal(t, float64(3), balance, "Balance does not match expected value")
+
+	// the decimals of an ibc denom come from the chains config
+	balance, err = balanceResolver.GetBalance(context.Background(), models.CoinDBModel{
+		CoinBase: models.CoinBase{
+			Chain:           common.Kujira,
+			Ticker:          "ATOM",
+			Address:         "kujira1qk00h5atutpsv900x202pxx42npjr9thg58dnqpa72f2p7m2luase444a7",
+			ContractAddress: "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2",
+		},
+	})
+	assert.NoErrorf(t, err, "Failed to get kujira balance: %v", err)
+	assert.Equal(t, float64(1.5), balance, "Balance does not match expected value")
+	// the coins of the address share one call
+	assert.Equal(t, int64(1), requests.Load())
+
+	balanceResolver.ResetCosmosBalances()
+	_, err = balanceResolver.GetBalance(context.Background(), models.CoinDBModel{
+		CoinBase: models.CoinBase{
+			Chain:    common.Kujira,
+			Ticker:   "KUJI",
+			Address:  "kujira1qk00h5atutpsv900x202pxx42npjr9thg58dnqpa72f2p7m2luase444a7",
+			IsNative: true,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), requests.Load())
+
+	// a fork fetches the address again and leaves the balances of the resolver alone
+	_, err = balanceResolver.Fork().GetBalance(context.Background(), models.CoinDBModel{
+		CoinBase: models.CoinBase{
+			Chain:    common.Kujira,
+			Ticker:   "KUJI",
+			Address:  "kujira1qk00h5atutpsv900x202pxx42npjr9thg58dnqpa72f2p7m2luase444a7",
+			IsNative: true,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), requests.Load())
+	_, err = balanceResolver.GetBalance(context.Background(), models.CoinDBModel{
+		CoinBase: models.CoinBase{
+			Chain:    common.Kujira,
+			Ticker:   "KUJI",
+			Address:  "kujira1qk00h5atutpsv900x202pxx42npjr9thg58dnqpa72f2p7m2luase444a7",
+			IsNative: true,
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), requests.Load())
 }
 
 func TestGetTHORChainRuneProviders(t *testing.T) {
@@ -138,10 +203,10 @@ func TestGetTHORChainRuneProviders(t *testing.T) {
 	// Create a LiquidityPositionResolver instance
 	balanceResolver := &BalanceResolver{
 		logger:                 logrus.WithField("module", "balance_resolver_test").Logger,
-		thornodeBaseAddress:    mockServer.URL,
+		chains:                 mockEndpoints(t, mockServer.URL, common.THORChain),
 		thorchainRuneProviders: &sync.Map{},
 	}
-	err := balanceResolver.GetTHORChainRuneProviders()
+	err := balanceResolver.GetTHORChainRuneProviders(context.Background())
 	assert.NoErrorf(t, err, "Failed to get thorchain rune providers: %v", err)
 
 	value, ok := balanceResolver.thorchainRuneProviders.Load("thor1cfzgzg02cp7yjrkagzdrdp7dqh0xlsdhawwjc")
@@ -174,8 +239,9 @@ func TestFetchTerraBalanceOfAddress(t *testing.T) {
 	// Create a LiquidityPositionResolver instance
 	balanceResolver := &BalanceResolver{
 		logger: logrus.WithField("module", "balance_resolver_test").Logger,
+		chains: mockEndpoints(t, mockServer.URL, common.Terra),
 	}
-	balance, err := balanceResolver.fetchSpecificCosmosBalance(mockServer.URL+"/cosmos/bank/v1beta1/spendable_balances/"+"terra1fl48vsnmsdzcv85q5d2q4z5ajdha8yu3nln0mh", "uluna", 6)
+	balance, err := balanceResolver.fetchSpecificCosmosBalance(context.Background(), common.Terra, "terra1fl48vsnmsdzcv85q5d2q4z5ajdha8yu3nln0mh", "uluna", 6)
 	assert.NoErrorf(t, err, "Failed to get thorchain rune providers: %v", err)
 	assert.Equal(t, float64(2500), balance)
 }
@@ -204,8 +270,9 @@ func TestFetchAkashBalanceOfAddress(t *testing.T) {
 	// Create a LiquidityPositionResolver instance
 	balanceResolver := &BalanceResolver{
 		logger: logrus.WithField("module", "balance_resolver_test").Logger,
+		chains: mockEndpoints(t, mockServer.URL, common.Akash),
 	}
-	balance, err := balanceResolver.fetchSpecificCosmosBalance(mockServer.URL+"/cosmos/bank/v1beta1/spendable_balances/"+"akash1ysywap8nllx5fn9had5qhywktnweuquv4hepyp", "uakt", 6)
+	balance, err := balanceResolver.fetchSpecificCosmosBalance(context.Background(), common.Akash, "akash1ysywap8nllx5fn9had5qhywktnweuquv4hepyp", "uakt", 6)
 	assert.NoErrorf(t, err, "Failed to get akash address balance: %v", err)
 	assert.Equal(t, float64(540733), balance)
 }
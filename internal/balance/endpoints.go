@@ -0,0 +1,74 @@
+package balance
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/endpoints"
+)
+
+// defaultEndpoints are the endpoints of the chains the chains config doesn't list
+var defaultEndpoints = map[common.Chain][]string{
+	common.Ethereum:     {"https://ethereum-rpc.publicnode.com"},
+	common.Avalanche:    {"https://avalanche-c-chain-rpc.publicnode.com"},
+	common.BscChain:     {"https://bsc-rpc.publicnode.com"},
+	common.Base:         {"https://base-rpc.publicnode.com"},
+	common.Blast:        {"https://rpc.ankr.com/blast"},
+	common.Optimism:     {"https://optimism-rpc.publicnode.com"},
+	common.Polygon:      {"https://polygon-bor-rpc.publicnode.com"},
+	common.Zksync:       {"https://mainnet.era.zksync.io"},
+	common.CronosChain:  {"https://cronos-evm-rpc.publicnode.com"},
+	common.Arbitrum:     {"https://arbitrum-one-rpc.publicnode.com"},
+	common.THORChain:    {"https://thornode.ninerealms.com"},
+	common.MayaChain:    {"https://mayanode.mayachain.info"},
+	common.GaiaChain:    {"https://cosmos-rest.publicnode.com"},
+	common.Kujira:       {"https://kujira-rest.publicnode.com"},
+	common.Osmosis:      {"https://osmosis-rest.publicnode.com"},
+	common.Dydx:         {"https://dydx-rest.publicnode.com"},
+	common.Terra:        {"https://terra-lcd.publicnode.com"},
+	common.TerraClassic: {"https://terra-classic-lcd.publicnode.com"},
+	common.Noble:        {"https://noble-api.polkachu.com"},
+	common.Akash:        {"https://akash-rest.publicnode.com"},
+	common.Solana:       {"https://api.vultisig.com/solana"},
+	common.Polkadot:     {"https://polkadot.api.subscan.io"},
+	common.Sui:          {"https://sui-rpc.publicnode.com"},
+	common.Ton:          {"https://api.vultisig.com/ton"},
+	common.Tron:         {"https://api.trongrid.io"},
+	common.XRP:          {"https://xrplcluster.com"},
+	common.Bitcoin:      {"https://api.vultisig.com/blockchair/bitcoin"},
+	common.BitcoinCash:  {"https://api.vultisig.com/blockchair/bitcoin-cash"},
+	common.Dash:         {"https://api.vultisig.com/blockchair/dash"},
+	common.Litecoin:     {"https://api.vultisig.com/blockchair/litecoin"},
+	common.Dogecoin:     {"https://api.vultisig.com/blockchair/dogecoin"},
+	common.Zcash:        {"https://api.vultisig.com/blockchair/zcash"},
+}
+
+// get sends a GET of the path to the endpoints of the chain one after the other until handle accepts a response
+func (b *BalanceResolver) get(ctx context.Context, chain common.Chain, path string, handle func(resp *http.Response) error) error {
+	return b.chains.Do(ctx, chain, func(e *endpoints.Endpoint) error {
+		resp, err := e.Get(ctx, path)
+		if err != nil {
+			return err
+		}
+		defer b.closer(resp.Body)
+		return handle(resp)
+	})
+}
+
+// post sends a json POST of the path to the endpoints of the chain one after the other until handle accepts a response
+func (b *BalanceResolver) post(ctx context.Context, chain common.Chain, path string, body []byte, handle func(resp *http.Response) error) error {
+	return b.chains.Do(ctx, chain, func(e *endpoints.Endpoint) error {
+		resp, err := e.Post(ctx, path, "application/json", body)
+		if err != nil {
+			return err
+		}
+		defer b.closer(resp.Body)
+		return handle(resp)
+	})
+}
+
+// EndpointStats reports the health of the endpoints of every chain
+func (b *BalanceResolver) EndpointStats() []endpoints.Stats {
+	return b.chains.Stats()
+}
@@ -1,7 +1,7 @@
 package balance
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,38 +10,7 @@ import (
 	"github.com/vultisig/airdrop-registry/internal/utils"
 )
 
-func (b *BalanceResolver) getRpcUrlForChain(chain common.Chain) (string, error) {
-	switch chain {
-	case common.Ethereum:
-		return "https://ethereum-rpc.publicnode.com", nil
-	case common.Avalanche:
-		return "https://avalanche-c-chain-rpc.publicnode.com", nil
-	case common.BscChain:
-		return "https://bsc-rpc.publicnode.com", nil
-	case common.Base:
-		return "https://base-rpc.publicnode.com", nil
-	case common.Blast:
-		return "https://rpc.ankr.com/blast", nil
-	case common.Optimism:
-		return "https://optimism-rpc.publicnode.com", nil
-	case common.Polygon:
-		return "https://polygon-bor-rpc.publicnode.com", nil
-	case common.Zksync:
-		return "https://mainnet.era.zksync.io", nil
-	case common.CronosChain:
-		return "https://cronos-evm-rpc.publicnode.com", nil
-	case common.Arbitrum:
-		return "https://arbitrum-one-rpc.publicnode.com", nil
-	default:
-		return "", fmt.Errorf("chain: %s doesn't support", chain)
-	}
-}
-
-func (b *BalanceResolver) FetchEvmBalanceOfAddress(chain common.Chain, address string) (float64, error) {
-	rpcUrl, err := b.getRpcUrlForChain(chain)
-	if err != nil {
-		return 0, fmt.Errorf("error getting rpc url for chain %s: %w", chain, err)
-	}
+func (b *BalanceResolver) FetchEvmBalanceOfAddress(ctx context.Context, chain common.Chain, address string) (float64, error) {
 	// Create parameters array
 	params := []interface{}{
 		address,
@@ -59,26 +28,27 @@ func (b *BalanceResolver) FetchEvmBalanceOfAddress(chain common.Chain, address s
 	if err != nil {
 		return 0, fmt.Errorf("error marshalling RPC request: %w", err)
 	}
-	resp, err := http.Post(rpcUrl, "application/json", bytes.NewBuffer(buf))
-	if err != nil {
-		return 0, fmt.Errorf("error fetching balance of address %s on %s: %w", address, chain, err)
-	}
-	defer b.closer(resp.Body)
-	if resp.StatusCode == http.StatusTooManyRequests {
-		// rate limited, need to backoff and then retry
-		return 0, ErrRateLimited
-	}
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("error fetching balance of address %s on %s: %s", address, chain, resp.Status)
-	}
 	type EthBalanceResult struct {
 		Jsonrpc string `json:"jsonrpc"`
 		Id      int    `json:"id"`
 		Result  string `json:"result"`
 	}
 	var result EthBalanceResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("error decoding response: %w", err)
+	err = b.post(ctx, chain, "", buf, func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			// rate limited, need to backoff and then retry
+			return ErrRateLimited
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("error fetching balance of address %s on %s: %s", address, chain, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("error decoding response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error fetching balance of address %s on %s: %w", address, chain, err)
 	}
 
 	balance, err := utils.HexToFloat64(result.Result, 18)
@@ -0,0 +1,195 @@
+package balance
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/utils"
+)
+
+const (
+	// Multicall3 is deployed at the same address on every supported chain except zkSync Era
+	multicall3Address       = "0xcA11bde05977b3631167028862bE2a173976CA11"
+	zksyncMulticall3Address = "0xF9cda624FBC7e059355ce98a31693d299FACd963"
+	// maxMulticallCalls bounds the calls of a single aggregate3 so it stays below the gas limit of eth_call
+	maxMulticallCalls = 500
+)
+
+const multicall3ABI = `[
+	{"name":"aggregate3","type":"function","stateMutability":"payable",
+	 "inputs":[{"name":"calls","type":"tuple[]","components":[{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],
+	 "outputs":[{"name":"returnData","type":"tuple[]","components":[{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]},
+	{"name":"getEthBalance","type":"function","stateMutability":"view",
+	 "inputs":[{"name":"addr","type":"address"}],
+	 "outputs":[{"name":"balance","type":"uint256"}]}
+]`
+
+// balanceOfSelector is the function signature hash of `balanceOf(address)`, the same for ERC-20 and ERC-721
+var balanceOfSelector = []byte{0x70, 0xa0, 0x82, 0x31}
+
+var multicall3 = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(multicall3ABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid multicall3 abi: %v", err))
+	}
+	return parsed
+}()
+
+// BalanceResult is the balance of a coin fetched in a batch, Err is set when the balance of this coin failed
+type BalanceResult struct {
+	Coin    models.CoinDBModel
+	Balance float64
+	Err     error
+}
+
+type call3 struct {
+	Target       ethcommon.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+type call3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+func multicallAddressForChain(chain common.Chain) ethcommon.Address {
+	if chain == common.Zksync {
+		return ethcommon.HexToAddress(zksyncMulticall3Address)
+	}
+	return ethcommon.HexToAddress(multicall3Address)
+}
+
+// FetchEvmBalances fetches the native, ERC-20 and ERC-721 balances of the coins on the chain through
+// Multicall3 aggregate3 calls. A coin whose call fails gets its own error, the returned error means the
+// whole batch failed. Coins of disabled tracked assets have no balance.
+func (b *BalanceResolver) FetchEvmBalances(ctx context.Context, chain common.Chain, coins []models.CoinDBModel) ([]BalanceResult, error) {
+	results := make([]BalanceResult, len(coins))
+	calls := make([]call3, 0, len(coins))
+	decimals := make([]int64, 0, len(coins))
+	indexes := make([]int, 0, len(coins))
+	for i, coin := range coins {
+		results[i].Coin = coin
+		if b.registry().Disabled(chain, coin.ContractAddress) {
+			continue
+		}
+		call, coinDecimals, err := b.balanceCall(chain, coin)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		calls = append(calls, call)
+		decimals = append(decimals, coinDecimals)
+		indexes = append(indexes, i)
+	}
+	for start := 0; start < len(calls); start += maxMulticallCalls {
+		end := min(start+maxMulticallCalls, len(calls))
+		returned, err := b.aggregate3(ctx, chain, calls[start:end])
+		if err != nil {
+			return nil, err
+		}
+		for j, r := range returned {
+			result := &results[indexes[start+j]]
+			if !r.Success {
+				result.Err = fmt.Errorf("balance call of %s on %s reverted", result.Coin.Address, chain)
+				continue
+			}
+			if len(r.ReturnData) != 32 {
+				result.Err = fmt.Errorf("unexpected balance of %s on %s: %x", result.Coin.Address, chain, r.ReturnData)
+				continue
+			}
+			result.Balance, result.Err = utils.HexToFloat64(hex.EncodeToString(r.ReturnData), decimals[start+j])
+		}
+	}
+	return results, nil
+}
+
+// balanceCall encodes the balance call of the coin and returns the decimals of its result. Native balances
+// are read through getEthBalance of Multicall3 itself.
+func (b *BalanceResolver) balanceCall(chain common.Chain, coin models.CoinDBModel) (call3, int64, error) {
+	if !ethcommon.IsHexAddress(coin.Address) {
+		return call3{}, 0, fmt.Errorf("invalid address %s on %s", coin.Address, chain)
+	}
+	owner := ethcommon.HexToAddress(coin.Address)
+	if coin.ContractAddress == "" {
+		data, err := multicall3.Pack("getEthBalance", owner)
+		if err != nil {
+			return call3{}, 0, fmt.Errorf("error packing getEthBalance: %w", err)
+		}
+		return call3{Target: multicallAddressForChain(chain), AllowFailure: true, CallData: data}, 18, nil
+	}
+	if !ethcommon.IsHexAddress(coin.ContractAddress) {
+		return call3{}, 0, fmt.Errorf("invalid contract address %s on %s", coin.ContractAddress, chain)
+	}
+	data := append(append([]byte{}, balanceOfSelector...), ethcommon.LeftPadBytes(owner.Bytes(), 32)...)
+	decimals := int64(coin.Decimals)
+	if b.isNFTCollection(chain, coin.ContractAddress) {
+		decimals = 0
+	}
+	return call3{Target: ethcommon.HexToAddress(coin.ContractAddress), AllowFailure: true, CallData: data}, decimals, nil
+}
+
+func (b *BalanceResolver) aggregate3(ctx context.Context, chain common.Chain, calls []call3) ([]call3Result, error) {
+	data, err := multicall3.Pack("aggregate3", calls)
+	if err != nil {
+		return nil, fmt.Errorf("error packing aggregate3: %w", err)
+	}
+	rpcRequest := RpcRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_call",
+		Params: []interface{}{
+			RpcParams{
+				To:   multicallAddressForChain(chain).Hex(),
+				Data: "0x" + hex.EncodeToString(data),
+			},
+			"latest",
+		},
+		Id: 1,
+	}
+	requestBody, err := json.Marshal(rpcRequest)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling RPC request: %w", err)
+	}
+	var rpcResponse RpcResponse
+	err = b.post(ctx, chain, "", requestBody, func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			// rate limited, need to backoff and then retry
+			return ErrRateLimited
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("error fetching %d balances on %s: %s", len(calls), chain, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&rpcResponse); err != nil {
+			return fmt.Errorf("error decoding RPC response: %w", err)
+		}
+		if rpcResponse.Error != nil {
+			return fmt.Errorf("error fetching %d balances on %s: %s", len(calls), chain, rpcResponse.Error.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	returnData, err := hex.DecodeString(strings.TrimPrefix(rpcResponse.Result, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("error decoding aggregate3 result: %w", err)
+	}
+	unpacked, err := multicall3.Unpack("aggregate3", returnData)
+	if err != nil {
+		return nil, fmt.Errorf("error unpacking aggregate3 result: %w", err)
+	}
+	results := *abi.ConvertType(unpacked[0], new([]call3Result)).(*[]call3Result)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("aggregate3 returned %d results for %d calls", len(results), len(calls))
+	}
+	return results, nil
+}
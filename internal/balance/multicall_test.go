@@ -0,0 +1,147 @@
+package balance
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	ethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+const (
+	testUSDT    = "0xdac17f958d2ee523a2206206994597c13d831ec7"
+	testReverts = "0x0000000000000000000000000000000000000bad"
+	testNFT     = "0xa98b29a8f5a247802149c268ecf860b8308b7291"
+	testOwner   = "0x07773707BdA78aC4052f736544928b15dD31c5cc"
+)
+
+// mockMulticallServer answers aggregate3 calls: 1 ETH for getEthBalance, 2 USDT, 3 NFTs and a revert
+// for testReverts. The first rateLimited requests are answered with 429.
+func mockMulticallServer(t *testing.T, requests *atomic.Int64, rateLimited int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= rateLimited {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		var params RpcParams
+		assert.NoError(t, json.Unmarshal(req.Params[0], &params))
+		assert.True(t, strings.EqualFold(multicall3Address, params.To))
+		data, err := hex.DecodeString(strings.TrimPrefix(params.Data, "0x"))
+		assert.NoError(t, err)
+		method, err := multicall3.MethodById(data[:4])
+		assert.NoError(t, err)
+		assert.Equal(t, "aggregate3", method.Name)
+		args, err := method.Inputs.Unpack(data[4:])
+		assert.NoError(t, err)
+		calls := *abi.ConvertType(args[0], new([]call3)).(*[]call3)
+
+		results := make([]call3Result, len(calls))
+		for i, call := range calls {
+			assert.True(t, call.AllowFailure)
+			var balance *big.Int
+			switch strings.ToLower(call.Target.Hex()) {
+			case strings.ToLower(multicall3Address):
+				balance = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+			case testUSDT:
+				assert.Equal(t, balanceOfSelector, call.CallData[:4])
+				assert.Equal(t, ethcommon.HexToAddress(testOwner), ethcommon.BytesToAddress(call.CallData[4:]))
+				balance = big.NewInt(2_000_000)
+			case testNFT:
+				balance = big.NewInt(3)
+			case testReverts:
+				continue
+			}
+			results[i] = call3Result{Success: true, ReturnData: ethcommon.LeftPadBytes(balance.Bytes(), 32)}
+		}
+		returnData, err := method.Outputs.Pack(results)
+		assert.NoError(t, err)
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(RpcResponse{
+			Jsonrpc: "2.0",
+			Id:      1,
+			Result:  "0x" + hex.EncodeToString(returnData),
+		})
+	}))
+}
+
+func TestFetchEvmBalances(t *testing.T) {
+	var requests atomic.Int64
+	mockServer := mockMulticallServer(t, &requests, 0)
+	defer mockServer.Close()
+	b := &BalanceResolver{
+		logger: logrus.WithField("module", "balance_resolver_test").Logger,
+		chains: mockEndpoints(t, mockServer.URL, common.Ethereum),
+		assets: &atomic.Pointer[models.AssetRegistry]{},
+	}
+	b.SetAssets(models.NewAssetRegistry([]models.TrackedAsset{
+		{Chain: common.Ethereum, ContractAddress: testNFT, Kind: models.AssetKindNFT, Enabled: true},
+	}))
+	coins := []models.CoinDBModel{
+		{CoinBase: models.CoinBase{Chain: common.Ethereum, Address: testOwner}},
+		{CoinBase: models.CoinBase{Chain: common.Ethereum, Address: testOwner, ContractAddress: testUSDT, Decimals: 6}},
+		{CoinBase: models.CoinBase{Chain: common.Ethereum, Address: testOwner, ContractAddress: testReverts, Decimals: 18}},
+		{CoinBase: models.CoinBase{Chain: common.Ethereum, Address: "not an address", ContractAddress: testUSDT, Decimals: 6}},
+		{CoinBase: models.CoinBase{Chain: common.Ethereum, Address: testOwner, ContractAddress: testNFT}},
+	}
+	results, err := b.FetchEvmBalances(context.Background(), common.Ethereum, coins)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), requests.Load())
+	assert.Len(t, results, len(coins))
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, float64(1), results[0].Balance)
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, float64(2), results[1].Balance)
+	assert.Error(t, results[2].Err)
+	assert.Error(t, results[3].Err)
+	assert.NoError(t, results[4].Err)
+	assert.Equal(t, float64(3), results[4].Balance)
+	for i, result := range results {
+		assert.Equal(t, coins[i], result.Coin)
+	}
+}
+
+func TestGetBalancesWithRetry(t *testing.T) {
+	var requests atomic.Int64
+	mockServer := mockMulticallServer(t, &requests, 1)
+	defer mockServer.Close()
+	b := &BalanceResolver{
+		logger: logrus.WithField("module", "balance_resolver_test").Logger,
+		chains: mockEndpoints(t, mockServer.URL, common.Ethereum),
+	}
+	coins := []models.CoinDBModel{
+		{CoinBase: models.CoinBase{Chain: common.Ethereum, Address: testOwner, ContractAddress: testUSDT, Decimals: 6}},
+		{CoinBase: models.CoinBase{Chain: common.Chain(-1), Address: testOwner}},
+		{CoinBase: models.CoinBase{Chain: common.Ethereum, Address: testOwner}},
+		{CoinBase: models.CoinBase{Chain: common.Arbitrum, Address: testOwner}},
+	}
+	results := b.GetBalancesWithRetry(context.Background(), coins)
+	// the rate limited batch was retried
+	assert.Equal(t, int64(2), requests.Load())
+	assert.Len(t, results, len(coins))
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, float64(2), results[0].Balance)
+	assert.Error(t, results[1].Err)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, float64(1), results[2].Balance)
+	// no rpc for arbitrum in this resolver
+	assert.Error(t, results[3].Err)
+	for i, result := range results {
+		assert.Equal(t, coins[i], result.Coin)
+	}
+}
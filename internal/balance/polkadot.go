@@ -1,11 +1,13 @@
 package balance
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
 )
 
 type SubscanResponse struct {
@@ -52,29 +54,25 @@ type SubscanResponse struct {
 	} `json:"data"`
 }
 
-func (b *BalanceResolver) FetchPolkadotBalanceOfAddress(address string) (float64, error) {
+func (b *BalanceResolver) FetchPolkadotBalanceOfAddress(ctx context.Context, address string) (float64, error) {
 	payload := fmt.Sprintf(`{"key":"%s"}`, address)
-	resp, err := http.Post(
-		"https://polkadot.api.subscan.io/api/v2/scan/search",
-		"application/json",
-		bytes.NewBuffer([]byte(payload)),
-	)
+	var subscanResp SubscanResponse
+	err := b.post(ctx, common.Polkadot, "/api/v2/scan/search", []byte(payload), func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			// rate limited, need to backoff and then retry
+			return ErrRateLimited
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("error fetching balance of address %s on Polkadot: %s", address, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&subscanResp); err != nil {
+			return fmt.Errorf("error unmarshalling response: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
 		return 0, fmt.Errorf("error fetching balance of address %s on Polkadot: %w", address, err)
 	}
-	defer b.closer(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("error fetching balance of address %s on Polkadot: %s", address, resp.Status)
-	}
-
-	if resp.StatusCode == http.StatusTooManyRequests {
-		// rate limited, need to backoff and then retry
-		return 0, ErrRateLimited
-	}
-	var subscanResp SubscanResponse
-	if err := json.NewDecoder(resp.Body).Decode(&subscanResp); err != nil {
-		return 0, fmt.Errorf("error unmarshalling response: %w", err)
-	}
 
 	if subscanResp.Code != 0 {
 		return 0, fmt.Errorf("error from subscan API: %s", subscanResp.Message)
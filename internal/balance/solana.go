@@ -1,10 +1,12 @@
 package balance
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
 )
 
 type RpcSolanaResp struct {
@@ -41,7 +43,7 @@ type RpcSplResp struct {
 	} `json:"result"`
 }
 
-func (b *BalanceResolver) FetchSolanaBalanceOfAddress(address string) (float64, error) {
+func (b *BalanceResolver) FetchSolanaBalanceOfAddress(ctx context.Context, address string) (float64, error) {
 	// Create parameters array
 	params := []interface{}{
 		address,
@@ -59,25 +61,26 @@ func (b *BalanceResolver) FetchSolanaBalanceOfAddress(address string) (float64,
 	if err != nil {
 		return 0, fmt.Errorf("error marshalling RPC request: %w", err)
 	}
-	response, err := http.Post("https://api.vultisig.com/solana/", "application/json", bytes.NewBuffer(reqBody))
+	var rpcResp RpcSolanaResp
+	err = b.post(ctx, common.Solana, "/", reqBody, func(response *http.Response) error {
+		if response.StatusCode == http.StatusTooManyRequests {
+			return ErrRateLimited
+		}
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("error fetching balance of address %s on Solana: %s", address, response.Status)
+		}
+		if err := json.NewDecoder(response.Body).Decode(&rpcResp); err != nil {
+			return fmt.Errorf("error decoding response: %v", err)
+		}
+		return nil
+	})
 	if err != nil {
 		return 0, fmt.Errorf("error fetching balance of address %s on Solana: %w", address, err)
 	}
-	defer b.closer(response.Body)
-	if response.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("error fetching balance of address %s on Solana: %s", address, response.Status)
-	}
-	if response.StatusCode == http.StatusTooManyRequests {
-		return 0, ErrRateLimited
-	}
-	var rpcResp RpcSolanaResp
-	if err := json.NewDecoder(response.Body).Decode(&rpcResp); err != nil {
-		return 0, fmt.Errorf("error decoding response: %v", err)
-	}
 	return rpcResp.Result.Value / 1000000000, nil
 }
 
-func (b *BalanceResolver) FetchSPLBalanceOfAddress(vaultAddress, contractAdderss string) (float64, error) {
+func (b *BalanceResolver) FetchSPLBalanceOfAddress(ctx context.Context, vaultAddress, contractAdderss string) (float64, error) {
 	// Create parameters array
 	params := []interface{}{
 		vaultAddress,
@@ -101,20 +104,21 @@ func (b *BalanceResolver) FetchSPLBalanceOfAddress(vaultAddress, contractAdderss
 	if err != nil {
 		return 0, fmt.Errorf("error marshalling RPC request: %w", err)
 	}
-	response, err := http.Post("https://api.vultisig.com/solana/", "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return 0, fmt.Errorf("error fetching spl balance  %s of address %s on Solana: %w", contractAdderss, vaultAddress, err)
-	}
-	defer b.closer(response.Body)
-	if response.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("error fetching spl balance %s of address %s on Solana: %s", contractAdderss, vaultAddress, response.Status)
-	}
-	if response.StatusCode == http.StatusTooManyRequests {
-		return 0, ErrRateLimited
-	}
 	var rpcResp RpcSplResp
-	if err := json.NewDecoder(response.Body).Decode(&rpcResp); err != nil {
-		return 0, fmt.Errorf("error decoding response: %v", err)
+	err = b.post(ctx, common.Solana, "/", reqBody, func(response *http.Response) error {
+		if response.StatusCode == http.StatusTooManyRequests {
+			return ErrRateLimited
+		}
+		if response.StatusCode != http.StatusOK {
+			return fmt.Errorf("error fetching spl balance %s of address %s on Solana: %s", contractAdderss, vaultAddress, response.Status)
+		}
+		if err := json.NewDecoder(response.Body).Decode(&rpcResp); err != nil {
+			return fmt.Errorf("error decoding response: %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error fetching spl balance %s of address %s on Solana: %w", contractAdderss, vaultAddress, err)
 	}
 	for _, v := range rpcResp.Result.Value {
 		if v.Account.Data.Parsed.Info.Mint == contractAdderss {
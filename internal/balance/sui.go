@@ -1,15 +1,16 @@
 package balance
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
 )
 
-func (b *BalanceResolver) FetchSuiBalanceOfAddress(address string) (float64, error) {
-	rpcUrl := "https://sui-rpc.publicnode.com"
+func (b *BalanceResolver) FetchSuiBalanceOfAddress(ctx context.Context, address string) (float64, error) {
 	// Create parameters array
 	params := []interface{}{
 		address,
@@ -29,19 +30,6 @@ func (b *BalanceResolver) FetchSuiBalanceOfAddress(address string) (float64, err
 		return 0, fmt.Errorf("error marshalling RPC request: %w", err)
 	}
 
-	resp, err := http.Post(rpcUrl, "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return 0, fmt.Errorf("error fetching balance of address %s on SUI: %w", address, err)
-	}
-	defer b.closer(resp.Body)
-	if resp.StatusCode == http.StatusTooManyRequests {
-		// rate limited, need to backoff and then retry
-		return 0, ErrRateLimited
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("error fetching balance of address %s on SUI: %s", address, resp.Status)
-	}
 	type RpcSuiResp struct {
 		Jsonrpc string `json:"jsonrpc"`
 		Id      int    `json:"id"`
@@ -50,8 +38,22 @@ func (b *BalanceResolver) FetchSuiBalanceOfAddress(address string) (float64, err
 		} `json:"result"`
 	}
 	var rpcResp RpcSuiResp
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
-		return 0, fmt.Errorf("error decoding response: %w", err)
+	err = b.post(ctx, common.Sui, "", reqBody, func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			// rate limited, need to backoff and then retry
+			return ErrRateLimited
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("error fetching balance of address %s on SUI: %s", address, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+			return fmt.Errorf("error decoding response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error fetching balance of address %s on SUI: %w", address, err)
 	}
 
 	balance, err := strconv.ParseFloat(rpcResp.Result.TotalBalance, 64)
@@ -1,28 +1,31 @@
 package balance
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
 )
 
 type tonBalanceResult struct {
 	Balance uint64 `json:"balance,string"`
 }
 
-func (b *BalanceResolver) FetchTonBalanceOfAddress(address string) (float64, error) {
-	url := fmt.Sprintf("%s?address=%s&use_v2=false", b.tonBalanceBaseAddress, address)
-	resp, err := http.Get(url)
+func (b *BalanceResolver) FetchTonBalanceOfAddress(ctx context.Context, address string) (float64, error) {
+	var result tonBalanceResult
+	err := b.get(ctx, common.Ton, fmt.Sprintf("/v3/addressInformation?address=%s&use_v2=false", address), func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("error fetching balance of address %s on TON: %s", address, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("error decoding response: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
 		return 0, fmt.Errorf("error fetching balance of address %s on TON: %w", address, err)
 	}
-	defer b.closer(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("error fetching balance of address %s on TON: %s", address, resp.Status)
-	}
-	var result tonBalanceResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("error decoding response: %w", err)
-	}
 	return float64(result.Balance) * 1e-9, nil
 }
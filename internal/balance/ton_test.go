@@ -1,6 +1,7 @@
 package balance
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +9,8 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
 )
 
 func TestFetchTonBalanceOfAddress(t *testing.T) {
@@ -29,10 +32,10 @@ func TestFetchTonBalanceOfAddress(t *testing.T) {
 
 	// Create a LiquidityPositionResolver instance
 	balanceResolver := &BalanceResolver{
-		logger:                logrus.WithField("module", "balance_resolver_test").Logger,
-		tonBalanceBaseAddress: mockServer.URL,
+		logger: logrus.WithField("module", "balance_resolver_test").Logger,
+		chains: mockEndpoints(t, mockServer.URL, common.Ton),
 	}
-	b, err := balanceResolver.FetchTonBalanceOfAddress("UQBM2SHV1AuhDNMB4E69SMtzqstKG2J_ZXwqpdgmAuulrUom")
+	b, err := balanceResolver.FetchTonBalanceOfAddress(context.Background(), "UQBM2SHV1AuhDNMB4E69SMtzqstKG2J_ZXwqpdgmAuulrUom")
 	assert.NoError(t, err)
 	assert.Equal(t, float64(10), b)
 }
@@ -1,11 +1,14 @@
 package balance
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
 	"net/http"
 	"strconv"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
 )
 
 type tronBalanceResult struct {
@@ -19,20 +22,20 @@ type tronBalanceResult struct {
 	Success bool `json:"success"`
 }
 
-func (b *BalanceResolver) FetchTronBalanceOfAddress(address, contract string, decimal int) (float64, error) {
-	url := fmt.Sprintf("%s/v1/accounts/%s", b.tronBalanceBaseAddress, address)
-	resp, err := http.Get(url)
+func (b *BalanceResolver) FetchTronBalanceOfAddress(ctx context.Context, address, contract string, decimal int) (float64, error) {
+	var result tronBalanceResult
+	err := b.get(ctx, common.Tron, "/v1/accounts/"+address, func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("error fetching balance of address %s (%s) on Tron: %s", address, contract, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("error decoding response: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
 		return 0, fmt.Errorf("error fetching balance of address %s (%s) on Tron: %w", address, contract, err)
 	}
-	defer b.closer(resp.Body)
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("error fetching balance of address %s (%s) on Tron: %s", address, contract, resp.Status)
-	}
-	var result tronBalanceResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, fmt.Errorf("error decoding response: %w", err)
-	}
 	if !result.Success || result.Data == nil || len(result.Data) == 0 {
 		return 0, fmt.Errorf("failed to get balance of address %s (%s) on Tron", address, contract)
 	}
@@ -1,12 +1,15 @@
 package balance
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
 )
 
 func TestFetchTronBalanceOfAddress(t *testing.T) {
@@ -33,14 +36,14 @@ func TestFetchTronBalanceOfAddress(t *testing.T) {
 
 	// Create a LiquidityPositionResolver instance
 	balanceResolver := &BalanceResolver{
-		logger:                 logrus.WithField("module", "balance_resolver_test").Logger,
-		tronBalanceBaseAddress: mockServer.URL,
+		logger: logrus.WithField("module", "balance_resolver_test").Logger,
+		chains: mockEndpoints(t, mockServer.URL, common.Tron),
 	}
-	trxBalance, err := balanceResolver.FetchTronBalanceOfAddress("TNrTj7SizyxBd4G48cLhZeBvJtZgUaCq2D", "", 6)
+	trxBalance, err := balanceResolver.FetchTronBalanceOfAddress(context.Background(), "TNrTj7SizyxBd4G48cLhZeBvJtZgUaCq2D", "", 6)
 	assert.NoError(t, err)
 	assert.Equal(t, float64(26), trxBalance)
 
-	trxBalance, err = balanceResolver.FetchTronBalanceOfAddress("TNrTj7SizyxBd4G48cLhZeBvJtZgUaCq2D", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", 6)
+	trxBalance, err = balanceResolver.FetchTronBalanceOfAddress(context.Background(), "TNrTj7SizyxBd4G48cLhZeBvJtZgUaCq2D", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", 6)
 	assert.NoError(t, err)
 	assert.Equal(t, float64(2.1), trxBalance)
 }
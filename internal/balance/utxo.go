@@ -1,6 +1,7 @@
 package balance
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -25,47 +26,33 @@ type UtxoResult struct {
 }
 
 // FetchUtxoBalanceOfAddress fetches the UTXO balance of an address and it's USD value
-func (b *BalanceResolver) FetchUtxoBalanceOfAddress(address string, chain common.Chain) (float64, float64, error) {
+func (b *BalanceResolver) FetchUtxoBalanceOfAddress(ctx context.Context, address string, chain common.Chain) (float64, float64, error) {
 	if address == "" {
 		return 0, 0, fmt.Errorf("address cannot be empty")
 	}
-	var chainName string
 	switch chain {
-	case common.Bitcoin:
-		chainName = "bitcoin"
-	case common.BitcoinCash:
-		chainName = "bitcoin-cash"
-	case common.Dash:
-		chainName = "dash"
-	case common.Litecoin:
-		chainName = "litecoin"
-	case common.Dogecoin:
-		chainName = "dogecoin"
-	case common.Zcash:
-		chainName = "zcash"
+	case common.Bitcoin, common.BitcoinCash, common.Dash, common.Litecoin, common.Dogecoin, common.Zcash:
 	default:
 		return 0, 0, fmt.Errorf("unsupported chain: %s", chain)
 	}
-	url := fmt.Sprintf("%s/blockchair/%s/dashboards/address/%s?state=latest", b.vultisigApiProxy, chainName, address)
-
-	resp, err := http.Get(url)
+	var result UtxoResult
+	err := b.get(ctx, chain, fmt.Sprintf("/dashboards/address/%s?state=latest", address), func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			// rate limited, need to backoff and then retry
+			return ErrRateLimited
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("error fetching UTXO balance of address %s: %s", address, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return fmt.Errorf("error unmarshalling response: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
 		return 0, 0, fmt.Errorf("error fetching UTXO balance of address %s: %w", address, err)
 	}
-
-	defer b.closer(resp.Body)
-	if resp.StatusCode == http.StatusTooManyRequests {
-		// rate limited, need to backoff and then retry
-		return 0, 0, ErrRateLimited
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, 0, fmt.Errorf("error fetching UTXO balance of address %s: %s", address, resp.Status)
-	}
-	var result UtxoResult
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return 0, 0, fmt.Errorf("error unmarshalling response: %w", err)
-	}
 	data, ok := result.Data[address]
 	if !ok {
 		return 0, 0, fmt.Errorf("address data not found in response")
@@ -1,14 +1,15 @@
 package balance
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
 )
 
-func (b *BalanceResolver) FetchXRPBalanceOfAddress(address string) (float64, error) {
-	rpcUrl := b.xrpBalanceBaseAddress
+func (b *BalanceResolver) FetchXRPBalanceOfAddress(ctx context.Context, address string) (float64, error) {
 	// Create parameters array
 	params := []interface{}{
 		map[string]interface{}{
@@ -32,19 +33,6 @@ func (b *BalanceResolver) FetchXRPBalanceOfAddress(address string) (float64, err
 		return 0, fmt.Errorf("error marshalling RPC request: %w", err)
 	}
 
-	resp, err := http.Post(rpcUrl, "application/json", bytes.NewBuffer(reqBody))
-	if err != nil {
-		return 0, fmt.Errorf("error fetching balance of address %s on SUI: %w", address, err)
-	}
-	defer b.closer(resp.Body)
-	if resp.StatusCode == http.StatusTooManyRequests {
-		// rate limited, need to backoff and then retry
-		return 0, ErrRateLimited
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("error fetching balance of address %s on SUI: %s", address, resp.Status)
-	}
 	type RpcXRPResp struct {
 		Result struct {
 			AccountData struct {
@@ -53,8 +41,22 @@ func (b *BalanceResolver) FetchXRPBalanceOfAddress(address string) (float64, err
 		} `json:"result"`
 	}
 	var rpcResp RpcXRPResp
-	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
-		return 0, fmt.Errorf("error decoding response: %w", err)
+	err = b.post(ctx, common.XRP, "", reqBody, func(resp *http.Response) error {
+		if resp.StatusCode == http.StatusTooManyRequests {
+			// rate limited, need to backoff and then retry
+			return ErrRateLimited
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("error fetching balance of address %s on XRP: %s", address, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+			return fmt.Errorf("error decoding response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error fetching balance of address %s on XRP: %w", address, err)
 	}
 
 	return float64(rpcResp.Result.AccountData.Balance) / 1e6, nil
@@ -1,6 +1,7 @@
 package balance
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -8,6 +9,8 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
 )
 
 func TestFetchXRPBalanceOfAddress(t *testing.T) {
@@ -58,10 +61,10 @@ func TestFetchXRPBalanceOfAddress(t *testing.T) {
 
 	// Create a LiquidityPositionResolver instance
 	balanceResolver := &BalanceResolver{
-		logger:                logrus.WithField("module", "balance_resolver_test").Logger,
-		xrpBalanceBaseAddress: mockServer.URL,
+		logger: logrus.WithField("module", "balance_resolver_test").Logger,
+		chains: mockEndpoints(t, mockServer.URL, common.XRP),
 	}
-	b, err := balanceResolver.FetchXRPBalanceOfAddress("rhmezeHcxx9sv3A69eafEcAeX3EWBmwFGX")
+	b, err := balanceResolver.FetchXRPBalanceOfAddress(context.Background(), "rhmezeHcxx9sv3A69eafEcAeX3EWBmwFGX")
 	assert.NoError(t, err)
 	assert.Equal(t, float64(10), b)
 }
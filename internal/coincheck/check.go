@@ -0,0 +1,166 @@
+// Package coincheck compares the CMC id, decimals, ticker and logo of the stored coins with the tracked
+// assets, the predefined tokens and the discovery service of their chain, and works out their fixes.
+package coincheck
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/tokens"
+)
+
+// IssueType is the kind of problem found on a coin
+type IssueType string
+
+const (
+	IssueCMCId            IssueType = "cmc_id"
+	IssueDecimals         IssueType = "decimals"
+	IssueTicker           IssueType = "ticker"
+	IssueLogo             IssueType = "logo"
+	IssueNotFound         IssueType = "not_found"         // no source knows the token
+	IssueUnsupportedChain IssueType = "unsupported_chain" // no source knows the token and the chain has no discovery service
+)
+
+// Source is where the reference data of a coin comes from
+type Source string
+
+const (
+	SourceTrackedAsset Source = "tracked_asset"
+	SourcePredefined   Source = "predefined"
+	SourceDiscovery    Source = "discovery"
+)
+
+// Finding is a problem found on a coin, a fixable finding carries the value the fix writes
+type Finding struct {
+	CoinID          uint         `json:"coin_id"`
+	Chain           common.Chain `json:"chain"`
+	ContractAddress string       `json:"contract_address"`
+	Ticker          string       `json:"ticker"`
+	Type            IssueType    `json:"type"`
+	Current         string       `json:"current"`
+	Expected        string       `json:"expected,omitempty"`
+	Source          Source       `json:"source,omitempty"`
+	Detail          string       `json:"detail,omitempty"`
+}
+
+// Fixable reports whether the fix of the coin corrects the finding
+func (f Finding) Fixable() bool {
+	return f.Expected != ""
+}
+
+// Checker checks coins against their reference data
+type Checker struct {
+	assets     *models.AssetRegistry
+	predefined tokens.AutoDiscoveryService
+	discovery  map[common.Chain]tokens.AutoDiscoveryService
+}
+
+func NewChecker(assets *models.AssetRegistry, predefined tokens.AutoDiscoveryService, discovery map[common.Chain]tokens.AutoDiscoveryService) *Checker {
+	return &Checker{
+		assets:     assets,
+		predefined: predefined,
+		discovery:  discovery,
+	}
+}
+
+// Check compares the coin with its reference data and returns the findings, and the fix of the coin when
+// a finding is fixable. NFT collections are not checked.
+func (c *Checker) Check(ctx context.Context, coin models.CoinDBModel) ([]Finding, *models.CoinFix) {
+	if asset, ok := c.assets.Lookup(coin.Chain, coin.ContractAddress); ok && asset.Kind == models.AssetKindNFT {
+		return nil, nil
+	}
+	expected, source, issue, err := c.reference(ctx, coin)
+	if issue != "" {
+		finding := c.finding(coin, issue, coin.Ticker)
+		if err != nil {
+			finding.Detail = err.Error()
+		}
+		return []Finding{finding}, nil
+	}
+
+	var findings []Finding
+	fix := models.CoinFix{CoinID: coin.ID, Chain: coin.Chain, Address: coin.Address}
+	if expected.CMCId != 0 && expected.CMCId != coin.CMCId {
+		findings = append(findings, c.fixable(coin, IssueCMCId, strconv.Itoa(coin.CMCId), strconv.Itoa(expected.CMCId), source))
+		fix.CMCId = expected.CMCId
+	}
+	if expected.Decimals != 0 && expected.Decimals != coin.Decimals {
+		findings = append(findings, c.fixable(coin, IssueDecimals, strconv.Itoa(coin.Decimals), strconv.Itoa(expected.Decimals), source))
+		fix.Decimals = expected.Decimals
+	}
+	if expected.Ticker != "" && expected.Ticker != coin.Ticker {
+		findings = append(findings, c.fixable(coin, IssueTicker, coin.Ticker, expected.Ticker, source))
+		fix.Ticker = expected.Ticker
+	}
+	if expected.Logo != "" && expected.Logo != coin.Logo {
+		findings = append(findings, c.fixable(coin, IssueLogo, coin.Logo, expected.Logo, source))
+		fix.Logo = expected.Logo
+	}
+	if len(findings) == 0 {
+		return nil, nil
+	}
+	return findings, &fix
+}
+
+// reference returns the reference data of the coin. Tracked assets override the predefined tokens, the
+// discovery service of the chain is only asked for tokens neither knows.
+func (c *Checker) reference(ctx context.Context, coin models.CoinDBModel) (models.CoinBase, Source, IssueType, error) {
+	search := models.CoinBase{
+		Chain:           coin.Chain,
+		Address:         coin.Address,
+		ContractAddress: coin.ContractAddress,
+	}
+	expected, err := c.predefined.Search(ctx, search)
+	found := err == nil
+	source := SourcePredefined
+	if asset, ok := c.assets.Lookup(coin.Chain, coin.ContractAddress); ok {
+		found = true
+		source = SourceTrackedAsset
+		if asset.Ticker != "" {
+			expected.Ticker = asset.Ticker
+		}
+		if asset.CMCId != 0 {
+			expected.CMCId = asset.CMCId
+		}
+		if asset.Decimals != 0 {
+			expected.Decimals = asset.Decimals
+		}
+	}
+	if found {
+		return expected, source, "", nil
+	}
+	service, ok := c.discovery[coin.Chain]
+	if !ok {
+		return models.CoinBase{}, "", IssueUnsupportedChain, nil
+	}
+	expected, err = service.Search(ctx, search)
+	if err != nil {
+		return models.CoinBase{}, "", IssueNotFound, err
+	}
+	if expected.CMCId == 0 && expected.Decimals == 0 {
+		return models.CoinBase{}, "", IssueNotFound, nil
+	}
+	// discovery services do not know the tickers the apps use
+	expected.Ticker = ""
+	return expected, SourceDiscovery, "", nil
+}
+
+func (c *Checker) finding(coin models.CoinDBModel, issue IssueType, current string) Finding {
+	return Finding{
+		CoinID:          coin.ID,
+		Chain:           coin.Chain,
+		ContractAddress: coin.ContractAddress,
+		Ticker:          coin.Ticker,
+		Type:            issue,
+		Current:         current,
+	}
+}
+
+func (c *Checker) fixable(coin models.CoinDBModel, issue IssueType, current, expected string, source Source) Finding {
+	finding := c.finding(coin, issue, current)
+	finding.Expected = expected
+	finding.Source = source
+	return finding
+}
@@ -0,0 +1,130 @@
+package coincheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/tokens"
+)
+
+type stubDiscovery map[string]models.CoinBase
+
+func (s stubDiscovery) Discover(ctx context.Context, address string, chain common.Chain) ([]models.CoinBase, error) {
+	return nil, nil
+}
+
+func (s stubDiscovery) Search(ctx context.Context, coin models.CoinBase) (models.CoinBase, error) {
+	if token, ok := s[coin.ContractAddress]; ok {
+		return token, nil
+	}
+	return models.CoinBase{}, fmt.Errorf("token %s not found", coin.ContractAddress)
+}
+
+func testCoin(id uint, chain common.Chain, ticker, contract string, cmcID, decimals int) models.CoinDBModel {
+	coin := models.CoinDBModel{CoinBase: models.CoinBase{Chain: chain, Ticker: ticker, Address: "owner", ContractAddress: contract, CMCId: cmcID, Decimals: decimals}}
+	coin.ID = id
+	return coin
+}
+
+func testChecker() *Checker {
+	predefined := stubDiscovery{
+		"0xdac17f958d2ee523a2206206994597c13d831ec7": {Ticker: "USDT", CMCId: 825, Decimals: 6, Logo: "usdt.png"},
+		"0xb788144df611029c60b859df47e79b7726c4deba": {Ticker: "VULT", CMCId: 1, Decimals: 18, Logo: "vult.png"},
+	}
+	discovery := map[common.Chain]tokens.AutoDiscoveryService{
+		common.Ethereum: stubDiscovery{
+			"0x514910771af9ca656af840dff83e8264ecf986ca": {Ticker: "ChainLink", CMCId: 1975, Decimals: 18},
+		},
+	}
+	assets := models.NewAssetRegistry([]models.TrackedAsset{
+		{Chain: common.Ethereum, ContractAddress: "0xb788144df611029c60b859df47e79b7726c4deba", Kind: models.AssetKindToken, CMCId: 33502, Decimals: 18, Enabled: true},
+		{Chain: common.Ethereum, ContractAddress: "0xa98b29a8f5a247802149c268ecf860b8308b7291", Kind: models.AssetKindNFT, Enabled: true},
+	})
+	return NewChecker(assets, predefined, discovery)
+}
+
+func TestCheck(t *testing.T) {
+	checker := testChecker()
+	ctx := context.Background()
+
+	// matching coin apart from its logo
+	findings, fix := checker.Check(ctx, testCoin(1, common.Ethereum, "USDT", "0xdac17f958d2ee523a2206206994597c13d831ec7", 825, 6))
+	assert.Len(t, findings, 1)
+	assert.Equal(t, IssueLogo, findings[0].Type)
+	assert.Equal(t, &models.CoinFix{CoinID: 1, Chain: common.Ethereum, Address: "owner", Logo: "usdt.png"}, fix)
+
+	// rows without cmc id are fixed too, the tracked asset overrides the predefined token
+	findings, fix = checker.Check(ctx, testCoin(2, common.Ethereum, "vult", "0xb788144df611029c60b859df47e79b7726c4deba", 0, 0))
+	assert.Len(t, findings, 4)
+	for _, finding := range findings {
+		assert.Equal(t, SourceTrackedAsset, finding.Source)
+		assert.True(t, finding.Fixable())
+	}
+	assert.Equal(t, &models.CoinFix{CoinID: 2, Chain: common.Ethereum, Address: "owner", CMCId: 33502, Decimals: 18, Ticker: "VULT", Logo: "vult.png"}, fix)
+
+	// discovery fixes cmc id and decimals but keeps the ticker
+	findings, fix = checker.Check(ctx, testCoin(3, common.Ethereum, "LINK", "0x514910771af9ca656af840dff83e8264ecf986ca", 1975, 8))
+	assert.Len(t, findings, 1)
+	assert.Equal(t, Finding{CoinID: 3, Chain: common.Ethereum, ContractAddress: "0x514910771af9ca656af840dff83e8264ecf986ca", Ticker: "LINK",
+		Type: IssueDecimals, Current: "8", Expected: "18", Source: SourceDiscovery}, findings[0])
+	assert.Equal(t, &models.CoinFix{CoinID: 3, Chain: common.Ethereum, Address: "owner", Decimals: 18}, fix)
+
+	findings, fix = checker.Check(ctx, testCoin(4, common.Ethereum, "SCAM", "0x0000000000000000000000000000000000000001", 0, 0))
+	assert.Len(t, findings, 1)
+	assert.Equal(t, IssueNotFound, findings[0].Type)
+	assert.False(t, findings[0].Fixable())
+	assert.Nil(t, fix)
+
+	findings, fix = checker.Check(ctx, testCoin(5, common.Sui, "FOO", "0x2::foo::FOO", 0, 9))
+	assert.Len(t, findings, 1)
+	assert.Equal(t, IssueUnsupportedChain, findings[0].Type)
+	assert.Nil(t, fix)
+
+	// nft collections are not checked
+	findings, fix = checker.Check(ctx, testCoin(6, common.Ethereum, "THORGUARDS", "0xa98b29a8f5a247802149c268ecf860b8308b7291", 0, 0))
+	assert.Empty(t, findings)
+	assert.Nil(t, fix)
+}
+
+func TestReport(t *testing.T) {
+	findings := []Finding{
+		{CoinID: 1, Chain: common.Solana, Type: IssueNotFound, Current: "BONK"},
+		{CoinID: 2, Chain: common.Ethereum, Type: IssueDecimals, Current: "8", Expected: "18", Source: SourceDiscovery},
+		{CoinID: 3, Chain: common.Ethereum, Type: IssueCMCId, Current: "0", Expected: "825", Source: SourcePredefined},
+		{CoinID: 4, Chain: common.Ethereum, Type: IssueDecimals, Current: "0", Expected: "6", Source: SourcePredefined},
+	}
+	report := NewReport(10, findings)
+	assert.Equal(t, 10, report.Checked)
+	assert.Len(t, report.Groups, 3)
+	assert.Equal(t, common.Ethereum, report.Groups[0].Chain)
+	assert.Equal(t, IssueCMCId, report.Groups[0].Type)
+	assert.Equal(t, IssueDecimals, report.Groups[1].Type)
+	assert.Equal(t, 2, report.Groups[1].Count)
+	assert.Equal(t, []uint{2, 4}, []uint{report.Groups[1].Findings[0].CoinID, report.Groups[1].Findings[1].CoinID})
+	assert.Equal(t, common.Solana, report.Groups[2].Chain)
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.WriteCSV(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 5)
+	assert.Equal(t, "Ethereum,cmc_id,3,,,0,825,predefined,", lines[1])
+
+	buf.Reset()
+	assert.NoError(t, report.WriteJSON(&buf))
+	var decoded Report
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report, decoded)
+
+	buf.Reset()
+	assert.NoError(t, WriteDiff(&buf, findings))
+	assert.Equal(t, 3, strings.Count(buf.String(), "\n"))
+	assert.Contains(t, buf.String(), `coin 2 Ethereum  : decimals "8" -> "18" (discovery)`)
+}
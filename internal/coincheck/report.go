@@ -0,0 +1,103 @@
+package coincheck
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+// Group is the findings of one type on one chain
+type Group struct {
+	Chain    common.Chain `json:"chain"`
+	Type     IssueType    `json:"type"`
+	Count    int          `json:"count"`
+	Findings []Finding    `json:"findings"`
+}
+
+type Report struct {
+	Checked int     `json:"checked"` // coins checked
+	Fixed   int     `json:"fixed"`   // coins written back, 0 without --fix or with --dry-run
+	DryRun  bool    `json:"dry_run"`
+	Groups  []Group `json:"groups"` // ordered by chain and type
+}
+
+// NewReport groups the findings by chain and type, findings keep their order within a group
+func NewReport(checked int, findings []Finding) Report {
+	type key struct {
+		chain common.Chain
+		issue IssueType
+	}
+	index := make(map[key]int)
+	report := Report{Checked: checked, Groups: []Group{}}
+	for _, finding := range findings {
+		k := key{chain: finding.Chain, issue: finding.Type}
+		i, ok := index[k]
+		if !ok {
+			i = len(report.Groups)
+			index[k] = i
+			report.Groups = append(report.Groups, Group{Chain: finding.Chain, Type: finding.Type})
+		}
+		report.Groups[i].Count++
+		report.Groups[i].Findings = append(report.Groups[i].Findings, finding)
+	}
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if a.Chain != b.Chain {
+			return a.Chain.String() < b.Chain.String()
+		}
+		return a.Type < b.Type
+	})
+	return report
+}
+
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes one row per finding, ordered by chain and type
+func (r Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"chain", "type", "coin_id", "contract_address", "ticker", "current", "expected", "source", "detail"}); err != nil {
+		return err
+	}
+	for _, group := range r.Groups {
+		for _, f := range group.Findings {
+			if err := writer.Write([]string{
+				f.Chain.String(),
+				string(f.Type),
+				strconv.FormatUint(uint64(f.CoinID), 10),
+				f.ContractAddress,
+				f.Ticker,
+				f.Current,
+				f.Expected,
+				string(f.Source),
+				f.Detail,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteDiff writes the changes the fixes make, one line per fixable finding
+func WriteDiff(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		if !f.Fixable() {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "coin %d %s %s %s: %s %q -> %q (%s)\n",
+			f.CoinID, f.Chain, f.Ticker, f.ContractAddress, f.Type, f.Current, f.Expected, f.Source); err != nil {
+			return err
+		}
+	}
+	return nil
+}
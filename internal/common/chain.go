@@ -4,6 +4,7 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
 type Chain int
@@ -143,6 +144,17 @@ func (c Chain) String() string {
 	}
 	return "UNKNOWN"
 }
+
+// ParseChain returns the chain with the given name, ignoring case
+func ParseChain(name string) (Chain, error) {
+	for key, value := range chainToString {
+		if strings.EqualFold(value, name) {
+			return key, nil
+		}
+	}
+	return Undefined, fmt.Errorf("unknown chain %s", name)
+}
+
 func (c Chain) MarshalJSON() ([]byte, error) {
 	return json.Marshal(c.String())
 }
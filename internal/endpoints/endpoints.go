@@ -0,0 +1,255 @@
+// Package endpoints holds the ordered endpoints of every chain. A call fails over to the next endpoint
+// when one errors, and every endpoint keeps health stats.
+package endpoints
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/utils"
+)
+
+// retryAfter is how long an endpoint whose last call failed is tried after the healthy ones
+const retryAfter = time.Minute
+
+// Endpoint is a base url of a chain api with the headers its requests need
+type Endpoint struct {
+	URL     string
+	Headers map[string]string
+
+	mu                  sync.Mutex
+	requests            int64
+	failures            int64
+	consecutiveFailures int64
+	latency             time.Duration // total latency of the calls
+	lastError           string
+	lastFailureAt       time.Time
+	lastSuccessAt       time.Time
+}
+
+// Stats is the health of an endpoint since the process started
+type Stats struct {
+	Chain               string     `json:"chain"`
+	URL                 string     `json:"url"`
+	Requests            int64      `json:"requests"`
+	Failures            int64      `json:"failures"`
+	ConsecutiveFailures int64      `json:"consecutive_failures"`
+	AverageLatencyMs    float64    `json:"average_latency_ms"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+}
+
+// NewRequest creates a request for the path below the endpoint url with the endpoint headers
+func (e *Endpoint) NewRequest(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, e.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range e.Headers {
+		req.Header.Set(key, value)
+	}
+	return req, nil
+}
+
+func (e *Endpoint) Get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := e.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return utils.HTTPDo(ctx, req)
+}
+
+func (e *Endpoint) Post(ctx context.Context, path, contentType string, body []byte) (*http.Response, error) {
+	req, err := e.NewRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return utils.HTTPDo(ctx, req)
+}
+
+// available reports whether the endpoint is tried before the endpoints whose last call failed
+func (e *Endpoint) available(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.consecutiveFailures == 0 || now.Sub(e.lastFailureAt) >= retryAfter
+}
+
+func (e *Endpoint) record(now time.Time, latency time.Duration, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.requests++
+	e.latency += latency
+	if err == nil {
+		e.consecutiveFailures = 0
+		e.lastSuccessAt = now
+		return
+	}
+	e.failures++
+	e.consecutiveFailures++
+	e.lastError = err.Error()
+	e.lastFailureAt = now
+}
+
+func (e *Endpoint) stats(chain common.Chain) Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats := Stats{
+		Chain:               chain.String(),
+		URL:                 e.URL,
+		Requests:            e.requests,
+		Failures:            e.failures,
+		ConsecutiveFailures: e.consecutiveFailures,
+		LastError:           e.lastError,
+	}
+	if e.requests > 0 {
+		stats.AverageLatencyMs = float64(e.latency.Milliseconds()) / float64(e.requests)
+	}
+	if !e.lastFailureAt.IsZero() {
+		lastFailureAt := e.lastFailureAt
+		stats.LastFailureAt = &lastFailureAt
+	}
+	if !e.lastSuccessAt.IsZero() {
+		lastSuccessAt := e.lastSuccessAt
+		stats.LastSuccessAt = &lastSuccessAt
+	}
+	return stats
+}
+
+// Pool holds the endpoints of a chain in the configured order
+type Pool struct {
+	chain     common.Chain
+	endpoints []*Endpoint
+	now       func() time.Time
+}
+
+func NewPool(chain common.Chain, endpoints []config.Endpoint) *Pool {
+	pool := &Pool{
+		chain: chain,
+		now:   time.Now,
+	}
+	for _, endpoint := range endpoints {
+		pool.endpoints = append(pool.endpoints, &Endpoint{
+			URL:     strings.TrimRight(endpoint.URL, "/"),
+			Headers: endpoint.Headers,
+		})
+	}
+	return pool
+}
+
+// Do calls fn with one endpoint after the other until a call succeeds and returns the error of the last
+// call. Endpoints whose last call failed less than a minute ago are tried after the others.
+func (p *Pool) Do(ctx context.Context, fn func(e *Endpoint) error) error {
+	now := p.now()
+	ordered := make([]*Endpoint, 0, len(p.endpoints))
+	var unavailable []*Endpoint
+	for _, e := range p.endpoints {
+		if e.available(now) {
+			ordered = append(ordered, e)
+		} else {
+			unavailable = append(unavailable, e)
+		}
+	}
+	ordered = append(ordered, unavailable...)
+	err := fmt.Errorf("no endpoint configured for chain %s", p.chain)
+	for _, e := range ordered {
+		start := p.now()
+		err = fn(e)
+		if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+			// the caller gave up, the endpoint is not to blame
+			return err
+		}
+		e.record(p.now(), p.now().Sub(start), err)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (p *Pool) Stats() []Stats {
+	stats := make([]Stats, len(p.endpoints))
+	for i, e := range p.endpoints {
+		stats[i] = e.stats(p.chain)
+	}
+	return stats
+}
+
+// Registry holds the endpoint pool of every chain
+type Registry struct {
+	pools map[common.Chain]*Pool
+}
+
+// NewRegistry creates the pools of the built-in endpoints, a chain listed in the config with endpoints
+// uses the configured endpoints instead
+func NewRegistry(chains []config.ChainConfig, defaults map[common.Chain][]string) (*Registry, error) {
+	r := &Registry{
+		pools: make(map[common.Chain]*Pool, len(defaults)),
+	}
+	for chain, urls := range defaults {
+		endpoints := make([]config.Endpoint, len(urls))
+		for i, url := range urls {
+			endpoints[i] = config.Endpoint{URL: url}
+		}
+		r.pools[chain] = NewPool(chain, endpoints)
+	}
+	for _, item := range chains {
+		chain, err := common.ParseChain(item.Chain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chains config: %w", err)
+		}
+		if len(item.Endpoints) == 0 {
+			continue
+		}
+		for _, endpoint := range item.Endpoints {
+			if endpoint.URL == "" {
+				return nil, fmt.Errorf("invalid chains config: empty url for chain %s", item.Chain)
+			}
+		}
+		r.pools[chain] = NewPool(chain, item.Endpoints)
+	}
+	return r, nil
+}
+
+// Pool returns the endpoints of the chain
+func (r *Registry) Pool(chain common.Chain) (*Pool, error) {
+	pool, ok := r.pools[chain]
+	if !ok {
+		return nil, fmt.Errorf("chain: %s doesn't support", chain)
+	}
+	return pool, nil
+}
+
+// Do calls fn with the endpoints of the chain, see Pool.Do
+func (r *Registry) Do(ctx context.Context, chain common.Chain, fn func(e *Endpoint) error) error {
+	pool, err := r.Pool(chain)
+	if err != nil {
+		return err
+	}
+	return pool.Do(ctx, fn)
+}
+
+// Stats reports the health of every endpoint sorted by chain in the configured order
+func (r *Registry) Stats() []Stats {
+	chains := make([]common.Chain, 0, len(r.pools))
+	for chain := range r.pools {
+		chains = append(chains, chain)
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].String() < chains[j].String()
+	})
+	var stats []Stats
+	for _, chain := range chains {
+		stats = append(stats, r.pools[chain].Stats()...)
+	}
+	return stats
+}
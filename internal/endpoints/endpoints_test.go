@@ -0,0 +1,123 @@
+package endpoints
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+func TestNewRegistry(t *testing.T) {
+	defaults := map[common.Chain][]string{
+		common.Ethereum: {"https://ethereum-rpc.publicnode.com"},
+		common.Tron:     {"https://api.trongrid.io"},
+	}
+	r, err := NewRegistry([]config.ChainConfig{
+		{Chain: "ethereum", Endpoints: []config.Endpoint{
+			{URL: "https://eth.example.com/", Headers: map[string]string{"X-Api-Key": "secret"}},
+			{URL: "https://ethereum-rpc.publicnode.com"},
+		}},
+	}, defaults)
+	assert.NoError(t, err)
+	pool, err := r.Pool(common.Ethereum)
+	assert.NoError(t, err)
+	assert.Len(t, pool.endpoints, 2)
+	assert.Equal(t, "https://eth.example.com", pool.endpoints[0].URL)
+	assert.Equal(t, "secret", pool.endpoints[0].Headers["X-Api-Key"])
+	pool, err = r.Pool(common.Tron)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.trongrid.io", pool.endpoints[0].URL)
+	_, err = r.Pool(common.Sui)
+	assert.Error(t, err)
+
+	_, err = NewRegistry([]config.ChainConfig{{Chain: "Moon", Endpoints: []config.Endpoint{{URL: "https://moon"}}}}, defaults)
+	assert.Error(t, err)
+	// a chain listed without endpoints keeps the built-in ones
+	r, err = NewRegistry([]config.ChainConfig{{Chain: "Tron"}}, defaults)
+	assert.NoError(t, err)
+	pool, err = r.Pool(common.Tron)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://api.trongrid.io", pool.endpoints[0].URL)
+	_, err = NewRegistry([]config.ChainConfig{{Chain: "Ethereum", Endpoints: []config.Endpoint{{}}}}, defaults)
+	assert.Error(t, err)
+}
+
+func TestPoolFailover(t *testing.T) {
+	errDown := errors.New("down")
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	pool := NewPool(common.Ethereum, []config.Endpoint{{URL: "https://a"}, {URL: "https://b"}})
+	pool.now = func() time.Time { return now }
+	var tried []string
+	call := func(e *Endpoint) error {
+		tried = append(tried, e.URL)
+		if e.URL == "https://a" {
+			return errDown
+		}
+		return nil
+	}
+	assert.NoError(t, pool.Do(context.Background(), call))
+	assert.Equal(t, []string{"https://a", "https://b"}, tried)
+
+	// the failed endpoint is tried last until a minute went by
+	tried = nil
+	assert.NoError(t, pool.Do(context.Background(), call))
+	assert.Equal(t, []string{"https://b"}, tried)
+	now = now.Add(time.Minute)
+	tried = nil
+	assert.NoError(t, pool.Do(context.Background(), call))
+	assert.Equal(t, []string{"https://a", "https://b"}, tried)
+
+	// the error of the last endpoint is returned
+	err := pool.Do(context.Background(), func(e *Endpoint) error {
+		return errDown
+	})
+	assert.ErrorIs(t, err, errDown)
+
+	stats := pool.Stats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "Ethereum", stats[0].Chain)
+	assert.Equal(t, int64(3), stats[0].Requests)
+	assert.Equal(t, int64(3), stats[0].Failures)
+	assert.Equal(t, int64(3), stats[0].ConsecutiveFailures)
+	assert.Equal(t, "down", stats[0].LastError)
+	assert.Equal(t, int64(4), stats[1].Requests)
+	assert.Equal(t, int64(1), stats[1].Failures)
+	assert.Equal(t, int64(1), stats[1].ConsecutiveFailures)
+	assert.NotNil(t, stats[1].LastSuccessAt)
+}
+
+func TestPoolCancelled(t *testing.T) {
+	pool := NewPool(common.Ethereum, []config.Endpoint{{URL: "https://a"}, {URL: "https://b"}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls := 0
+	err := pool.Do(ctx, func(e *Endpoint) error {
+		calls++
+		return ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	// the caller gave up, neither endpoint is blamed
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, int64(0), pool.Stats()[0].Failures)
+}
+
+func TestEndpointHeaders(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		assert.Equal(t, "/v1/accounts/T1", r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+	e := &Endpoint{URL: mockServer.URL, Headers: map[string]string{"X-Api-Key": "secret"}}
+	resp, err := e.Get(context.Background(), "/v1/accounts/T1")
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// lookupAddressHandler tells whether the address belongs to a registered vault that joined the airdrop
+func (a *Api) lookupAddressHandler(c *gin.Context) {
+	chain, err := common.ParseChain(c.Param("chain"))
+	if err != nil || c.Param("address") == "" {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	lookups, err := a.lookupAddresses([]models.AddressKey{{Chain: chain, Address: c.Param("address")}})
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToLookupAddress)
+		return
+	}
+	c.JSON(http.StatusOK, lookups[0])
+}
+
+// lookupAddressesHandler looks up to MaxPageSize addresses, the results are in the order of the request
+func (a *Api) lookupAddressesHandler(c *gin.Context) {
+	var req []models.AddressLookupRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req) == 0 || len(req) > MaxPageSize {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	keys := make([]models.AddressKey, len(req))
+	for i, item := range req {
+		chain, err := common.ParseChain(item.Chain)
+		if err != nil || item.Address == "" {
+			_ = c.Error(errInvalidRequest)
+			return
+		}
+		keys[i] = models.AddressKey{Chain: chain, Address: item.Address}
+	}
+	lookups, err := a.lookupAddresses(keys)
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToLookupAddress)
+		return
+	}
+	c.JSON(http.StatusOK, lookups)
+}
+
+// lookupAddresses reveals the vault of each address as far as the configured privacy allows
+func (a *Api) lookupAddresses(keys []models.AddressKey) ([]models.AddressLookup, error) {
+	vaults, err := a.s.LookupAddresses(keys)
+	if err != nil {
+		return nil, err
+	}
+	privacy := a.cfg.AddressLookup
+	lookups := make([]models.AddressLookup, len(keys))
+	for i, key := range keys {
+		vaultID, ok := vaults[models.AddressKey{Chain: key.Chain, Address: strings.ToLower(key.Address)}]
+		lookups[i] = models.AddressLookup{Chain: key.Chain, Address: key.Address, Registered: ok}
+		if !ok {
+			continue
+		}
+		switch privacy.Privacy {
+		case config.LookupPrivacyNone:
+			lookups[i].VaultID = &vaultID
+		case config.LookupPrivacyHashed:
+			lookups[i].VaultHash = models.HashVaultID(privacy.HashSecret, vaultID)
+		}
+	}
+	return lookups, nil
+}
@@ -21,12 +21,12 @@ import (
 
 // Api is the main handler for the API
 type Api struct {
-	logger       *logrus.Logger
-	cfg          *config.Config
-	s            *services.Storage
-	router       *gin.Engine
-	cachedData   *cache.Cache
-	questService *QuestService
+	logger     *logrus.Logger
+	cfg        *config.Config
+	s          *services.Storage
+	router     *gin.Engine
+	cachedData *cache.Cache
+	quests     map[string]*partnerQuest // configured quests by slug
 }
 
 // NewApi creates a new Api instance
@@ -37,17 +37,22 @@ func NewApi(cfg *config.Config, s *services.Storage) (*Api, error) {
 	if nil == s {
 		return nil, fmt.Errorf("storage is nil")
 	}
-	questService, err := NewQuestService(s)
+	quests, err := newConfiguredQuests(cfg.Quests)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create quest service: %w", err)
+		return nil, fmt.Errorf("invalid quests: %w", err)
+	}
+	router := gin.Default()
+	// forwarding headers are only honored from the proxies in front of the api
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
 	}
 	return &Api{
-		cfg:          cfg,
-		s:            s,
-		router:       gin.Default(),
-		logger:       logrus.WithField("module", "api").Logger,
-		cachedData:   cache.New(5*time.Minute, 10*time.Minute),
-		questService: questService,
+		cfg:        cfg,
+		s:          s,
+		router:     router,
+		logger:     logrus.WithField("module", "api").Logger,
+		cachedData: cache.New(5*time.Minute, 10*time.Minute),
+		quests:     quests,
 	}, nil
 }
 
@@ -55,7 +60,7 @@ func (a *Api) setupRouting() {
 	a.router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Replace with your allowed origins
 		AllowMethods:     []string{"GET", "POST", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "x-hex-chain-code"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "x-hex-chain-code", "X-Api-Key"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -77,6 +82,8 @@ func (a *Api) setupRouting() {
 	rg.POST("/vault", a.registerVaultHandler)
 	rg.DELETE("/vault/:ecdsaPublicKey/:eddsaPublicKey", a.deleteVaultHandler)
 	rg.GET("/vault/:ecdsaPublicKey/:eddsaPublicKey", a.getVaultHandler)
+	rg.GET("/vault/:ecdsaPublicKey/:eddsaPublicKey/points", a.getVaultPointEventsHandler)
+	rg.GET("/vault/:ecdsaPublicKey/:eddsaPublicKey/history", a.getVaultHistoryHandler)
 	rg.POST("/vault/:ecdsaPublicKey/:eddsaPublicKey/alias", a.updateAliasHandler)
 	rg.POST("/vault/:ecdsaPublicKey/:eddsaPublicKey/referral", a.updateReferralHandler)
 	rg.GET("/vault/shared/:uid", a.getVaultByUIDHandler)
@@ -88,6 +95,8 @@ func (a *Api) setupRouting() {
 	rg.POST("/coin/:ecdsaPublicKey/:eddsaPublicKey", a.addCoin)
 	rg.POST("/coins/:ecdsaPublicKey/:eddsaPublicKey", a.addCoins)
 	rg.GET("/coin/:ecdsaPublicKey/:eddsaPublicKey", a.getCoin)
+	rg.GET("/coin/:ecdsaPublicKey/:eddsaPublicKey/:coinID/history", a.getCoinHistoryHandler)
+	rg.GET("/coin/:ecdsaPublicKey/:eddsaPublicKey/discovered", a.getDiscoveredCoinsHandler)
 
 	// Vault Share Appearance
 	rg.GET("vault/theme/:uid", a.getVaultShareAppearanceHandler)
@@ -106,7 +115,12 @@ func (a *Api) setupRouting() {
 	// new endpoint for fetching total points of a season
 	rg.GET("/seasons/points/:seasonID", a.getTotalPointsBySeasonHandler)
 
-	// coinmarketcap quest
+	// address lookup
+	rg.GET("/address/:chain/:address", a.lookupAddressHandler)
+	rg.POST("/address/lookup", a.lookupAddressesHandler)
+
+	// partner quests
+	rg.GET("/quests/:questID/verify", a.verifyQuestHandler)
 	rg.GET("/cmc/quest/verify", a.verifyCoinMarketCapQuest)
 
 }
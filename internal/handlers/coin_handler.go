@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -24,13 +25,24 @@ func addCoin(store *services.Storage, coin models.CoinBase, ecdsaPublicKey, edds
 	if vault.HexChainCode != hexChainCode {
 		return 0, errForbiddenAccess
 	}
-	addr, err := vault.GetAddress(coin.Chain)
-	if err != nil {
+	derived, err := store.EnsureVaultAddresses(vault)
+	if err != nil && !errors.Is(err, models.ErrDeriveAddress) {
+		return 0, errFailedToGetAddress
+	}
+	addr, ok := models.AddressMap(derived)[coin.Chain]
+	if !ok {
 		return 0, errFailedToGetAddress
 	}
 	if coin.Address != addr {
 		return 0, errAddressNotMatch
 	}
+	owners, err := store.GetAddressOwners([]models.AddressKey{{Chain: coin.Chain, Address: addr}})
+	if err != nil {
+		return 0, errFailedToAddCoin
+	}
+	if owner, ok := owners[models.AddressKey{Chain: coin.Chain, Address: addr}]; ok && owner != vault.ID {
+		return 0, errAddressOwnedByVault
+	}
 	coinDB := models.CoinDBModel{
 		CoinBase: coin,
 		VaultID:  vault.ID,
@@ -151,3 +163,20 @@ func (a *Api) getCoin(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, coin.CoinBase)
 }
+
+// getDiscoveredCoinsHandler lists the tokens the worker found on the vault addresses and added to its coins
+func (a *Api) getDiscoveredCoinsHandler(c *gin.Context) {
+	vault, err := a.s.GetVault(c.Param("ecdsaPublicKey"), c.Param("eddsaPublicKey"))
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errVaultNotFound)
+		return
+	}
+	discovered, err := a.s.GetDiscoveredCoins(vault.ID)
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToGetCoin)
+		return
+	}
+	c.JSON(http.StatusOK, discovered)
+}
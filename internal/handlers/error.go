@@ -8,27 +8,54 @@ import (
 )
 
 var (
-	errUnknown                 = errors.New("UNKNOWN_ERROR")
-	errInvalidRequest          = errors.New("INVALID_REQUEST")
-	errVaultAlreadyRegist      = errors.New("VAULT_ALREADY_REGISTERED")
-	errFailedToRegisterVault   = errors.New("FAIL_TO_REGISTER_VAULT")
-	errVaultNotFound           = errors.New("VAULT_NOT_FOUND")
-	errFailedToGetVault        = errors.New("FAIL_TO_GET_VAULT")
-	errFailedToDeleteVault     = errors.New("FAIL_TO_DELETE_VAULT")
-	errFailedToGetCoin         = errors.New("FAIL_TO_GET_COIN")
-	errFailedToJoinRegistry    = errors.New("FAIL_TO_JOIN_REGISTRY")
-	errFailedToUpdateVault     = errors.New("FAIL_TO_UPDATE_VAULT")
-	errFailedToExitRegistry    = errors.New("FAIL_TO_EXIT_REGISTRY")
-	errForbiddenAccess         = errors.New("FORBIDDEN_ACCESS")
-	errFailedToGetAddress      = errors.New("FAIL_TO_GET_ADDRESS")
-	errAddressNotMatch         = errors.New("ADDRESS_NOT_MATCH")
-	errFailedToAddCoin         = errors.New("FAIL_TO_ADD_COIN")
-	errFailedToDeleteCoin      = errors.New("FAIL_TO_DELETE_COIN")
-	errFailedToDerivePublicKey = errors.New("FAIL_TO_DERIVE_PUBLIC_KEY")
-	errFailedToGetTheme        = errors.New("FAIL_TO_GET_THEME")
-	errFailedToSetTheme        = errors.New("FAIL_TO_SET_THEME")
-	errLogoTooLarge            = errors.New("LOGO_TOO_LARGE")
-	errFailedToGetCollection   = errors.New("FAIL_TO_GET_COLLECTION")
+	errUnknown                  = errors.New("UNKNOWN_ERROR")
+	errInvalidRequest           = errors.New("INVALID_REQUEST")
+	errVaultAlreadyRegist       = errors.New("VAULT_ALREADY_REGISTERED")
+	errFailedToRegisterVault    = errors.New("FAIL_TO_REGISTER_VAULT")
+	errVaultNotFound            = errors.New("VAULT_NOT_FOUND")
+	errFailedToGetVault         = errors.New("FAIL_TO_GET_VAULT")
+	errFailedToDeleteVault      = errors.New("FAIL_TO_DELETE_VAULT")
+	errFailedToGetCoin          = errors.New("FAIL_TO_GET_COIN")
+	errFailedToJoinRegistry     = errors.New("FAIL_TO_JOIN_REGISTRY")
+	errFailedToUpdateVault      = errors.New("FAIL_TO_UPDATE_VAULT")
+	errFailedToExitRegistry     = errors.New("FAIL_TO_EXIT_REGISTRY")
+	errForbiddenAccess          = errors.New("FORBIDDEN_ACCESS")
+	errFailedToGetAddress       = errors.New("FAIL_TO_GET_ADDRESS")
+	errAddressNotMatch          = errors.New("ADDRESS_NOT_MATCH")
+	errFailedToAddCoin          = errors.New("FAIL_TO_ADD_COIN")
+	errFailedToDeleteCoin       = errors.New("FAIL_TO_DELETE_COIN")
+	errFailedToDerivePublicKey  = errors.New("FAIL_TO_DERIVE_PUBLIC_KEY")
+	errFailedToGetTheme         = errors.New("FAIL_TO_GET_THEME")
+	errFailedToSetTheme         = errors.New("FAIL_TO_SET_THEME")
+	errLogoTooLarge             = errors.New("LOGO_TOO_LARGE")
+	errFailedToGetCollection    = errors.New("FAIL_TO_GET_COLLECTION")
+	errFailedToGetPointEvents   = errors.New("FAIL_TO_GET_POINT_EVENTS")
+	errInvalidSeasonFormula     = errors.New("INVALID_SEASON_FORMULA")
+	errFailedToGetHistory       = errors.New("FAIL_TO_GET_HISTORY")
+	errUnauthorized             = errors.New("UNAUTHORIZED")
+	errJobInProgress            = errors.New("JOB_IN_PROGRESS")
+	errNoJobInProgress          = errors.New("NO_JOB_IN_PROGRESS")
+	errJobNotFound              = errors.New("JOB_NOT_FOUND")
+	errJobSucceeded             = errors.New("JOB_ALREADY_SUCCEEDED")
+	errLedgerIncomplete         = errors.New("LEDGER_INCOMPLETE")
+	errNoCurrentSeason          = errors.New("NO_CURRENT_SEASON")
+	errFailedToGetStatus        = errors.New("FAIL_TO_GET_STATUS")
+	errFailedToCancelJob        = errors.New("FAIL_TO_CANCEL_JOB")
+	errFailedToRerunJob         = errors.New("FAIL_TO_RERUN_JOB")
+	errFailedToRescoreVault     = errors.New("FAIL_TO_RESCORE_VAULT")
+	errAddressOwnedByVault      = errors.New("ADDRESS_OWNED_BY_OTHER_VAULT")
+	errFailedToGetCollisions    = errors.New("FAIL_TO_GET_COLLISIONS")
+	errFailedToLookupAddress    = errors.New("FAIL_TO_LOOKUP_ADDRESS")
+	errQuestNotFound            = errors.New("QUEST_NOT_FOUND")
+	errFailedToVerifyQuest      = errors.New("FAIL_TO_VERIFY_QUEST")
+	errFailedToSaveQuest        = errors.New("FAIL_TO_SAVE_QUEST")
+	errFailedToGetVerifications = errors.New("FAIL_TO_GET_VERIFICATIONS")
+	errFailedToGetAssets        = errors.New("FAIL_TO_GET_ASSETS")
+	errFailedToSaveAsset        = errors.New("FAIL_TO_SAVE_ASSET")
+	errPriceAlertNotFound       = errors.New("PRICE_ALERT_NOT_FOUND")
+	errPriceAlertNotPending     = errors.New("PRICE_ALERT_NOT_PENDING")
+	errFailedToGetPriceAlerts   = errors.New("FAIL_TO_GET_PRICE_ALERTS")
+	errFailedToResolveAlert     = errors.New("FAIL_TO_RESOLVE_PRICE_ALERT")
 )
 
 func ErrorHandler() gin.HandlerFunc {
@@ -46,10 +73,24 @@ func ErrorHandler() gin.HandlerFunc {
 				statusCode = http.StatusBadRequest
 			case errors.Is(err, errAddressNotMatch):
 				statusCode = http.StatusBadRequest
-			case errors.Is(err, errVaultNotFound):
+			case errors.Is(err, errAddressOwnedByVault):
+				statusCode = http.StatusConflict
+			case errors.Is(err, errVaultNotFound),
+				errors.Is(err, errQuestNotFound),
+				errors.Is(err, errPriceAlertNotFound),
+				errors.Is(err, errJobNotFound):
 				statusCode = http.StatusNotFound
 			case errors.Is(err, errForbiddenAccess):
 				statusCode = http.StatusForbidden
+			case errors.Is(err, errUnauthorized):
+				statusCode = http.StatusUnauthorized
+			case errors.Is(err, errJobInProgress),
+				errors.Is(err, errJobSucceeded),
+				errors.Is(err, errLedgerIncomplete),
+				errors.Is(err, errNoJobInProgress),
+				errors.Is(err, errNoCurrentSeason),
+				errors.Is(err, errPriceAlertNotPending):
+				statusCode = http.StatusConflict
 			case errors.Is(err, errFailedToRegisterVault),
 				errors.Is(err, errFailedToGetVault),
 				errors.Is(err, errFailedToDeleteVault),
@@ -63,7 +104,23 @@ func ErrorHandler() gin.HandlerFunc {
 				errors.Is(err, errFailedToDerivePublicKey),
 				errors.Is(err, errFailedToSetTheme),
 				errors.Is(err, errFailedToGetTheme),
-				errors.Is(err, errFailedToGetCollection):
+				errors.Is(err, errFailedToGetCollection),
+				errors.Is(err, errFailedToGetPointEvents),
+				errors.Is(err, errInvalidSeasonFormula),
+				errors.Is(err, errFailedToGetHistory),
+				errors.Is(err, errFailedToGetStatus),
+				errors.Is(err, errFailedToCancelJob),
+				errors.Is(err, errFailedToRerunJob),
+				errors.Is(err, errFailedToRescoreVault),
+				errors.Is(err, errFailedToGetCollisions),
+				errors.Is(err, errFailedToLookupAddress),
+				errors.Is(err, errFailedToVerifyQuest),
+				errors.Is(err, errFailedToSaveQuest),
+				errors.Is(err, errFailedToGetVerifications),
+				errors.Is(err, errFailedToGetAssets),
+				errors.Is(err, errFailedToSaveAsset),
+				errors.Is(err, errFailedToGetPriceAlerts),
+				errors.Is(err, errFailedToResolveAlert):
 				statusCode = http.StatusInternalServerError
 			default:
 				statusCode = http.StatusInternalServerError
@@ -10,6 +10,12 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+const (
+	assetRegistryKey = "asset-registry"
+	assetRegistryTTL = time.Minute
 )
 
 type SetNftProfileRequest struct {
@@ -97,8 +103,9 @@ func (a *Api) setNftAvatarHandler(c *gin.Context) {
 		}
 
 		owned := false
-		ethAddress, err := v.GetAddress(common.Ethereum)
-		if err != nil {
+		derived, err := a.s.EnsureVaultAddresses(v)
+		ethAddress, ok := models.AddressMap(derived)[common.Ethereum]
+		if !ok {
 			a.logger.Errorf("fail to get address: %v", err)
 			_ = c.Error(errFailedToGetAddress)
 			return
@@ -148,11 +155,18 @@ func (a *Api) getCollectionMinPriceHandler(c *gin.Context) {
 		_ = c.Error(errInvalidRequest)
 		return
 	}
-	if !strings.EqualFold(collectionID, "0xa98b29a8f5a247802149c268ecf860b8308b7291") {
+	assets, err := a.getAssetRegistry()
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToGetCollection)
+		return
+	}
+	collection, ok := assets.Lookup(common.Ethereum, collectionID)
+	if !ok || !assets.IsNFTCollection(common.Ethereum, collectionID) {
 		_ = c.Error(errAddressNotMatch)
 		return
 	}
-	collectionSlug := "thorguards"
+	collectionSlug := collection.PriceProviderID
 	//check cache first
 	if cached, ok := a.cachedData.Get(collectionSlug); ok {
 		if price, ok := cached.(OpenSeaBestCollectionResponse); ok {
@@ -192,3 +206,16 @@ func (a *Api) getCollectionMinPriceHandler(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"minPrice": openseaResp.Listings[0].Price.Current})
 }
+
+// getAssetRegistry returns the tracked assets, cached for a minute
+func (a *Api) getAssetRegistry() (*models.AssetRegistry, error) {
+	if cached, ok := a.cachedData.Get(assetRegistryKey); ok {
+		return cached.(*models.AssetRegistry), nil
+	}
+	assets, err := a.s.GetAssetRegistry()
+	if err != nil {
+		return nil, err
+	}
+	a.cachedData.Set(assetRegistryKey, assets, assetRegistryTTL)
+	return assets, nil
+}
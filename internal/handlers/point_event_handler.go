@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const MaxPointEventPageSize = 1000
+
+func (a *Api) getVaultPointEventsHandler(c *gin.Context) {
+	ecdsaPublicKey := c.Param("ecdsaPublicKey")
+	eddsaPublicKey := c.Param("eddsaPublicKey")
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	if limit > MaxPointEventPageSize {
+		limit = MaxPointEventPageSize
+	}
+	vault, err := a.s.GetVault(ecdsaPublicKey, eddsaPublicKey)
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errVaultNotFound)
+		return
+	}
+	events, err := a.s.GetPointEvents(vault.ID, limit)
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToGetPointEvents)
+		return
+	}
+	c.JSON(http.StatusOK, events)
+}
@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+const (
+	coinMarketCapQuest = "cmc"
+	storedQuestsKey    = "stored-quests"
+	storedQuestsTTL    = time.Minute
+)
+
+// partnerQuest is a quest ready to verify, with its parsed response template and allowed networks
+type partnerQuest struct {
+	models.Quest
+	response *template.Template
+	networks []*net.IPNet
+}
+
+// questResponse is what the response template of a quest renders
+type questResponse struct {
+	Valid   bool
+	Address questString
+	Quest   questString
+}
+
+// questString is a value rendered into the JSON response of a quest. Printed it is escaped to sit inside a
+// JSON string, and {{json .}} quotes it, so an address from the query cannot add fields to the response.
+type questString string
+
+func (s questString) String() string {
+	buf, _ := json.Marshal(string(s))
+	return string(buf[1 : len(buf)-1])
+}
+
+var questFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		buf, err := json.Marshal(v)
+		return string(buf), err
+	},
+}
+
+func newPartnerQuest(quest models.Quest) (*partnerQuest, error) {
+	if err := quest.Validate(); err != nil {
+		return nil, err
+	}
+	if quest.Chain == common.Undefined {
+		quest.Chain = common.Ethereum
+	}
+	response := quest.Response
+	if response == "" {
+		response = models.DefaultQuestResponse
+	}
+	tmpl, err := template.New(quest.Slug).Funcs(questFuncs).Parse(response)
+	if err != nil {
+		return nil, fmt.Errorf("quest %s: invalid response template: %w", quest.Slug, err)
+	}
+	// an address with characters to escape shows a value printed outside of a JSON string
+	for _, valid := range []bool{true, false} {
+		var buf bytes.Buffer
+		sample := questResponse{Valid: valid, Address: `0x"\`, Quest: questString(quest.Slug)}
+		if err := tmpl.Execute(&buf, sample); err != nil {
+			return nil, fmt.Errorf("quest %s: invalid response template: %w", quest.Slug, err)
+		}
+		if !json.Valid(buf.Bytes()) {
+			return nil, fmt.Errorf("quest %s: response template does not render JSON", quest.Slug)
+		}
+	}
+	pq := &partnerQuest{Quest: quest, response: tmpl}
+	for _, allowed := range quest.AllowedIPs {
+		if !strings.Contains(allowed, "/") {
+			if ip := net.ParseIP(allowed); ip != nil && ip.To4() != nil {
+				allowed += "/32"
+			} else {
+				allowed += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(allowed)
+		if err != nil {
+			return nil, fmt.Errorf("quest %s: invalid allowed ip %s: %w", quest.Slug, allowed, err)
+		}
+		pq.networks = append(pq.networks, network)
+	}
+	return pq, nil
+}
+
+// newConfiguredQuests returns the quests of the config by slug, with the coinmarketcap quest the
+// registry always served unless the config replaces it
+func newConfiguredQuests(quests []config.Quest) (map[string]*partnerQuest, error) {
+	result := make(map[string]*partnerQuest, len(quests)+1)
+	cmc, err := newPartnerQuest(models.Quest{Slug: coinMarketCapQuest, Rule: models.QuestRuleRegistered, Chain: common.Ethereum})
+	if err != nil {
+		return nil, err
+	}
+	result[cmc.Slug] = cmc
+	for _, item := range quests {
+		quest := models.Quest{
+			Slug:            item.Slug,
+			Rule:            models.QuestRule(item.Rule),
+			Ticker:          item.Ticker,
+			ContractAddress: item.ContractAddress,
+			MinAmount:       item.MinAmount,
+			APIKeys:         item.APIKeys,
+			AllowedIPs:      item.AllowedIPs,
+			Response:        item.Response,
+			Points:          item.Points,
+		}
+		if item.Chain != "" {
+			chain, err := common.ParseChain(item.Chain)
+			if err != nil {
+				return nil, fmt.Errorf("quest %s: %w", item.Slug, err)
+			}
+			quest.Chain = chain
+		}
+		if !item.RegisteredBefore.IsZero() {
+			registeredBefore := item.RegisteredBefore
+			quest.RegisteredBefore = &registeredBefore
+		}
+		pq, err := newPartnerQuest(quest)
+		if err != nil {
+			return nil, err
+		}
+		result[pq.Slug] = pq
+	}
+	return result, nil
+}
+
+// getQuest returns the enabled quest with the slug, a stored quest replaces the configured one
+func (a *Api) getQuest(slug string) (*partnerQuest, error) {
+	stored, err := a.getStoredQuests()
+	if err != nil {
+		return nil, err
+	}
+	if quest, ok := stored[slug]; ok {
+		if quest.Disabled {
+			return nil, nil
+		}
+		return quest, nil
+	}
+	return a.quests[slug], nil
+}
+
+func (a *Api) getStoredQuests() (map[string]*partnerQuest, error) {
+	if cached, ok := a.cachedData.Get(storedQuestsKey); ok {
+		return cached.(map[string]*partnerQuest), nil
+	}
+	quests, err := a.s.GetQuests()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]*partnerQuest, len(quests))
+	for _, quest := range quests {
+		pq, err := newPartnerQuest(quest)
+		if err != nil {
+			a.logger.Errorf("skip stored quest: %v", err)
+			continue
+		}
+		result[pq.Slug] = pq
+	}
+	a.cachedData.Set(storedQuestsKey, result, storedQuestsTTL)
+	return result, nil
+}
+
+// authorize checks the api key or the ip of the caller against the quest, a quest without keys and ips
+// is open. The key is only read from the X-Api-Key header so it stays out of access logs, and the ip is
+// taken from forwarding headers only when they come from a trusted proxy. It returns the hash prefix of
+// the api key identifying the caller in the audit.
+func (q *partnerQuest) authorize(c *gin.Context) (string, bool) {
+	key := c.GetHeader("X-Api-Key")
+	var caller string
+	if key != "" {
+		sum := sha256.Sum256([]byte(key))
+		caller = hex.EncodeToString(sum[:8])
+	}
+	if len(q.APIKeys) == 0 && len(q.networks) == 0 {
+		return caller, true
+	}
+	for _, apiKey := range q.APIKeys {
+		if key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			return caller, true
+		}
+	}
+	if ip := net.ParseIP(c.ClientIP()); ip != nil {
+		for _, network := range q.networks {
+			if network.Contains(ip) {
+				return caller, true
+			}
+		}
+	}
+	return caller, false
+}
+
+// verifyQuestHandler tells a partner whether the address belongs to a registered vault completing the quest
+func (a *Api) verifyQuestHandler(c *gin.Context) {
+	a.verifyQuest(c, c.Param("questID"))
+}
+
+// verifyCoinMarketCapQuest keeps the coinmarketcap endpoint working on top of its quest
+func (a *Api) verifyCoinMarketCapQuest(c *gin.Context) {
+	a.verifyQuest(c, coinMarketCapQuest)
+}
+
+func (a *Api) verifyQuest(c *gin.Context, slug string) {
+	quest, err := a.getQuest(slug)
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToVerifyQuest)
+		return
+	}
+	if quest == nil {
+		_ = c.Error(errQuestNotFound)
+		return
+	}
+	address := strings.TrimSpace(c.Query("address"))
+	caller, ok := quest.authorize(c)
+	verification := models.QuestVerification{
+		QuestSlug: quest.Slug,
+		Address:   address,
+		ClientIP:  c.ClientIP(),
+		Caller:    caller,
+	}
+	defer func() {
+		if err := a.s.AddQuestVerification(&verification); err != nil {
+			a.logger.Error(err)
+		}
+	}()
+	if !ok {
+		verification.Status = models.QuestStatusUnauthorized
+		_ = c.Error(errUnauthorized)
+		return
+	}
+	if address == "" {
+		verification.Status = models.QuestStatusInvalid
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	valid, vaultID, err := a.completeQuest(quest, address)
+	verification.VaultID = vaultID
+	if err != nil {
+		a.logger.Error(err)
+		verification.Status = models.QuestStatusError
+		_ = c.Error(errFailedToVerifyQuest)
+		return
+	}
+	verification.Status = models.QuestStatusInvalid
+	if valid {
+		verification.Status = models.QuestStatusValid
+	}
+	var buf bytes.Buffer
+	if err := quest.response.Execute(&buf, questResponse{Valid: valid, Address: questString(address), Quest: questString(quest.Slug)}); err != nil {
+		a.logger.Errorf("failed to render response of quest %s: %v", quest.Slug, err)
+		verification.Status = models.QuestStatusError
+		_ = c.Error(errFailedToVerifyQuest)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", buf.Bytes())
+}
+
+// completeQuest verifies the vault of the address against the quest and records its first completion,
+// it returns the vault id or 0 when the address belongs to no vault in the airdrop
+func (a *Api) completeQuest(quest *partnerQuest, address string) (bool, uint, error) {
+	key := models.AddressKey{Chain: quest.Chain, Address: address}
+	vaults, err := a.s.LookupAddresses([]models.AddressKey{key})
+	if err != nil {
+		return false, 0, err
+	}
+	vaultID, ok := vaults[models.AddressKey{Chain: key.Chain, Address: strings.ToLower(key.Address)}]
+	if !ok {
+		return false, 0, nil
+	}
+	vault, err := a.s.GetVaultByID(vaultID)
+	if err != nil {
+		return false, vaultID, err
+	}
+	var coins []models.CoinDBModel
+	if quest.Rule == models.QuestRuleTokenBalance {
+		if coins, err = a.s.GetCoins(vaultID); err != nil {
+			return false, vaultID, err
+		}
+	}
+	if !quest.Verify(vault, coins) {
+		return false, vaultID, nil
+	}
+	first, err := a.s.CompleteQuest(quest.Slug, vaultID, quest.Points)
+	if err != nil {
+		return true, vaultID, err
+	}
+	if first && quest.Points > 0 {
+		a.logger.Infof("vault %d completed quest %s for %f points", vaultID, quest.Slug, quest.Points)
+	}
+	return true, vaultID, nil
+}
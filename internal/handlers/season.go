@@ -4,7 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/vultisig/airdrop-registry/internal/utils"
+	"github.com/vultisig/airdrop-registry/internal/scoring"
 )
 
 func (a *Api) getAllSeasonInfo(c *gin.Context) {
@@ -17,6 +17,13 @@ type SeasonPoints struct {
 }
 
 func (a *Api) getTotalPointsBySeasonHandler(c *gin.Context) {
+	// vaults hold the points of the current season, score them like the worker does
+	formula, err := scoring.NewFormula(a.cfg.GetCurrentSeason())
+	if err != nil {
+		a.logger.Error("failed to get season formula: ", err)
+		_ = c.Error(errInvalidSeasonFormula)
+		return
+	}
 	startId := uint(0)
 	totalPoints := 0.0
 	for {
@@ -30,9 +37,7 @@ func (a *Api) getTotalPointsBySeasonHandler(c *gin.Context) {
 			break
 		}
 		for _, vault := range allVaults {
-			referralMultiplier := utils.GetReferralMultiplier(vault.ReferralCount)
-			swapMultiplier := utils.GetSwapVolumeMultiplier(vault.SwapVolume)
-			totalPoints += vault.TotalPoints * referralMultiplier * swapMultiplier
+			totalPoints += formula.SeasonPoints(vault.TotalPoints, vault.ReferralCount, vault.SwapVolume)
 			startId = vault.ID
 		}
 
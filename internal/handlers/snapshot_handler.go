@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+const (
+	historyDateLayout  = "2006-01-02"
+	DefaultHistoryDays = 30
+	MaxHistoryDays     = 366
+)
+
+// parseHistoryQuery reads the from, to and granularity query parameters, to defaults to today and from to
+// DefaultHistoryDays before it
+func parseHistoryQuery(c *gin.Context) (time.Time, time.Time, models.Granularity, bool) {
+	granularity, err := models.ParseGranularity(c.Query("granularity"))
+	if err != nil {
+		return time.Time{}, time.Time{}, "", false
+	}
+	to := time.Now().UTC().Truncate(24 * time.Hour)
+	if strTo := c.Query("to"); strTo != "" {
+		if to, err = time.Parse(historyDateLayout, strTo); err != nil {
+			return time.Time{}, time.Time{}, "", false
+		}
+	}
+	from := to.AddDate(0, 0, -DefaultHistoryDays)
+	if strFrom := c.Query("from"); strFrom != "" {
+		if from, err = time.Parse(historyDateLayout, strFrom); err != nil {
+			return time.Time{}, time.Time{}, "", false
+		}
+	}
+	if from.After(to) || to.Sub(from) > MaxHistoryDays*24*time.Hour {
+		return time.Time{}, time.Time{}, "", false
+	}
+	return from, to, granularity, true
+}
+
+func (a *Api) getVaultHistoryHandler(c *gin.Context) {
+	ecdsaPublicKey := c.Param("ecdsaPublicKey")
+	eddsaPublicKey := c.Param("eddsaPublicKey")
+	from, to, granularity, ok := parseHistoryQuery(c)
+	if !ok {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	vault, err := a.s.GetVault(ecdsaPublicKey, eddsaPublicKey)
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errVaultNotFound)
+		return
+	}
+	snapshots, err := a.s.GetVaultSnapshots(vault.ID, from, to)
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToGetHistory)
+		return
+	}
+	c.JSON(http.StatusOK, models.Downsample(snapshots, granularity, func(s models.VaultDailySnapshot) time.Time {
+		return s.Date
+	}))
+}
+
+func (a *Api) getCoinHistoryHandler(c *gin.Context) {
+	ecdsaPublicKey := c.Param("ecdsaPublicKey")
+	eddsaPublicKey := c.Param("eddsaPublicKey")
+	strCoinID := c.Param("coinID")
+	from, to, granularity, ok := parseHistoryQuery(c)
+	if !ok {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	vault, err := a.s.GetVault(ecdsaPublicKey, eddsaPublicKey)
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errVaultNotFound)
+		return
+	}
+	coin, err := a.s.GetCoin(strCoinID)
+	if err != nil {
+		a.logger.Errorf("failed to get coin: %v", err)
+		_ = c.Error(errFailedToGetCoin)
+		return
+	}
+	if coin.VaultID != vault.ID {
+		_ = c.Error(errForbiddenAccess)
+		return
+	}
+	snapshots, err := a.s.GetCoinSnapshots(coin.ID, from, to)
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToGetHistory)
+		return
+	}
+	c.JSON(http.StatusOK, models.Downsample(snapshots, granularity, func(s models.CoinDailySnapshot) time.Time {
+		return s.Date
+	}))
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -46,7 +47,7 @@ func (a *Api) registerVaultHandler(c *gin.Context) {
 		_ = c.Error(errFailedToRegisterVault)
 		return
 	}
-	a.questService.Add(vaultModel)
+	a.claimAddresses(&vaultModel)
 	c.Status(http.StatusCreated)
 }
 
@@ -102,6 +103,7 @@ func (a *Api) getVaultHandler(c *gin.Context) {
 			})
 		}
 	}
+	vaultResp.Staleness = a.staleness(*vault, coins)
 	vaultResp.SeasonActivities = make([]models.SeasonStats, 0)
 	for _, season := range a.cfg.Seasons {
 		if season.ID == vault.CurrentSeasonID {
@@ -202,6 +204,7 @@ func (a *Api) getVaultByUIDHandler(c *gin.Context) {
 			})
 		}
 	}
+	vaultResp.Staleness = a.staleness(*vault, coins)
 	vaultResp.SeasonActivities = make([]models.SeasonStats, 0)
 	for _, season := range a.cfg.Seasons {
 		if season.ID == vault.CurrentSeasonID {
@@ -251,6 +254,7 @@ func (a *Api) joinAirdrop(c *gin.Context) {
 			_ = c.Error(errFailedToJoinRegistry)
 			return
 		}
+		a.claimAddresses(v)
 	} else {
 		_ = c.Error(errForbiddenAccess)
 		return
@@ -283,6 +287,7 @@ func (a *Api) exitAirdrop(c *gin.Context) {
 			_ = c.Error(errFailedToExitRegistry)
 			return
 		}
+		a.claimAddresses(v)
 	}
 	c.Status(http.StatusOK)
 }
@@ -314,7 +319,6 @@ func (a *Api) deleteVaultHandler(c *gin.Context) {
 		_ = c.Error(errForbiddenAccess)
 		return
 	}
-	a.questService.Remove(vault.ID)
 	c.Status(http.StatusOK)
 }
 
@@ -597,3 +601,30 @@ func (a *Api) getVaultsByVolumeHandler(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, vaultsResp)
 }
+
+// staleness reports the values of the vault that failed to refresh under the stale balance policy
+func (a *Api) staleness(vault models.Vault, coins []models.CoinDBModel) *models.Staleness {
+	staleness := models.NewStaleness(vault, coins, time.Now())
+	staleness.Flagged = a.cfg.StaleBalance.Exceeded(staleness.StaleDays)
+	staleness.Accruing = a.cfg.StaleBalance.Accrues(staleness.StaleDays)
+	return &staleness
+}
+
+// claimAddresses derives and stores the addresses of a vault when it registers, joins or exits the airdrop,
+// an address several vaults derive is credited to the first registered of them that joined. Failures are
+// left for the worker to store and claim again.
+func (a *Api) claimAddresses(vault *models.Vault) {
+	derived, err := a.s.EnsureVaultAddresses(vault)
+	if err != nil {
+		a.logger.Errorf("failed to get addresses of vault %d: %v", vault.ID, err)
+	}
+	addresses := models.AddressMap(derived)
+	foreign, err := a.s.ClaimAddresses(vault, addresses)
+	if err != nil {
+		a.logger.Errorf("failed to claim addresses of vault %d: %v", vault.ID, err)
+		return
+	}
+	for key, owner := range foreign {
+		a.logger.Warnf("%s address %s of vault %d is credited to vault %d", key.Chain, key.Address, vault.ID, owner)
+	}
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/services"
+)
+
+// WorkerAdminApi serves the admin endpoints of a worker instance, every request needs the admin token
+type WorkerAdminApi struct {
+	logger *logrus.Logger
+	cfg    *config.Config
+	s      *services.Storage
+	worker *services.PointWorker
+	router *gin.Engine
+}
+
+func NewWorkerAdminApi(cfg *config.Config, s *services.Storage, worker *services.PointWorker) (*WorkerAdminApi, error) {
+	if nil == cfg {
+		return nil, fmt.Errorf("config is nil")
+	}
+	if nil == s {
+		return nil, fmt.Errorf("storage is nil")
+	}
+	if nil == worker {
+		return nil, fmt.Errorf("worker is nil")
+	}
+	if cfg.Worker.Admin.Token == "" {
+		return nil, fmt.Errorf("worker admin token is required")
+	}
+	return &WorkerAdminApi{
+		logger: logrus.WithField("module", "worker_admin").Logger,
+		cfg:    cfg,
+		s:      s,
+		worker: worker,
+		router: gin.Default(),
+	}, nil
+}
+
+// Handler returns the routes of the admin api
+func (a *WorkerAdminApi) Handler() http.Handler {
+	a.router.Use(ErrorHandler())
+	a.router.Use(a.authenticate())
+	rg := a.router.Group("/admin")
+	rg.GET("/status", a.statusHandler)
+	rg.POST("/pause", a.pauseHandler)
+	rg.POST("/resume", a.resumeHandler)
+	rg.POST("/cancel", a.cancelHandler)
+	rg.POST("/jobs/:date/rerun", a.rerunJobHandler)
+	rg.POST("/vaults/:vaultID/rescore", a.rescoreVaultHandler)
+	rg.GET("/address-collisions", a.addressCollisionsHandler)
+	rg.PUT("/quests/:questID", a.saveQuestHandler)
+	rg.GET("/quests/:questID/verifications", a.questVerificationsHandler)
+	rg.GET("/assets", a.trackedAssetsHandler)
+	rg.PUT("/assets", a.saveTrackedAssetHandler)
+	rg.GET("/price-alerts", a.priceAlertsHandler)
+	rg.POST("/price-alerts/:alertID/approve", a.approvePriceAlertHandler)
+	rg.POST("/price-alerts/:alertID/reject", a.rejectPriceAlertHandler)
+	return a.router
+}
+
+func (a *WorkerAdminApi) authenticate() gin.HandlerFunc {
+	expected := []byte("Bearer " + a.cfg.Worker.Admin.Token)
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			_ = c.Error(errUnauthorized)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func (a *WorkerAdminApi) statusHandler(c *gin.Context) {
+	status, err := a.worker.Status()
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToGetStatus)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+func (a *WorkerAdminApi) pauseHandler(c *gin.Context) {
+	a.worker.Pause()
+	c.Status(http.StatusNoContent)
+}
+
+func (a *WorkerAdminApi) resumeHandler(c *gin.Context) {
+	a.worker.Resume()
+	c.Status(http.StatusNoContent)
+}
+
+func (a *WorkerAdminApi) cancelHandler(c *gin.Context) {
+	job, err := a.worker.CancelJob()
+	if err != nil {
+		if errors.Is(err, services.ErrNoJobInProgress) {
+			_ = c.Error(errNoJobInProgress)
+			return
+		}
+		a.logger.Error(err)
+		_ = c.Error(errFailedToCancelJob)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"job_id": job.ID, "date": job.Date()})
+}
+
+func (a *WorkerAdminApi) rerunJobHandler(c *gin.Context) {
+	date, err := time.Parse(historyDateLayout, c.Param("date"))
+	if err != nil || date.After(time.Now()) {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	job, err := a.worker.RerunJob(date)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrJobInProgress):
+			_ = c.Error(errJobInProgress)
+		case errors.Is(err, services.ErrJobNotFound):
+			_ = c.Error(errJobNotFound)
+		case errors.Is(err, services.ErrJobSucceeded):
+			_ = c.Error(errJobSucceeded)
+		default:
+			a.logger.Error(err)
+			_ = c.Error(errFailedToRerunJob)
+		}
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"job_id": job.ID, "date": job.Date()})
+}
+
+func (a *WorkerAdminApi) rescoreVaultHandler(c *gin.Context) {
+	vaultID, err := strconv.ParseUint(c.Param("vaultID"), 10, 64)
+	if err != nil {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	apply, err := strconv.ParseBool(c.DefaultQuery("apply", "false"))
+	if err != nil {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	vault, err := a.s.GetVaultByID(uint(vaultID))
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errVaultNotFound)
+		return
+	}
+	score, err := a.worker.RescoreVault(vault, apply)
+	if err != nil {
+		if errors.Is(err, services.ErrNoCurrentSeason) {
+			_ = c.Error(errNoCurrentSeason)
+			return
+		}
+		if errors.Is(err, services.ErrLedgerIncomplete) {
+			_ = c.Error(errLedgerIncomplete)
+			return
+		}
+		if errors.Is(err, services.ErrJobUnfinished) {
+			_ = c.Error(errJobInProgress)
+			return
+		}
+		a.logger.Error(err)
+		_ = c.Error(errFailedToRescoreVault)
+		return
+	}
+	c.JSON(http.StatusOK, score)
+}
+
+// addressCollisionsHandler reports the addresses derived by several vaults and the vault each is credited to
+func (a *WorkerAdminApi) addressCollisionsHandler(c *gin.Context) {
+	reports, err := a.s.GetAddressCollisions()
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToGetCollisions)
+		return
+	}
+	c.JSON(http.StatusOK, reports)
+}
+
+// saveQuestHandler stores the quest under the slug of the path, replacing the configured quest with that slug.
+// The api picks stored quests up within a minute.
+func (a *WorkerAdminApi) saveQuestHandler(c *gin.Context) {
+	var quest models.Quest
+	if err := c.ShouldBindJSON(&quest); err != nil {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	quest.ID = 0
+	quest.Slug = c.Param("questID")
+	if _, err := newPartnerQuest(quest); err != nil {
+		a.logger.Errorf("invalid quest: %v", err)
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	if err := a.s.SaveQuest(&quest); err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToSaveQuest)
+		return
+	}
+	c.JSON(http.StatusOK, quest)
+}
+
+// questVerificationsHandler pages through the verification calls of the quest, newest first
+func (a *WorkerAdminApi) questVerificationsHandler(c *gin.Context) {
+	beforeID, err := strconv.ParseUint(c.DefaultQuery("before_id", "0"), 10, 64)
+	if err != nil {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(MaxPageSize)))
+	if err != nil || limit <= 0 || limit > MaxPageSize {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	verifications, err := a.s.GetQuestVerifications(c.Param("questID"), uint(beforeID), limit)
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToGetVerifications)
+		return
+	}
+	c.JSON(http.StatusOK, verifications)
+}
+
+// trackedAssetsHandler lists the tracked assets, disabled ones included
+func (a *WorkerAdminApi) trackedAssetsHandler(c *gin.Context) {
+	assets, err := a.s.GetTrackedAssets()
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToGetAssets)
+		return
+	}
+	c.JSON(http.StatusOK, assets)
+}
+
+// saveTrackedAssetHandler stores the asset, replacing the tracked asset of its chain and contract.
+// Workers pick it up from their next shard, the api within a minute.
+func (a *WorkerAdminApi) saveTrackedAssetHandler(c *gin.Context) {
+	var asset models.TrackedAsset
+	if err := c.ShouldBindJSON(&asset); err != nil {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	asset.ID = 0
+	if asset.Kind == "" {
+		asset.Kind = models.AssetKindToken
+	}
+	if asset.PriceSource == "" {
+		asset.PriceSource = models.PriceSourceCMC
+	}
+	if err := asset.Validate(); err != nil {
+		a.logger.Errorf("invalid tracked asset: %v", err)
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	if err := a.s.SaveTrackedAsset(&asset); err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToSaveAsset)
+		return
+	}
+	c.JSON(http.StatusOK, asset)
+}
+
+// priceAlertsHandler lists the latest price alerts, the pending ones unless another status is asked for
+func (a *WorkerAdminApi) priceAlertsHandler(c *gin.Context) {
+	status := models.PriceAlertStatus(c.DefaultQuery("status", string(models.PriceAlertPending)))
+	if status != "all" && !models.ValidPriceAlertStatus(status) {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	if status == "all" {
+		status = ""
+	}
+	alerts, err := a.s.GetPriceAlerts(status, MaxPageSize)
+	if err != nil {
+		a.logger.Error(err)
+		_ = c.Error(errFailedToGetPriceAlerts)
+		return
+	}
+	c.JSON(http.StatusOK, alerts)
+}
+
+// approvePriceAlertHandler records the quarantined price and prices the coins of the alert with it
+func (a *WorkerAdminApi) approvePriceAlertHandler(c *gin.Context) {
+	a.resolvePriceAlert(c, a.s.ApprovePriceAlert)
+}
+
+// rejectPriceAlertHandler drops the quarantined price, the coins of the alert keep their last good price
+func (a *WorkerAdminApi) rejectPriceAlertHandler(c *gin.Context) {
+	a.resolvePriceAlert(c, a.s.RejectPriceAlert)
+}
+
+func (a *WorkerAdminApi) resolvePriceAlert(c *gin.Context, resolve func(id uint) (*models.PriceAlert, error)) {
+	alertID, err := strconv.ParseUint(c.Param("alertID"), 10, 64)
+	if err != nil {
+		_ = c.Error(errInvalidRequest)
+		return
+	}
+	alert, err := resolve(uint(alertID))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPriceAlertNotFound):
+			_ = c.Error(errPriceAlertNotFound)
+		case errors.Is(err, services.ErrPriceAlertNotPending):
+			_ = c.Error(errPriceAlertNotPending)
+		default:
+			a.logger.Error(err)
+			_ = c.Error(errFailedToResolveAlert)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
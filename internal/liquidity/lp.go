@@ -1,6 +1,7 @@
 package liquidity
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,6 +9,8 @@ import (
 	"sync"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/airdrop-registry/internal/utils"
 )
 
 type LiquidityPositionResolver struct {
@@ -34,15 +37,15 @@ type poolPositionResponse struct {
 }
 
 // fetch Thorchain and Maya LP position from Thorwallet api
-func (l *LiquidityPositionResolver) GetLiquidityPosition(address string) (float64, error) {
+func (l *LiquidityPositionResolver) GetLiquidityPosition(ctx context.Context, address string) (float64, error) {
 	if address == "" {
 		return 0, fmt.Errorf("address cannot be empty")
 	}
 	url := fmt.Sprintf("%s/pools/positions?addresses=%s", l.thorwalletBaseURL, address)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		l.logger.Errorf("error fetching liquidity position from %s: %e", url, err)
-		return 0, fmt.Errorf("error fetching liquidity position from %s: %e", url, err)
+		return 0, fmt.Errorf("error fetching liquidity position from %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
@@ -72,15 +75,15 @@ func (l *LiquidityPositionResolver) GetLiquidityPosition(address string) (float6
 }
 
 // fetch Thorchain TCY LP position from thornode api
-func (l *LiquidityPositionResolver) GetTCYStakePosition(address string) (float64, error) {
+func (l *LiquidityPositionResolver) GetTCYStakePosition(ctx context.Context, address string) (float64, error) {
 	if address == "" {
 		return 0, nil
 	}
 	url := fmt.Sprintf("%s/thorchain/tcy_staker/%s", l.thornodeBaseURL, address)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		l.logger.Errorf("error fetching liquidity position from %s: %e", url, err)
-		return 0, fmt.Errorf("error fetching liquidity position from %s: %e", url, err)
+		return 0, fmt.Errorf("error fetching liquidity position from %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
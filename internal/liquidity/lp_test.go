@@ -1,6 +1,7 @@
 package liquidity
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -92,7 +93,7 @@ func TestGetLiquidityPosition(t *testing.T) {
 		thorwalletBaseURL: mockServer.URL,
 	}
 	addrs := []string{"thor21cfzgzg02cp7yjrkagzdrdp7dqh0xlsdhawwjc", "0x3d512341ca1ff1142caca57d75ead1179ba1dd3a"}
-	lp, err := liquidityPositionResolver.GetLiquidityPosition(strings.Join(addrs, ","))
+	lp, err := liquidityPositionResolver.GetLiquidityPosition(context.Background(), strings.Join(addrs, ","))
 	if err != nil {
 		t.Fatalf("Failed to get liquidity position: %v", err)
 	}
@@ -114,7 +115,7 @@ func TestGetTCYStakePosition(t *testing.T) {
 	}
 	liquidityPositionResolver.SetTCYPrice(2)
 
-	got, err := liquidityPositionResolver.GetTCYStakePosition("thor1005rk5k9uuew3u5y489yd8tgjyrsykknnat8z0")
+	got, err := liquidityPositionResolver.GetTCYStakePosition(context.Background(), "thor1005rk5k9uuew3u5y489yd8tgjyrsykknnat8z0")
 	assert.NoError(t, err)
 	assert.Equal(t, float64(2), got)
 }
@@ -1,6 +1,7 @@
 package liquidity
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -8,6 +9,8 @@ import (
 
 	cache "github.com/patrickmn/go-cache"
 	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/airdrop-registry/internal/utils"
 )
 
 type SaverPositionResolver struct {
@@ -26,14 +29,14 @@ func NewSaverPositionResolver() *SaverPositionResolver {
 	}
 }
 
-func (l *SaverPositionResolver) GetSaverPosition(address string) (float64, error) {
-	positions, err := l.fetchSaverPosition(address)
+func (l *SaverPositionResolver) GetSaverPosition(ctx context.Context, address string) (float64, error) {
+	positions, err := l.fetchSaverPosition(ctx, address)
 	if err != nil {
 		return 0, err
 	}
 	var totalLiquidity float64
 	for _, v := range positions.SaverPosition {
-		pool, err := l.getpool(v.Pool)
+		pool, err := l.getpool(ctx, v.Pool)
 		if err != nil {
 			return 0, err
 		}
@@ -49,15 +52,15 @@ type saverResponse struct {
 	} `json:"pools"`
 }
 
-func (l *SaverPositionResolver) fetchSaverPosition(address string) (saverResponse, error) {
+func (l *SaverPositionResolver) fetchSaverPosition(ctx context.Context, address string) (saverResponse, error) {
 	if address == "" {
 		return saverResponse{}, fmt.Errorf("address cannot be empty")
 	}
 	url := fmt.Sprintf("%s/saver/positions?addresses=%s", l.thorwalletBaseURL, address)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		l.logger.Errorf("error fetching saver position from %s: %e", url, err)
-		return saverResponse{}, fmt.Errorf("error fetching saver position from %s: %e", url, err)
+		return saverResponse{}, fmt.Errorf("error fetching saver position from %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
@@ -77,26 +80,26 @@ type poolResp struct {
 	RuneOrCacaoLiquidityInUsd float64 `json:"runeOrCacaoLiquidityInUsd,string"`
 }
 
-func (l *SaverPositionResolver) getpool(pool string) (poolResp, error) {
+func (l *SaverPositionResolver) getpool(ctx context.Context, pool string) (poolResp, error) {
 	resp, found := l.poolCache.Get(pool)
 	if found {
 		return resp.(poolResp), nil
 	}
-	pools, err := l.fetchPools()
+	pools, err := l.fetchPools(ctx)
 	if err != nil {
 		return poolResp{}, err
 	}
 	for _, p := range pools {
 		l.poolCache.Set(p.Pool, p, cache.DefaultExpiration)
 	}
-	return l.getpool(pool)
+	return l.getpool(ctx, pool)
 }
-func (l *SaverPositionResolver) fetchPools() ([]poolResp, error) {
+func (l *SaverPositionResolver) fetchPools(ctx context.Context) ([]poolResp, error) {
 	url := fmt.Sprintf("%s/pools", l.thorwalletBaseURL)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		l.logger.Errorf("error fetching pools from %s: %e", url, err)
-		return nil, fmt.Errorf("error fetching pools from %s: %e", url, err)
+		return nil, fmt.Errorf("error fetching pools from %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusOK {
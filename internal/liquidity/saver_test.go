@@ -1,6 +1,7 @@
 package liquidity
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -90,7 +91,7 @@ func TestGetSaverPosition(t *testing.T) {
 		Pool:          "ETH.USDT-0XDAC17F958D2EE523A2206206994597C13D831EC7",
 		AssetPriceUsd: 1}, cache.DefaultExpiration)
 	addrs := []string{"0x3d204941ca5ff1143caca57d71ead1179ba1dd3a", "0x1d204941ca5ff1143caca57d71ead1179ba1dd3a"}
-	position, err := saverPositionResolver.GetSaverPosition(strings.Join(addrs, ","))
+	position, err := saverPositionResolver.GetSaverPosition(context.Background(), strings.Join(addrs, ","))
 	assert.NoErrorf(t, err, "Failed to get saver position: %v", err)
 	assert.Equal(t, float64(31927), position)
 }
@@ -141,7 +142,7 @@ func TestFetchPools(t *testing.T) {
 		poolCache:         poolCache,
 	}
 
-	pool, err := saverPositionResolver.getpool("AVAX.SOL-0XFE6B19286885A4F7F55ADAD09C3CD1F906D2478F")
+	pool, err := saverPositionResolver.getpool(context.Background(), "AVAX.SOL-0XFE6B19286885A4F7F55ADAD09C3CD1F906D2478F")
 	assert.NoErrorf(t, err, "Failed to get pools: %v", err)
 	assert.Equal(t, float64(177.1), pool.AssetPriceUsd)
 
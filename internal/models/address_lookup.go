@@ -0,0 +1,33 @@
+package models
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+// AddressLookupRequest is an address to look up
+type AddressLookupRequest struct {
+	Chain   string `json:"chain" binding:"required"`
+	Address string `json:"address" binding:"required"`
+}
+
+// AddressLookup tells whether an address belongs to a registered vault that joined the airdrop
+type AddressLookup struct {
+	Chain      common.Chain `json:"chain"`
+	Address    string       `json:"address"`
+	Registered bool         `json:"registered"`
+	VaultID    *uint        `json:"vault_id,omitempty"`   // only when the lookup privacy is none
+	VaultHash  string       `json:"vault_hash,omitempty"` // only when the lookup privacy is hashed
+}
+
+// HashVaultID returns the HMAC-SHA256 of the vault id, partners can match the vaults of their lookups
+// without learning their ids
+func HashVaultID(secret string, vaultID uint) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatUint(uint64(vaultID), 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
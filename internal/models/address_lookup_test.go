@@ -0,0 +1,15 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashVaultID(t *testing.T) {
+	hash := HashVaultID("secret", 42)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashVaultID("secret", 42))
+	assert.NotEqual(t, hash, HashVaultID("secret", 43))
+	assert.NotEqual(t, hash, HashVaultID("other", 42))
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+// AddressKey identifies an on-chain address
+type AddressKey struct {
+	Chain   common.Chain
+	Address string
+}
+
+// AddressOwner is the vault credited for an on-chain address. Vaults sharing a key derive the same
+// address on some chains, the address is credited to the first of them that joined the airdrop.
+type AddressOwner struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	Chain     common.Chain `gorm:"type:varchar(50);not null;uniqueIndex:address_owner_idx"`
+	Address   string       `gorm:"type:varchar(255);not null;uniqueIndex:address_owner_idx"`
+	VaultID   uint         `gorm:"type:bigint;not null;index"`
+}
+
+func (*AddressOwner) TableName() string {
+	return "address_owners"
+}
+
+// AddressClaimant is a vault deriving an address
+type AddressClaimant struct {
+	VaultID     uint
+	JoinAirdrop bool
+}
+
+// SelectAddressOwner returns the vault credited for an address among the vaults deriving it: the first
+// registered of the vaults that joined the airdrop, or the first registered when none joined
+func SelectAddressOwner(claimants []AddressClaimant) uint {
+	var owner *AddressClaimant
+	for i := range claimants {
+		claimant := &claimants[i]
+		if owner == nil || claimant.JoinAirdrop && !owner.JoinAirdrop ||
+			claimant.JoinAirdrop == owner.JoinAirdrop && claimant.VaultID < owner.VaultID {
+			owner = claimant
+		}
+	}
+	if owner == nil {
+		return 0
+	}
+	return owner.VaultID
+}
+
+// AddressCollision is a vault deriving an address that another vault derives as well
+type AddressCollision struct {
+	ID        uint `gorm:"primarykey"`
+	CreatedAt time.Time
+	Chain     common.Chain `gorm:"type:varchar(50);not null;uniqueIndex:address_collision_idx"`
+	Address   string       `gorm:"type:varchar(255);not null;uniqueIndex:address_collision_idx"`
+	VaultID   uint         `gorm:"type:bigint;not null;uniqueIndex:address_collision_idx"`
+}
+
+func (*AddressCollision) TableName() string {
+	return "address_collisions"
+}
+
+// AddressCollisionReport lists the vaults deriving an address and the one it is credited to
+type AddressCollisionReport struct {
+	Chain        common.Chain `json:"chain"`
+	Address      string       `json:"address"`
+	OwnerVaultID uint         `json:"owner_vault_id"`
+	VaultIDs     []uint       `json:"vault_ids"`
+	DetectedAt   time.Time    `json:"detected_at"`
+}
+
+// NewAddressCollisionReports groups the collisions by address, most recently detected first
+func NewAddressCollisionReports(collisions []AddressCollision, owners map[AddressKey]uint) []AddressCollisionReport {
+	byKey := make(map[AddressKey]*AddressCollisionReport)
+	var keys []AddressKey
+	for _, collision := range collisions {
+		key := AddressKey{Chain: collision.Chain, Address: collision.Address}
+		report, ok := byKey[key]
+		if !ok {
+			report = &AddressCollisionReport{
+				Chain:        collision.Chain,
+				Address:      collision.Address,
+				OwnerVaultID: owners[key],
+			}
+			byKey[key] = report
+			keys = append(keys, key)
+		}
+		report.VaultIDs = append(report.VaultIDs, collision.VaultID)
+		if collision.CreatedAt.After(report.DetectedAt) {
+			report.DetectedAt = collision.CreatedAt
+		}
+	}
+	reports := make([]AddressCollisionReport, len(keys))
+	for i, key := range keys {
+		report := byKey[key]
+		sort.Slice(report.VaultIDs, func(i, j int) bool {
+			return report.VaultIDs[i] < report.VaultIDs[j]
+		})
+		reports[i] = *report
+	}
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].DetectedAt.After(reports[j].DetectedAt)
+	})
+	return reports
+}
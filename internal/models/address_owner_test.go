@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+func TestNewAddressCollisionReports(t *testing.T) {
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	collisions := []AddressCollision{
+		{Chain: common.Solana, Address: "sol1", VaultID: 9, CreatedAt: day},
+		{Chain: common.Solana, Address: "sol1", VaultID: 3, CreatedAt: day},
+		{Chain: common.Sui, Address: "sui1", VaultID: 5, CreatedAt: day.Add(time.Hour)},
+		{Chain: common.Sui, Address: "sui1", VaultID: 4, CreatedAt: day},
+		{Chain: common.Solana, Address: "sol1", VaultID: 12, CreatedAt: day.Add(time.Minute)},
+	}
+	owners := map[AddressKey]uint{
+		{Chain: common.Solana, Address: "sol1"}: 3,
+		{Chain: common.Sui, Address: "sui1"}:    4,
+	}
+	reports := NewAddressCollisionReports(collisions, owners)
+	assert.Equal(t, []AddressCollisionReport{
+		{Chain: common.Sui, Address: "sui1", OwnerVaultID: 4, VaultIDs: []uint{4, 5}, DetectedAt: day.Add(time.Hour)},
+		{Chain: common.Solana, Address: "sol1", OwnerVaultID: 3, VaultIDs: []uint{3, 9, 12}, DetectedAt: day.Add(time.Minute)},
+	}, reports)
+	assert.Empty(t, NewAddressCollisionReports(nil, nil))
+}
+
+func TestSelectAddressOwner(t *testing.T) {
+	assert.Equal(t, uint(0), SelectAddressOwner(nil))
+	assert.Equal(t, uint(3), SelectAddressOwner([]AddressClaimant{{VaultID: 9}, {VaultID: 3}, {VaultID: 12}}))
+	assert.Equal(t, uint(9), SelectAddressOwner([]AddressClaimant{{VaultID: 3}, {VaultID: 9, JoinAirdrop: true}, {VaultID: 12, JoinAirdrop: true}}))
+	assert.Equal(t, uint(12), SelectAddressOwner([]AddressClaimant{{VaultID: 12, JoinAirdrop: true}, {VaultID: 3}}))
+}
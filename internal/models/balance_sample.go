@@ -0,0 +1,51 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// BalanceSample is the balance of a coin read at a randomized time of a day, see SampleRound
+type BalanceSample struct {
+	ID        uint      `gorm:"primarykey"`
+	CoinID    uint      `gorm:"type:bigint;not null;index:balance_sample_coin_idx"`
+	SampledAt time.Time `gorm:"not null;index:balance_sample_coin_idx;index"`
+	Balance   float64   `gorm:"type:decimal(65,30);default:0"`
+}
+
+func (*BalanceSample) TableName() string {
+	return "balance_samples"
+}
+
+// SampleRound is a randomized time of a day at which a single worker instance samples the balances of
+// all coins. The rounds of a day are planned by the first instance that sees the day.
+type SampleRound struct {
+	ID          uint      `gorm:"primarykey"`
+	Day         time.Time `gorm:"type:date;not null;uniqueIndex:sample_round_idx"`
+	Round       int       `gorm:"not null;uniqueIndex:sample_round_idx"`
+	SampleAt    time.Time `gorm:"not null"`
+	ClaimedBy   string    `gorm:"type:varchar(255);not null;default:''"` // instance sampling the round, empty until it is due
+	CompletedAt *time.Time
+}
+
+func (*SampleRound) TableName() string {
+	return "sample_rounds"
+}
+
+// PlanSampleRounds spreads count rounds over the UTC day of the given time, offset returns a random
+// duration below its argument
+func PlanSampleRounds(day time.Time, count int, offset func(time.Duration) time.Duration) []SampleRound {
+	day = day.UTC().Truncate(24 * time.Hour)
+	times := make([]time.Time, count)
+	for i := range times {
+		times[i] = day.Add(offset(24 * time.Hour))
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+	rounds := make([]SampleRound, count)
+	for i, at := range times {
+		rounds[i] = SampleRound{Day: day, Round: i, SampleAt: at}
+	}
+	return rounds
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSampleRounds(t *testing.T) {
+	offsets := []time.Duration{20 * time.Hour, 3 * time.Hour, 11 * time.Hour}
+	offset := func(max time.Duration) time.Duration {
+		assert.Equal(t, 24*time.Hour, max)
+		next := offsets[0]
+		offsets = offsets[1:]
+		return next
+	}
+	now := time.Date(2025, 6, 1, 15, 30, 0, 0, time.UTC)
+	rounds := PlanSampleRounds(now, 3, offset)
+	assert.Len(t, rounds, 3)
+	day := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	for i, expected := range []time.Duration{3 * time.Hour, 11 * time.Hour, 20 * time.Hour} {
+		assert.Equal(t, i, rounds[i].Round)
+		assert.Equal(t, day, rounds[i].Day)
+		assert.Equal(t, day.Add(expected), rounds[i].SampleAt)
+	}
+	assert.Empty(t, PlanSampleRounds(now, 0, offset))
+}
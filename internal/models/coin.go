@@ -54,7 +54,9 @@ func NewCoin(c CoinDBModel) Coin {
 type CoinDBModel struct {
 	gorm.Model
 	CoinBase
-	VaultID uint `json:"vault_id" binding:"required" gorm:"not null"`
+	VaultID     uint   `json:"vault_id" binding:"required" gorm:"not null"`
+	PriceSource string `json:"price_source" gorm:"type:varchar(64)"` // source of price_usd, see the pricing package
+	Freshness
 }
 
 func (CoinDBModel) TableName() string {
@@ -67,3 +69,14 @@ type CoinIdentity struct {
 	ContractAddress string
 	CMCId           int
 }
+
+// CoinFix is the corrected reference data of a coin, zero fields are left unchanged
+type CoinFix struct {
+	CoinID   uint
+	Chain    common.Chain
+	Address  string
+	CMCId    int
+	Decimals int
+	Ticker   string
+	Logo     string
+}
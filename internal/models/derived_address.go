@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/vultisig/mobile-tss-lib/tss"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+// ErrDeriveAddress is reported for chains whose address fails to derive
+var ErrDeriveAddress = errors.New("failed to derive address")
+
+// DerivedAddress is the address of a vault on a chain, stored at registration so the worker and the
+// api do not derive the keys of every vault again
+type DerivedAddress struct {
+	ID             uint         `gorm:"primarykey" json:"-"`
+	CreatedAt      time.Time    `json:"-"`
+	VaultID        uint         `gorm:"type:bigint;not null;uniqueIndex:vault_chain_idx" json:"vault_id"`
+	Chain          common.Chain `gorm:"type:varchar(50);not null;uniqueIndex:vault_chain_idx;index:chain_address_idx" json:"chain"`
+	DerivePath     string       `gorm:"type:varchar(255)" json:"derive_path"` // empty on EdDSA chains
+	ChildPublicKey string       `gorm:"type:varchar(255)" json:"child_public_key"`
+	Address        string       `gorm:"type:varchar(255);not null;index:chain_address_idx" json:"address"`
+}
+
+func (*DerivedAddress) TableName() string {
+	return "vault_addresses"
+}
+
+// DeriveAddresses derives the addresses of the vault on the chains, chains sharing a derive path share
+// one derivation. Chains failing to derive are left out and reported with ErrDeriveAddress.
+func (v *Vault) DeriveAddresses(chains []common.Chain) ([]DerivedAddress, error) {
+	childPublicKeys := make(map[string]string)
+	var addresses []DerivedAddress
+	var errs []error
+	for _, chain := range chains {
+		derived := DerivedAddress{VaultID: v.ID, Chain: chain, ChildPublicKey: v.EDDSA}
+		if !chain.IsEdDSA() {
+			derived.DerivePath = chain.GetDerivePath()
+			childPublicKey, ok := childPublicKeys[derived.DerivePath]
+			if !ok {
+				var err error
+				childPublicKey, err = tss.GetDerivedPubKey(v.ECDSA, v.HexChainCode, derived.DerivePath, false)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%w on chain %s: %v", ErrDeriveAddress, chain, err))
+					continue
+				}
+				childPublicKeys[derived.DerivePath] = childPublicKey
+			}
+			derived.ChildPublicKey = childPublicKey
+		}
+		addr, err := chainAddress(chain, derived.ChildPublicKey, v.EDDSA)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w on chain %s: %v", ErrDeriveAddress, chain, err))
+			continue
+		}
+		derived.Address = addr
+		addresses = append(addresses, derived)
+	}
+	return addresses, errors.Join(errs...)
+}
+
+// AddressMap returns the addresses by chain
+func AddressMap(addresses []DerivedAddress) map[common.Chain]string {
+	m := make(map[common.Chain]string, len(addresses))
+	for _, derived := range addresses {
+		m[derived.Chain] = derived.Address
+	}
+	return m
+}
+
+// MissingChains returns the supported chains without an address
+func MissingChains(addresses []DerivedAddress) []common.Chain {
+	known := AddressMap(addresses)
+	var missing []common.Chain
+	for _, chain := range common.GetAllChains() {
+		if _, ok := known[chain]; !ok {
+			missing = append(missing, chain)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+func TestDeriveAddresses(t *testing.T) {
+	vault := Vault{
+		ECDSA:        "027e897b35aa9f9fff223b6c826ff42da37e8169fae7be57cbd38be86938a746c6",
+		EDDSA:        "2dff7cf8446bd3829604bc5c2193ec64c43f67e764de3fd4807df759b91426fe",
+		HexChainCode: "57f3f25c4b034ad80016ef37da5b245bfd6187dc5547696c336ff5a66ed7ee0f",
+	}
+	vault.ID = 7
+	chains := []common.Chain{common.Ethereum, common.Base, common.THORChain, common.Bitcoin, common.Sui}
+	addresses, err := vault.DeriveAddresses(chains)
+	assert.NoError(t, err)
+	assert.Len(t, addresses, len(chains))
+	for i, derived := range addresses {
+		expected, err := vault.GetAddress(chains[i])
+		assert.NoError(t, err)
+		assert.Equal(t, expected, derived.Address)
+		assert.Equal(t, uint(7), derived.VaultID)
+		assert.Equal(t, chains[i], derived.Chain)
+	}
+	assert.Equal(t, "0x7a4629f9194d10526e80d76be734535bd5581ef37760d6914052d26066a8ff7b", addresses[4].Address)
+	assert.Empty(t, addresses[4].DerivePath)
+	assert.Equal(t, vault.EDDSA, addresses[4].ChildPublicKey)
+	// evm chains share the derivation
+	assert.Equal(t, addresses[0].ChildPublicKey, addresses[1].ChildPublicKey)
+	assert.Equal(t, addresses[0].Address, addresses[1].Address)
+
+	missing := MissingChains(addresses)
+	assert.Len(t, missing, len(common.GetAllChains())-len(chains))
+	assert.NotContains(t, missing, common.Ethereum)
+	assert.Equal(t, addresses[2].Address, AddressMap(addresses)[common.THORChain])
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+// DiscoveredCoin records a token the worker found on a vault address and added to its coins, it also
+// keeps the token from being added again once the user removed it
+type DiscoveredCoin struct {
+	ID              uint         `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time    `json:"created_at"`
+	JobID           uint         `gorm:"type:bigint;not null" json:"job_id"`
+	VaultID         uint         `gorm:"type:bigint;not null;uniqueIndex:vault_token_idx" json:"-"`
+	CoinID          uint         `gorm:"type:bigint;not null" json:"coin_id"`
+	Chain           common.Chain `gorm:"type:varchar(50);not null;uniqueIndex:vault_token_idx" json:"chain"`
+	ContractAddress string       `gorm:"type:varchar(255);not null;uniqueIndex:vault_token_idx" json:"contract_address"`
+	Address         string       `gorm:"type:varchar(255)" json:"address"`
+	Ticker          string       `gorm:"type:varchar(255)" json:"ticker"`
+	CMCId           int          `gorm:"type:Integer" json:"cmc_id"`
+	Decimals        int          `gorm:"type:Integer" json:"decimals"`
+	PriceUSD        float64      `gorm:"type:decimal(65,30);default:0" json:"price_usd"` // price when discovered
+	USDValue        float64      `gorm:"type:decimal(65,30);default:0" json:"usd_value"` // value of the holding when discovered
+	Allowlisted     bool         `json:"allowlisted"`                                    // added for being allowlisted, not for its market
+}
+
+func (*DiscoveredCoin) TableName() string {
+	return "discovered_coins"
+}
+
+// TokenKey identifies a token, contract addresses compare in lower case
+type TokenKey struct {
+	Chain           common.Chain
+	ContractAddress string
+}
+
+// NewTokenKey returns the key of the contract on the chain
+func NewTokenKey(chain common.Chain, contractAddress string) TokenKey {
+	return TokenKey{Chain: chain, ContractAddress: strings.ToLower(contractAddress)}
+}
+
+// TokenQuote is the market data of a token on CoinMarketCap
+type TokenQuote struct {
+	CMCId     int
+	Symbol    string
+	Price     float64
+	MarketCap float64
+	Volume24h float64
+}
+
+// DiscoveryPolicy decides which discovered tokens are added to a vault. Allowlisted tokens are added
+// whatever their market, other tokens need a price and the minimum value, volume and market cap.
+type DiscoveryPolicy struct {
+	MinValue     float64 // USD value of the holding
+	MinVolume    float64 // 24h USD volume
+	MinMarketCap float64
+}
+
+// Allows reports whether a holding of amount tokens with the quote can be added
+func (p DiscoveryPolicy) Allows(amount float64, quote TokenQuote) bool {
+	if quote.Price <= 0 || amount <= 0 {
+		return false
+	}
+	return amount*quote.Price >= p.MinValue && quote.Volume24h >= p.MinVolume && quote.MarketCap >= p.MinMarketCap
+}
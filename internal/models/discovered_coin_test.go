@@ -0,0 +1,26 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+func TestDiscoveryPolicyAllows(t *testing.T) {
+	policy := DiscoveryPolicy{MinValue: 10, MinVolume: 1000, MinMarketCap: 50000}
+	quote := TokenQuote{CMCId: 1, Price: 2, Volume24h: 1000, MarketCap: 50000}
+	assert.True(t, policy.Allows(5, quote))
+	assert.False(t, policy.Allows(4.9, quote))
+	assert.False(t, policy.Allows(0, quote))
+	assert.False(t, policy.Allows(5, TokenQuote{CMCId: 1, Price: 2, Volume24h: 999, MarketCap: 50000}))
+	assert.False(t, policy.Allows(5, TokenQuote{CMCId: 1, Price: 2, Volume24h: 1000, MarketCap: 49999}))
+	assert.False(t, policy.Allows(5, TokenQuote{CMCId: 1}))
+	assert.True(t, DiscoveryPolicy{}.Allows(0.001, TokenQuote{Price: 0.01}))
+}
+
+func TestNewTokenKey(t *testing.T) {
+	assert.Equal(t, NewTokenKey(common.Ethereum, "0xA0b8"), NewTokenKey(common.Ethereum, "0xa0B8"))
+	assert.NotEqual(t, NewTokenKey(common.Ethereum, "0xa0b8"), NewTokenKey(common.Base, "0xa0b8"))
+}
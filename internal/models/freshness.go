@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Freshness is when a value was last fetched and how many fetches failed since
+type Freshness struct {
+	LastFetchedAt *time.Time `json:"last_fetched_at"`
+	FailureStreak int        `gorm:"type:Integer;default:0" json:"failure_streak"`
+}
+
+// StaleDays is the number of days the value has not been refreshed at the given time, 0 while its last
+// fetch succeeded. A value that was never fetched counts a day per failed fetch.
+func (f Freshness) StaleDays(now time.Time) int {
+	if f.FailureStreak == 0 {
+		return 0
+	}
+	if f.LastFetchedAt == nil {
+		return f.FailureStreak
+	}
+	return max(int(now.Sub(*f.LastFetchedAt).Hours()/24), 0)
+}
+
+// Staleness reports the values of a vault that failed to refresh
+type Staleness struct {
+	StaleCoins     int        `json:"stale_coins"`               // coins whose last balance fetch failed
+	PositionsStale bool       `json:"positions_stale"`           // whether the last lp or nft fetch failed
+	LastFetchedAt  *time.Time `json:"last_fetched_at,omitempty"` // last fetch of the oldest stale value
+	StaleDays      int        `json:"stale_days"`                // days the oldest stale value has not been refreshed
+	Flagged        bool       `json:"flagged"`                   // stale for longer than the stale balance policy allows
+	Accruing       bool       `json:"accruing"`                  // whether the stale values still accrue points
+}
+
+func NewStaleness(vault Vault, coins []CoinDBModel, now time.Time) Staleness {
+	staleness := Staleness{
+		PositionsStale: vault.FailureStreak > 0,
+		Accruing:       true,
+	}
+	values := []Freshness{vault.Freshness}
+	for _, coin := range coins {
+		if coin.FailureStreak > 0 {
+			staleness.StaleCoins++
+		}
+		values = append(values, coin.Freshness)
+	}
+	for _, value := range values {
+		if days := value.StaleDays(now); value.FailureStreak > 0 && days >= staleness.StaleDays {
+			staleness.StaleDays = days
+			staleness.LastFetchedAt = value.LastFetchedAt
+		}
+	}
+	return staleness
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreshnessStaleDays(t *testing.T) {
+	now := time.Date(2025, 7, 10, 12, 0, 0, 0, time.UTC)
+	fetchedAt := now.Add(-3*24*time.Hour - time.Hour)
+	assert.Equal(t, 0, Freshness{LastFetchedAt: &fetchedAt}.StaleDays(now))
+	assert.Equal(t, 3, Freshness{LastFetchedAt: &fetchedAt, FailureStreak: 3}.StaleDays(now))
+	// never fetched, a day per failed fetch
+	assert.Equal(t, 2, Freshness{FailureStreak: 2}.StaleDays(now))
+}
+
+func TestNewStaleness(t *testing.T) {
+	now := time.Date(2025, 7, 10, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-24 * time.Hour)
+	old := now.Add(-5 * 24 * time.Hour)
+	vault := Vault{Freshness: Freshness{LastFetchedAt: &recent}}
+	coins := []CoinDBModel{
+		{Freshness: Freshness{LastFetchedAt: &recent}},
+		{Freshness: Freshness{LastFetchedAt: &old, FailureStreak: 5}},
+		{Freshness: Freshness{LastFetchedAt: &recent, FailureStreak: 1}},
+	}
+	staleness := NewStaleness(vault, coins, now)
+	assert.Equal(t, 2, staleness.StaleCoins)
+	assert.False(t, staleness.PositionsStale)
+	assert.Equal(t, 5, staleness.StaleDays)
+	assert.Equal(t, &old, staleness.LastFetchedAt)
+
+	staleness = NewStaleness(vault, coins[:1], now)
+	assert.Equal(t, 0, staleness.StaleCoins)
+	assert.Equal(t, 0, staleness.StaleDays)
+	assert.Nil(t, staleness.LastFetchedAt)
+}
@@ -8,12 +8,14 @@ import (
 
 type Job struct {
 	gorm.Model
-	JobDate         time.Time `gorm:"type:date;not null"`
+	JobDate         time.Time `gorm:"type:date;not null;uniqueIndex"` // unique so concurrent worker instances create a single job per day
 	Multiplier      int64
-	CurrentID       int64
-	CurrentVaultID  uint
+	CurrentID       int64 // progress of jobs run before sharding, see JobShard
+	CurrentVaultID  uint  // progress of jobs run before sharding, see JobShard
 	IsSuccess       bool
 	IsVolumeFetched bool `gorm:"type:boolean;default:false"`
+	PointsApplied   bool `gorm:"type:boolean;default:false"` // point events of this job have been added to vault totals
+	IsCancelled     bool `gorm:"type:boolean;default:false"` // cancelled from the worker admin api, instances stop working on it
 }
 
 func (*Job) TableName() string {
@@ -0,0 +1,24 @@
+package models
+
+import "gorm.io/gorm"
+
+type JobItemType string
+
+const (
+	JobItemVault    JobItemType = "vault"    // referral count and swap volume of a vault
+	JobItemPosition JobItemType = "position" // lp and nft value of a vault
+	JobItemCoin     JobItemType = "coin"     // balance of a coin
+)
+
+// JobItem marks a vault or coin as processed by a job, it is written in the same transaction as the
+// accrual it guards so a resumed job credits every item exactly once
+type JobItem struct {
+	gorm.Model
+	JobID    uint        `gorm:"type:bigint;not null;uniqueIndex:job_item_idx"`
+	ItemType JobItemType `gorm:"type:varchar(20);not null;uniqueIndex:job_item_idx"`
+	ItemID   uint        `gorm:"type:bigint;not null;uniqueIndex:job_item_idx"`
+}
+
+func (*JobItem) TableName() string {
+	return "job_items"
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type JobPhaseName string
+
+const (
+	JobPhasePrices       JobPhaseName = "prices"        // refresh coin prices
+	JobPhaseVaults       JobPhaseName = "vaults"        // referral count, swap volume and positions, sharded by vault id
+	JobPhaseDiscovery    JobPhaseName = "discovery"     // tokens found on vault addresses, sharded by vault id
+	JobPhaseBalances     JobPhaseName = "balances"      // coin balances, sharded by coin id
+	JobPhasePoints       JobPhaseName = "points"        // apply point events to vault totals
+	JobPhaseVaultBalance JobPhaseName = "vault_balance" // sum coin values into vault balances
+	JobPhaseTotalPoints  JobPhaseName = "total_points"  // season formula on the accrued vault value
+	JobPhaseMilestones   JobPhaseName = "milestones"    // season milestone prizes
+	JobPhaseRanks        JobPhaseName = "ranks"         // leaderboard ranks
+	JobPhaseSnapshots    JobPhaseName = "snapshots"     // daily vault and coin snapshots, the job succeeds with this phase
+)
+
+// JobPhases lists the phases of a job in the order they are usually run
+var JobPhases = []JobPhaseName{
+	JobPhasePrices,
+	JobPhaseVaults,
+	JobPhaseDiscovery,
+	JobPhaseBalances,
+	JobPhasePoints,
+	JobPhaseVaultBalance,
+	JobPhaseTotalPoints,
+	JobPhaseMilestones,
+	JobPhaseRanks,
+	JobPhaseSnapshots,
+}
+
+// JobPhaseDependencies lists the phases that must succeed before a phase can start
+var JobPhaseDependencies = map[JobPhaseName][]JobPhaseName{
+	JobPhasePrices:       {},
+	JobPhaseVaults:       {},
+	JobPhaseDiscovery:    {JobPhaseVaults},                    // the vaults phase stores the addresses of new vaults
+	JobPhaseBalances:     {JobPhasePrices, JobPhaseDiscovery}, // the vaults and discovery phases add coins
+	JobPhasePoints:       {JobPhaseVaults, JobPhaseBalances},
+	JobPhaseVaultBalance: {JobPhaseBalances},
+	JobPhaseTotalPoints:  {JobPhasePoints},
+	JobPhaseMilestones:   {JobPhaseTotalPoints},
+	JobPhaseRanks:        {JobPhaseVaultBalance, JobPhaseMilestones},
+	JobPhaseSnapshots:    {JobPhaseRanks, JobPhaseDiscovery},
+}
+
+// IsSharded reports whether the phase is split into id ranges, other phases run as a single shard
+func (p JobPhaseName) IsSharded() bool {
+	return p == JobPhaseVaults || p == JobPhaseBalances || p == JobPhaseDiscovery
+}
+
+type JobPhaseStatus string
+
+const (
+	JobPhasePending   JobPhaseStatus = "pending"
+	JobPhaseRunning   JobPhaseStatus = "running"
+	JobPhaseSucceeded JobPhaseStatus = "succeeded"
+	JobPhaseFailed    JobPhaseStatus = "failed" // retried on the next run
+)
+
+// JobPhase is the durable state of one step of a job
+type JobPhase struct {
+	gorm.Model
+	JobID      uint           `gorm:"type:bigint;not null;uniqueIndex:job_phase_idx" json:"job_id"`
+	Phase      JobPhaseName   `gorm:"type:varchar(20);not null;uniqueIndex:job_phase_idx" json:"phase"`
+	Status     JobPhaseStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	StartedAt  *time.Time     `json:"started_at"`
+	FinishedAt *time.Time     `json:"finished_at"`
+	Error      string         `gorm:"type:text" json:"error"` // error of the last failed attempt
+}
+
+func (*JobPhase) TableName() string {
+	return "job_phases"
+}
+
+// ReadyJobPhases returns the phases that are not done yet and whose dependencies have succeeded
+func ReadyJobPhases(phases []JobPhase) []JobPhaseName {
+	succeeded := make(map[JobPhaseName]bool)
+	for _, phase := range phases {
+		if phase.Status == JobPhaseSucceeded {
+			succeeded[phase.Phase] = true
+		}
+	}
+	var ready []JobPhaseName
+	for _, phase := range phases {
+		if succeeded[phase.Phase] {
+			continue
+		}
+		isReady := true
+		for _, dependency := range JobPhaseDependencies[phase.Phase] {
+			if !succeeded[dependency] {
+				isReady = false
+				break
+			}
+		}
+		if isReady {
+			ready = append(ready, phase.Phase)
+		}
+	}
+	return ready
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobPhaseDependencies(t *testing.T) {
+	order := make(map[JobPhaseName]int)
+	for i, phase := range JobPhases {
+		order[phase] = i
+	}
+	assert.Len(t, JobPhaseDependencies, len(JobPhases))
+	for phase, dependencies := range JobPhaseDependencies {
+		for _, dependency := range dependencies {
+			assert.Less(t, order[dependency], order[phase], "%s depends on later phase %s", phase, dependency)
+		}
+	}
+}
+
+func TestReadyJobPhases(t *testing.T) {
+	phases := make([]JobPhase, len(JobPhases))
+	for i, phase := range JobPhases {
+		phases[i] = JobPhase{Phase: phase, Status: JobPhasePending}
+	}
+	assert.Equal(t, []JobPhaseName{JobPhasePrices, JobPhaseVaults}, ReadyJobPhases(phases))
+
+	phases[0].Status = JobPhaseSucceeded
+	phases[1].Status = JobPhaseFailed
+	assert.Equal(t, []JobPhaseName{JobPhaseVaults}, ReadyJobPhases(phases))
+
+	// balances wait for the coins the vaults and discovery phases add
+	phases[1].Status = JobPhaseSucceeded
+	assert.Equal(t, []JobPhaseName{JobPhaseDiscovery}, ReadyJobPhases(phases))
+
+	phases[2].Status = JobPhaseSucceeded
+	phases[3].Status = JobPhaseSucceeded
+	assert.Equal(t, []JobPhaseName{JobPhasePoints, JobPhaseVaultBalance}, ReadyJobPhases(phases))
+
+	for i := range phases {
+		phases[i].Status = JobPhaseSucceeded
+	}
+	assert.Empty(t, ReadyJobPhases(phases))
+}
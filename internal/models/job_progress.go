@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+)
+
+// ItemProgress is how far a job got through its vaults or coins across all worker instances
+type ItemProgress struct {
+	Processed int64   `json:"processed"`
+	Total     int64   `json:"total"`
+	Percent   float64 `json:"percent"`
+	PerSecond float64 `json:"per_second"` // items processed per second since the phase started
+}
+
+// NewItemProgress computes the progress of the items processed by the phase, the throughput is measured
+// until the phase finished or until now while it is still running
+func NewItemProgress(processed, total int64, phase *JobPhase, now time.Time) ItemProgress {
+	progress := ItemProgress{
+		Processed: processed,
+		Total:     total,
+	}
+	if total > 0 {
+		// items deleted during the job are still counted as processed
+		progress.Percent = min(100, float64(processed)*100/float64(total))
+	}
+	if phase == nil || phase.StartedAt == nil {
+		return progress
+	}
+	end := now
+	if phase.Status != JobPhaseRunning && phase.FinishedAt != nil {
+		end = *phase.FinishedAt
+	}
+	if elapsed := end.Sub(*phase.StartedAt).Seconds(); elapsed > 0 {
+		progress.PerSecond = float64(processed) / elapsed
+	}
+	return progress
+}
+
+// JobProgress is the state of a job as reported by the worker admin api
+type JobProgress struct {
+	JobID       uint           `json:"job_id"`
+	Date        string         `json:"date"`
+	IsSuccess   bool           `json:"is_success"`
+	IsCancelled bool           `json:"is_cancelled"`
+	Running     []JobPhaseName `json:"running"` // phases currently worked on
+	Phases      []JobPhase     `json:"phases"`
+	Vaults      ItemProgress   `json:"vaults"`
+	Coins       ItemProgress   `json:"coins"`
+}
+
+// NewJobProgress summarizes the phases of the job and the number of items it processed out of the
+// vaults and coins currently registered
+func NewJobProgress(job *Job, phases []JobPhase, processed map[JobItemType]int64, vaults, coins int64, now time.Time) JobProgress {
+	progress := JobProgress{
+		JobID:       job.ID,
+		Date:        job.Date(),
+		IsSuccess:   job.IsSuccess,
+		IsCancelled: job.IsCancelled,
+		Running:     []JobPhaseName{},
+		Phases:      phases,
+	}
+	var vaultPhase, balancePhase *JobPhase
+	for i, phase := range phases {
+		switch phase.Phase {
+		case JobPhaseVaults:
+			vaultPhase = &phases[i]
+		case JobPhaseBalances:
+			balancePhase = &phases[i]
+		}
+		if phase.Status == JobPhaseRunning {
+			progress.Running = append(progress.Running, phase.Phase)
+		}
+	}
+	progress.Vaults = NewItemProgress(processed[JobItemVault], vaults, vaultPhase, now)
+	progress.Coins = NewItemProgress(processed[JobItemCoin], coins, balancePhase, now)
+	return progress
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewItemProgress(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	startedAt := now.Add(-100 * time.Second)
+
+	progress := NewItemProgress(250, 1000, &JobPhase{Status: JobPhaseRunning, StartedAt: &startedAt}, now)
+	assert.Equal(t, 25.0, progress.Percent)
+	assert.Equal(t, 2.5, progress.PerSecond)
+
+	finishedAt := startedAt.Add(50 * time.Second)
+	progress = NewItemProgress(1000, 1000, &JobPhase{Status: JobPhaseSucceeded, StartedAt: &startedAt, FinishedAt: &finishedAt}, now)
+	assert.Equal(t, 100.0, progress.Percent)
+	assert.Equal(t, 20.0, progress.PerSecond)
+
+	progress = NewItemProgress(12, 10, &JobPhase{Status: JobPhasePending}, now)
+	assert.Equal(t, 100.0, progress.Percent)
+	assert.Zero(t, progress.PerSecond)
+
+	progress = NewItemProgress(0, 0, nil, now)
+	assert.Zero(t, progress.Percent)
+}
+
+func TestNewJobProgress(t *testing.T) {
+	now := time.Date(2024, 10, 1, 12, 0, 0, 0, time.UTC)
+	startedAt := now.Add(-10 * time.Second)
+	job := &Job{JobDate: now}
+	phases := []JobPhase{
+		{Phase: JobPhasePrices, Status: JobPhaseSucceeded},
+		{Phase: JobPhaseVaults, Status: JobPhaseRunning, StartedAt: &startedAt},
+		{Phase: JobPhaseBalances, Status: JobPhasePending},
+	}
+	processed := map[JobItemType]int64{JobItemVault: 50, JobItemPosition: 40}
+
+	progress := NewJobProgress(job, phases, processed, 200, 1000, now)
+	assert.Equal(t, "2024-10-01", progress.Date)
+	assert.Equal(t, []JobPhaseName{JobPhaseVaults}, progress.Running)
+	assert.Equal(t, ItemProgress{Processed: 50, Total: 200, Percent: 25, PerSecond: 5}, progress.Vaults)
+	assert.Equal(t, ItemProgress{Processed: 0, Total: 1000}, progress.Coins)
+}
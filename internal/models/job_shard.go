@@ -0,0 +1,65 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type JobShardStatus string
+
+const (
+	JobShardPending JobShardStatus = "pending"
+	JobShardRunning JobShardStatus = "running"
+	JobShardDone    JobShardStatus = "done"
+)
+
+// JobShard is an id range of a job phase that a worker instance processes while holding its lease.
+// A shard whose lease expired can be taken over by any instance. Phases that are not sharded have a single shard.
+type JobShard struct {
+	gorm.Model
+	JobID          uint           `gorm:"type:bigint;not null;uniqueIndex:job_shard_idx" json:"job_id"`
+	Phase          JobPhaseName   `gorm:"type:varchar(20);not null;uniqueIndex:job_shard_idx" json:"phase"`
+	StartID        uint           `gorm:"type:bigint;not null;uniqueIndex:job_shard_idx" json:"start_id"` // exclusive
+	EndID          uint           `gorm:"type:bigint;not null" json:"end_id"`                             // inclusive
+	CurrentID      uint           `gorm:"type:bigint;not null;default:0" json:"current_id"`               // last id handed to the workers
+	Status         JobShardStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Owner          string         `gorm:"type:varchar(255);not null;default:''" json:"owner"`
+	LeaseExpiresAt *time.Time     `json:"lease_expires_at"`
+}
+
+func (*JobShard) TableName() string {
+	return "job_shards"
+}
+
+// Contains reports whether the id is in the range of the shard
+func (s *JobShard) Contains(id uint) bool {
+	return id > s.StartID && id <= s.EndID
+}
+
+// MaxShardID is the end of the last shard of a phase, it has no upper bound
+const MaxShardID = uint(math.MaxInt64)
+
+// NewJobShards splits the ids up to maxID into shards of the given size. The last shard has no upper bound,
+// so ids added while the job runs are processed too.
+func NewJobShards(jobID uint, phase JobPhaseName, maxID, size uint) []JobShard {
+	var shards []JobShard
+	start := uint(0)
+	for ; start+size < maxID; start += size {
+		shards = append(shards, JobShard{
+			JobID:   jobID,
+			Phase:   phase,
+			StartID: start,
+			EndID:   start + size,
+			Status:  JobShardPending,
+		})
+	}
+	return append(shards, JobShard{
+		JobID:   jobID,
+		Phase:   phase,
+		StartID: start,
+		EndID:   MaxShardID,
+		Status:  JobShardPending,
+	})
+}
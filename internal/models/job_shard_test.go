@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJobShards(t *testing.T) {
+	shards := NewJobShards(7, JobPhaseBalances, 2500, 1000)
+	assert.Len(t, shards, 3)
+	assert.Equal(t, uint(0), shards[0].StartID)
+	assert.Equal(t, uint(1000), shards[0].EndID)
+	assert.Equal(t, uint(2000), shards[2].StartID)
+	assert.Equal(t, MaxShardID, shards[2].EndID)
+	for _, shard := range shards {
+		assert.Equal(t, uint(7), shard.JobID)
+		assert.Equal(t, JobPhaseBalances, shard.Phase)
+		assert.Equal(t, JobShardPending, shard.Status)
+	}
+	assert.True(t, shards[0].Contains(1000))
+	assert.False(t, shards[0].Contains(0))
+	assert.False(t, shards[0].Contains(1001))
+	// ids added after the shards were created belong to the last shard
+	assert.True(t, shards[2].Contains(1_000_000))
+
+	shards = NewJobShards(7, JobPhaseBalances, 2000, 1000)
+	assert.Len(t, shards, 2)
+	assert.Equal(t, uint(1000), shards[1].StartID)
+
+	shards = NewJobShards(7, JobPhaseVaults, 0, 1000)
+	assert.Len(t, shards, 1)
+	assert.True(t, shards[0].Contains(1))
+}
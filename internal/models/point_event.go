@@ -0,0 +1,47 @@
+package models
+
+import "gorm.io/gorm"
+
+type PointSource string
+
+const (
+	PointSourceBalance PointSource = "balance"
+	PointSourceLP      PointSource = "lp"
+	PointSourceNFT     PointSource = "nft"
+	// volume and referral events carry the swap volume and referral count of the job in Balance with a zero Value
+	PointSourceVolume   PointSource = "volume"
+	PointSourceReferral PointSource = "referral"
+)
+
+// PointEvent is an append-only ledger entry recording why a vault accrued value during a job.
+// A vault's total_vault_value for a job is the sum of Value over its events.
+type PointEvent struct {
+	gorm.Model
+	JobID            uint        `gorm:"type:bigint;not null;uniqueIndex:job_vault_source_coin_idx" json:"job_id"`
+	VaultID          uint        `gorm:"type:bigint;not null;uniqueIndex:job_vault_source_coin_idx;index:vault_idx" json:"vault_id"`
+	Source           PointSource `gorm:"type:varchar(20);not null;uniqueIndex:job_vault_source_coin_idx" json:"source"`
+	CoinID           uint        `gorm:"type:bigint;not null;default:0;uniqueIndex:job_vault_source_coin_idx" json:"coin_id"` // 0 for lp and nft events
+	Balance          float64     `gorm:"type:decimal(65,30);default:0" json:"balance"`
+	Price            float64     `gorm:"type:decimal(65,30);default:0" json:"price"`
+	Multiplier       int64       `gorm:"type:bigint;default:0" json:"multiplier"` // days since the previous job
+	SeasonMultiplier float64     `gorm:"type:decimal(65,30);default:0" json:"season_multiplier"`
+	Value            float64     `gorm:"type:decimal(65,30);default:0" json:"value"` // balance * price * multiplier * season multiplier
+}
+
+func (*PointEvent) TableName() string {
+	return "point_events"
+}
+
+func NewPointEvent(job Job, vaultID uint, source PointSource, coinID uint, balance, price, seasonMultiplier float64) PointEvent {
+	return PointEvent{
+		JobID:            job.ID,
+		VaultID:          vaultID,
+		Source:           source,
+		CoinID:           coinID,
+		Balance:          balance,
+		Price:            price,
+		Multiplier:       job.Multiplier,
+		SeasonMultiplier: seasonMultiplier,
+		Value:            balance * price * float64(job.Multiplier) * seasonMultiplier,
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPointEvent(t *testing.T) {
+	job := Job{Multiplier: 2}
+	job.ID = 7
+	event := NewPointEvent(job, 3, PointSourceBalance, 11, 1.5, 4, 1.5)
+	assert.Equal(t, uint(7), event.JobID)
+	assert.Equal(t, uint(3), event.VaultID)
+	assert.Equal(t, uint(11), event.CoinID)
+	assert.Equal(t, int64(2), event.Multiplier)
+	assert.Equal(t, float64(18), event.Value)
+
+	event = NewPointEvent(job, 3, PointSourceLP, 0, 100, 1, 1)
+	assert.Equal(t, float64(200), event.Value)
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+// Price is the USD price of an asset on a day from one source. The prices a job credits coins, positions and
+// NFTs at are recorded, so a rerun of the job prices them the same.
+type Price struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Asset     string    `gorm:"type:varchar(255);not null;uniqueIndex:asset_date_source_idx" json:"asset"`
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex:asset_date_source_idx" json:"date"`
+	Source    string    `gorm:"type:varchar(64);not null;uniqueIndex:asset_date_source_idx" json:"source"`
+	PriceUSD  float64   `gorm:"type:decimal(65,30);not null" json:"price_usd"`
+}
+
+func (*Price) TableName() string {
+	return "prices"
+}
+
+// CMCAsset is the asset of the coins priced by their cmc id
+func CMCAsset(cmcID int) string {
+	return "cmc:" + strconv.Itoa(cmcID)
+}
+
+// CoinAsset is the asset of the coins with the ticker on the chain
+func CoinAsset(chain common.Chain, ticker string) string {
+	return chain.String() + ":" + ticker
+}
+
+// CollectionAsset is the asset of the floor price of an OpenSea collection
+func CollectionAsset(slug string) string {
+	return "opensea:" + slug
+}
+
+// PriceDate returns the day of t that prices are recorded for
+func PriceDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
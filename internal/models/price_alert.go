@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+type PriceAlertStatus string
+
+const (
+	PriceAlertPending  PriceAlertStatus = "pending"  // the coins keep their last good price
+	PriceAlertApproved PriceAlertStatus = "approved" // the price is recorded and the coins take it
+	PriceAlertRejected PriceAlertStatus = "rejected"
+)
+
+// PriceAlert is a price of an asset quarantined for a day, since it moved too far from the last good price
+// of the asset. A coin priced by its cmc id has its CMCId, a routed coin its chain and ticker.
+type PriceAlert struct {
+	ID            uint             `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time        `json:"created_at"`
+	UpdatedAt     time.Time        `json:"updated_at"`
+	Asset         string           `gorm:"type:varchar(255);not null;uniqueIndex:asset_date_idx" json:"asset"`
+	Date          time.Time        `gorm:"type:date;not null;uniqueIndex:asset_date_idx" json:"date"`
+	Chain         common.Chain     `gorm:"type:varchar(50)" json:"chain"`
+	Ticker        string           `gorm:"type:varchar(255)" json:"ticker"`
+	CMCId         int              `json:"cmc_id"`
+	Source        string           `gorm:"type:varchar(64);not null" json:"source"`
+	PreviousPrice float64          `gorm:"type:decimal(65,30);not null" json:"previous_price"`
+	Price         float64          `gorm:"type:decimal(65,30);not null" json:"price"`
+	Change        float64          `json:"change"` // relative to the previous price
+	Status        PriceAlertStatus `gorm:"type:varchar(16);not null;index" json:"status"`
+	ResolvedAt    *time.Time       `json:"resolved_at"`
+}
+
+func (*PriceAlert) TableName() string {
+	return "price_alerts"
+}
+
+// ValidPriceAlertStatus reports whether the status is known
+func ValidPriceAlertStatus(status PriceAlertStatus) bool {
+	switch status {
+	case PriceAlertPending, PriceAlertApproved, PriceAlertRejected:
+		return true
+	}
+	return false
+}
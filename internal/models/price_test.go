@@ -0,0 +1,29 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+func TestPriceAssets(t *testing.T) {
+	assert.Equal(t, "cmc:825", CMCAsset(825))
+	assert.Equal(t, "THORChain:THOR.TCY", CoinAsset(common.THORChain, "THOR.TCY"))
+	assert.Equal(t, "opensea:thorguards", CollectionAsset("thorguards"))
+}
+
+func TestPriceDate(t *testing.T) {
+	assert.Equal(t, time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC), PriceDate(time.Date(2025, 3, 4, 23, 59, 0, 0, time.UTC)))
+	// the calendar day of the time, whatever its location
+	tokyo := time.FixedZone("JST", 9*60*60)
+	assert.Equal(t, time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC), PriceDate(time.Date(2025, 3, 4, 1, 0, 0, 0, tokyo)))
+}
+
+func TestValidPriceAlertStatus(t *testing.T) {
+	assert.True(t, ValidPriceAlertStatus(PriceAlertPending))
+	assert.True(t, ValidPriceAlertStatus(PriceAlertRejected))
+	assert.False(t, ValidPriceAlertStatus("all"))
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+// QuestRule is what a vault has to satisfy to complete a quest
+type QuestRule string
+
+const (
+	QuestRuleRegistered       QuestRule = "registered"        // the address belongs to a vault that joined the airdrop
+	QuestRuleRegisteredBefore QuestRule = "registered_before" // and the vault registered before the quest's date
+	QuestRuleTokenBalance     QuestRule = "token_balance"     // and the vault holds at least min_amount of the token
+	QuestRuleSwapVolume       QuestRule = "swap_volume"       // and the vault swapped at least min_amount USD
+)
+
+// DefaultQuestResponse is the response template of a quest without one
+const DefaultQuestResponse = `{"result":{"is_valid":{{.Valid}}}}`
+
+// Quest is a partner campaign verifying the vault of an address. Quests come from the config and from the
+// quests table, a stored quest replaces the configured one with the same slug.
+type Quest struct {
+	ID               uint         `gorm:"primarykey" json:"-"`
+	CreatedAt        time.Time    `json:"-"`
+	UpdatedAt        time.Time    `json:"-"`
+	Slug             string       `gorm:"type:varchar(64);not null;uniqueIndex" json:"slug"` // path segment of the verification endpoint
+	Rule             QuestRule    `gorm:"type:varchar(32);not null" json:"rule"`
+	Chain            common.Chain `gorm:"type:varchar(50)" json:"chain"`   // chain of the verified addresses
+	Ticker           string       `gorm:"type:varchar(255)" json:"ticker"` // token of the token_balance rule
+	ContractAddress  string       `gorm:"type:varchar(255)" json:"contract_address"`
+	MinAmount        float64      `gorm:"type:decimal(65,30);default:0" json:"min_amount"`
+	RegisteredBefore *time.Time   `json:"registered_before,omitempty"`
+	APIKeys          []string     `gorm:"type:text;serializer:json" json:"api_keys,omitempty"`
+	AllowedIPs       []string     `gorm:"type:text;serializer:json" json:"allowed_ips"` // ips or CIDRs
+	Response         string       `gorm:"type:text" json:"response"`                    // text/template of the JSON response
+	Points           float64      `gorm:"type:decimal(65,30);default:0" json:"points"`  // awarded once per vault, 0 for none
+	Disabled         bool         `gorm:"type:boolean;default:false" json:"disabled"`
+}
+
+func (*Quest) TableName() string {
+	return "quests"
+}
+
+// Validate checks that the rule of the quest has the settings it needs, and that a quest awarding points
+// is restricted to api keys or ips
+func (q *Quest) Validate() error {
+	if q.Slug == "" {
+		return fmt.Errorf("quest without slug")
+	}
+	if q.Points > 0 && len(q.APIKeys) == 0 && len(q.AllowedIPs) == 0 {
+		return fmt.Errorf("quest %s: api_keys or allowed_ips are required to award points", q.Slug)
+	}
+	switch q.Rule {
+	case QuestRuleRegistered, QuestRuleSwapVolume:
+	case QuestRuleRegisteredBefore:
+		if q.RegisteredBefore == nil {
+			return fmt.Errorf("quest %s: registered_before is required by its rule", q.Slug)
+		}
+	case QuestRuleTokenBalance:
+		if q.Ticker == "" && q.ContractAddress == "" {
+			return fmt.Errorf("quest %s: ticker or contract_address is required by its rule", q.Slug)
+		}
+	default:
+		return fmt.Errorf("quest %s: unknown rule %s", q.Slug, q.Rule)
+	}
+	return nil
+}
+
+// Verify reports whether the vault satisfies the rule of the quest, coins are the coins of the vault
+func (q *Quest) Verify(vault *Vault, coins []CoinDBModel) bool {
+	switch q.Rule {
+	case QuestRuleRegistered:
+		return true
+	case QuestRuleRegisteredBefore:
+		return q.RegisteredBefore != nil && vault.CreatedAt.Before(*q.RegisteredBefore)
+	case QuestRuleTokenBalance:
+		var amount float64
+		for _, coin := range coins {
+			if coin.Chain != q.Chain {
+				continue
+			}
+			if q.ContractAddress != "" && !strings.EqualFold(coin.ContractAddress, q.ContractAddress) {
+				continue
+			}
+			if q.ContractAddress == "" && !strings.EqualFold(coin.Ticker, q.Ticker) {
+				continue
+			}
+			balance, err := strconv.ParseFloat(coin.Balance, 64)
+			if err == nil {
+				amount += balance
+			}
+		}
+		return amount >= q.MinAmount
+	case QuestRuleSwapVolume:
+		return vault.SwapVolume >= q.MinAmount
+	}
+	return false
+}
+
+// QuestVerification is the audit record of a call to the verification endpoint of a quest
+type QuestVerification struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+	QuestSlug string    `gorm:"type:varchar(64);not null;index" json:"quest"`
+	Address   string    `gorm:"type:varchar(255)" json:"address"`
+	VaultID   uint      `gorm:"type:bigint;default:0" json:"vault_id"` // 0 when the address belongs to no vault
+	ClientIP  string    `gorm:"type:varchar(64)" json:"client_ip"`
+	Caller    string    `gorm:"type:varchar(64)" json:"caller"` // hash prefix of the api key, empty without one
+	Status    string    `gorm:"type:varchar(32)" json:"status"` // valid, invalid, unauthorized or error
+}
+
+func (*QuestVerification) TableName() string {
+	return "quest_verifications"
+}
+
+const (
+	QuestStatusValid        = "valid"
+	QuestStatusInvalid      = "invalid"
+	QuestStatusUnauthorized = "unauthorized"
+	QuestStatusError        = "error"
+)
+
+// QuestCompletion is a vault that completed a quest, with the points it was awarded
+type QuestCompletion struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	QuestSlug string    `gorm:"type:varchar(64);not null;uniqueIndex:quest_vault_idx"`
+	VaultID   uint      `gorm:"type:bigint;not null;uniqueIndex:quest_vault_idx"`
+	Points    float64   `gorm:"type:decimal(65,30);default:0"`
+}
+
+func (*QuestCompletion) TableName() string {
+	return "quest_completions"
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+func TestQuestValidate(t *testing.T) {
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, (&Quest{Slug: "cmc", Rule: QuestRuleRegistered}).Validate())
+	assert.NoError(t, (&Quest{Slug: "early", Rule: QuestRuleRegisteredBefore, RegisteredBefore: &date}).Validate())
+	assert.NoError(t, (&Quest{Slug: "usdc", Rule: QuestRuleTokenBalance, Ticker: "USDC"}).Validate())
+	assert.NoError(t, (&Quest{Slug: "swap", Rule: QuestRuleSwapVolume, MinAmount: 100}).Validate())
+	assert.NoError(t, (&Quest{Slug: "keyed", Rule: QuestRuleRegistered, Points: 50, APIKeys: []string{"key"}}).Validate())
+	assert.NoError(t, (&Quest{Slug: "listed", Rule: QuestRuleRegistered, Points: 50, AllowedIPs: []string{"203.0.113.0/24"}}).Validate())
+
+	assert.Error(t, (&Quest{Rule: QuestRuleRegistered}).Validate())
+	assert.Error(t, (&Quest{Slug: "early", Rule: QuestRuleRegisteredBefore}).Validate())
+	assert.Error(t, (&Quest{Slug: "usdc", Rule: QuestRuleTokenBalance}).Validate())
+	assert.Error(t, (&Quest{Slug: "other", Rule: "other"}).Validate())
+	assert.Error(t, (&Quest{Slug: "open", Rule: QuestRuleRegistered, Points: 50}).Validate())
+}
+
+func TestQuestVerify(t *testing.T) {
+	date := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	vault := &Vault{SwapVolume: 250}
+	vault.CreatedAt = date.Add(-time.Hour)
+	coins := []CoinDBModel{
+		{CoinBase: CoinBase{Chain: common.Ethereum, Ticker: "USDC", ContractAddress: "0xA0b8", Balance: "40"}},
+		{CoinBase: CoinBase{Chain: common.Ethereum, Ticker: "usdc", ContractAddress: "0xa0B8", Balance: "70"}},
+		{CoinBase: CoinBase{Chain: common.Base, Ticker: "USDC", ContractAddress: "0xA0b8", Balance: "500"}},
+		{CoinBase: CoinBase{Chain: common.Ethereum, Ticker: "ETH", Balance: "invalid"}},
+	}
+
+	assert.True(t, (&Quest{Rule: QuestRuleRegistered}).Verify(vault, nil))
+
+	assert.True(t, (&Quest{Rule: QuestRuleRegisteredBefore, RegisteredBefore: &date}).Verify(vault, nil))
+	before := date.Add(-2 * time.Hour)
+	assert.False(t, (&Quest{Rule: QuestRuleRegisteredBefore, RegisteredBefore: &before}).Verify(vault, nil))
+
+	assert.True(t, (&Quest{Rule: QuestRuleTokenBalance, Chain: common.Ethereum, Ticker: "USDC", MinAmount: 110}).Verify(vault, coins))
+	assert.False(t, (&Quest{Rule: QuestRuleTokenBalance, Chain: common.Ethereum, Ticker: "USDC", MinAmount: 111}).Verify(vault, coins))
+	assert.True(t, (&Quest{Rule: QuestRuleTokenBalance, Chain: common.Base, ContractAddress: "0xa0b8", MinAmount: 500}).Verify(vault, coins))
+	assert.False(t, (&Quest{Rule: QuestRuleTokenBalance, Chain: common.Ethereum, Ticker: "ETH", MinAmount: 1}).Verify(vault, coins))
+
+	assert.True(t, (&Quest{Rule: QuestRuleSwapVolume, MinAmount: 250}).Verify(vault, nil))
+	assert.False(t, (&Quest{Rule: QuestRuleSwapVolume, MinAmount: 251}).Verify(vault, nil))
+}
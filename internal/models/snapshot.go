@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// VaultDailySnapshot is the state of a vault at the end of a successful job
+type VaultDailySnapshot struct {
+	gorm.Model  `json:"-"`
+	VaultID     uint      `gorm:"type:bigint;not null;uniqueIndex:vault_date_idx" json:"vault_id"`
+	Date        time.Time `gorm:"type:date;not null;uniqueIndex:vault_date_idx" json:"date"`
+	JobID       uint      `gorm:"type:bigint;not null" json:"job_id"`
+	Balance     int64     `gorm:"type:bigint;default:0" json:"balance"` // USD value of the coins
+	LPValue     int64     `gorm:"type:bigint;default:0" json:"lp_value"`
+	NFTValue    int64     `gorm:"type:bigint;default:0" json:"nft_value"`
+	SwapVolume  float64   `gorm:"type:decimal(65,30);default:0" json:"swap_volume"`
+	TotalPoints float64   `json:"total_points"`
+	Rank        int64     `json:"rank"`
+}
+
+func (*VaultDailySnapshot) TableName() string {
+	return "vault_daily_snapshots"
+}
+
+// CoinDailySnapshot is the balance of a coin at the end of a successful job, coins without balance are skipped
+type CoinDailySnapshot struct {
+	gorm.Model `json:"-"`
+	CoinID     uint      `gorm:"type:bigint;not null;uniqueIndex:coin_date_idx" json:"coin_id"`
+	VaultID    uint      `gorm:"type:bigint;not null;index:coin_snapshot_vault_idx" json:"vault_id"`
+	Date       time.Time `gorm:"type:date;not null;uniqueIndex:coin_date_idx" json:"date"`
+	JobID      uint      `gorm:"type:bigint;not null" json:"job_id"`
+	Balance    float64   `gorm:"type:decimal(65,30);default:0" json:"balance"`
+	PriceUSD   float64   `gorm:"type:decimal(65,30);default:0" json:"price"`
+	USDValue   float64   `gorm:"type:decimal(65,30);default:0" json:"usd_value"`
+}
+
+func (*CoinDailySnapshot) TableName() string {
+	return "coin_daily_snapshots"
+}
+
+type Granularity string
+
+const (
+	GranularityDay   Granularity = "day"
+	GranularityWeek  Granularity = "week"
+	GranularityMonth Granularity = "month"
+)
+
+func ParseGranularity(s string) (Granularity, error) {
+	switch Granularity(s) {
+	case "":
+		return GranularityDay, nil
+	case GranularityDay, GranularityWeek, GranularityMonth:
+		return Granularity(s), nil
+	}
+	return "", fmt.Errorf("unknown granularity %s", s)
+}
+
+// PeriodStart returns the first day of the period the date falls in, weeks start on monday
+func (g Granularity) PeriodStart(date time.Time) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	switch g {
+	case GranularityWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case GranularityMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// Downsample keeps the last of the date ordered items in each period of the granularity
+func Downsample[T any](items []T, granularity Granularity, date func(T) time.Time) []T {
+	var result []T
+	for i, item := range items {
+		if i+1 < len(items) && granularity.PeriodStart(date(items[i+1])).Equal(granularity.PeriodStart(date(item))) {
+			continue
+		}
+		result = append(result, item)
+	}
+	return result
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGranularityPeriodStart(t *testing.T) {
+	// 2025-07-10 is a thursday
+	date := time.Date(2025, 7, 10, 15, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC), GranularityDay.PeriodStart(date))
+	assert.Equal(t, time.Date(2025, 7, 7, 0, 0, 0, 0, time.UTC), GranularityWeek.PeriodStart(date))
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), GranularityMonth.PeriodStart(date))
+	// sunday belongs to the week started on monday
+	sunday := time.Date(2025, 7, 13, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 7, 7, 0, 0, 0, 0, time.UTC), GranularityWeek.PeriodStart(sunday))
+
+	_, err := ParseGranularity("year")
+	assert.Error(t, err)
+}
+
+func TestDownsample(t *testing.T) {
+	var snapshots []VaultDailySnapshot
+	for day := 1; day <= 40; day++ {
+		snapshots = append(snapshots, VaultDailySnapshot{
+			Date:        time.Date(2025, 7, day, 0, 0, 0, 0, time.UTC),
+			TotalPoints: float64(day),
+		})
+	}
+	date := func(s VaultDailySnapshot) time.Time { return s.Date }
+	assert.Len(t, Downsample(snapshots, GranularityDay, date), 40)
+
+	monthly := Downsample(snapshots, GranularityMonth, date)
+	assert.Len(t, monthly, 2)
+	assert.Equal(t, float64(31), monthly[0].TotalPoints)
+	assert.Equal(t, float64(40), monthly[1].TotalPoints)
+
+	weekly := Downsample(snapshots, GranularityWeek, date)
+	// 2025-07-06 is the first sunday
+	assert.Equal(t, float64(6), weekly[0].TotalPoints)
+	assert.Equal(t, float64(40), weekly[len(weekly)-1].TotalPoints)
+}
@@ -0,0 +1,145 @@
+package models
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+// AssetKind tells how the balance of a tracked asset is fetched and valued
+type AssetKind string
+
+const (
+	AssetKindToken AssetKind = "token" // native coin or fungible token, valued at its price
+	AssetKindNFT   AssetKind = "nft"   // ERC-721 collection, valued at its floor price
+)
+
+const (
+	PriceSourceCMC     = "cmc"     // CoinMarketCap quote of the cmc id
+	PriceSourceOpenSea = "opensea" // OpenSea floor of the collection slug in the price provider id
+)
+
+// TrackedAsset is a coin, token or NFT collection the registry knows about. SPL and TRC-20 tokens and
+// NFT collections only have a balance while tracked and enabled, other coins unless their asset is disabled.
+type TrackedAsset struct {
+	ID              uint         `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time    `json:"created_at"`
+	UpdatedAt       time.Time    `json:"updated_at"`
+	Chain           common.Chain `gorm:"type:varchar(50);not null;uniqueIndex:chain_contract_idx" json:"chain"`
+	ContractAddress string       `gorm:"type:varchar(255);not null;uniqueIndex:chain_contract_idx" json:"contract_address"` // empty for the native coin
+	Ticker          string       `gorm:"type:varchar(255)" json:"ticker"`
+	Kind            AssetKind    `gorm:"type:varchar(16);not null;default:'token'" json:"kind"`
+	Decimals        int          `gorm:"type:Integer" json:"decimals"`
+	CMCId           int          `gorm:"type:Integer" json:"cmc_id"`
+	PriceSource     string       `gorm:"type:varchar(32);not null;default:'cmc'" json:"price_source"`
+	PriceProviderID string       `gorm:"type:varchar(255)" json:"price_provider_id"` // id of the asset at its price source
+	Enabled         bool         `gorm:"not null" json:"enabled"`
+	Boosted         bool         `gorm:"not null" json:"boosted"`
+	Multiplier      float64      `gorm:"type:decimal(65,30)" json:"multiplier"` // season multiplier of a boosted asset the season does not list
+}
+
+func (*TrackedAsset) TableName() string {
+	return "tracked_assets"
+}
+
+// Validate checks that the asset has what its kind and price source need
+func (a *TrackedAsset) Validate() error {
+	if a.Chain == common.Undefined {
+		return fmt.Errorf("tracked asset without chain")
+	}
+	switch a.Kind {
+	case AssetKindToken:
+	case AssetKindNFT:
+		if a.ContractAddress == "" {
+			return fmt.Errorf("nft collection on %s without contract address", a.Chain)
+		}
+		if a.PriceSource != PriceSourceOpenSea {
+			return fmt.Errorf("nft collection %s on %s must be priced on %s", a.ContractAddress, a.Chain, PriceSourceOpenSea)
+		}
+	default:
+		return fmt.Errorf("unknown asset kind %s", a.Kind)
+	}
+	switch a.PriceSource {
+	case PriceSourceCMC:
+	case PriceSourceOpenSea:
+		if a.PriceProviderID == "" {
+			return fmt.Errorf("asset %s on %s priced on %s without price provider id", a.ContractAddress, a.Chain, a.PriceSource)
+		}
+	default:
+		return fmt.Errorf("unknown price source %s", a.PriceSource)
+	}
+	if a.Boosted && a.Multiplier <= 0 {
+		return fmt.Errorf("boosted asset %s on %s without multiplier", a.ContractAddress, a.Chain)
+	}
+	return nil
+}
+
+// AssetRegistry looks up the tracked assets by chain and contract, a nil registry tracks nothing
+type AssetRegistry struct {
+	assets map[TokenKey]TrackedAsset
+	nfts   []TrackedAsset
+}
+
+// NewAssetRegistry indexes the assets, a later asset replaces an earlier one with the same key
+func NewAssetRegistry(assets []TrackedAsset) *AssetRegistry {
+	r := &AssetRegistry{assets: make(map[TokenKey]TrackedAsset, len(assets))}
+	for _, asset := range assets {
+		r.assets[NewTokenKey(asset.Chain, asset.ContractAddress)] = asset
+	}
+	for _, asset := range r.assets {
+		if asset.Kind == AssetKindNFT && asset.Enabled {
+			r.nfts = append(r.nfts, asset)
+		}
+	}
+	slices.SortFunc(r.nfts, func(a, b TrackedAsset) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return r
+}
+
+// Lookup returns the asset of the contract on the chain, enabled or not
+func (r *AssetRegistry) Lookup(chain common.Chain, contractAddress string) (TrackedAsset, bool) {
+	if r == nil {
+		return TrackedAsset{}, false
+	}
+	asset, ok := r.assets[NewTokenKey(chain, contractAddress)]
+	return asset, ok
+}
+
+// Tracks reports whether the contract on the chain is tracked and enabled
+func (r *AssetRegistry) Tracks(chain common.Chain, contractAddress string) bool {
+	asset, ok := r.Lookup(chain, contractAddress)
+	return ok && asset.Enabled
+}
+
+// Disabled reports whether the contract on the chain is tracked and disabled
+func (r *AssetRegistry) Disabled(chain common.Chain, contractAddress string) bool {
+	asset, ok := r.Lookup(chain, contractAddress)
+	return ok && !asset.Enabled
+}
+
+// IsNFTCollection reports whether the contract on the chain is an enabled NFT collection
+func (r *AssetRegistry) IsNFTCollection(chain common.Chain, contractAddress string) bool {
+	asset, ok := r.Lookup(chain, contractAddress)
+	return ok && asset.Enabled && asset.Kind == AssetKindNFT
+}
+
+// NFTCollections returns the enabled NFT collections
+func (r *AssetRegistry) NFTCollections() []TrackedAsset {
+	if r == nil {
+		return nil
+	}
+	return r.nfts
+}
+
+// Multiplier returns the multiplier of the contract on the chain when it is an enabled boosted asset
+func (r *AssetRegistry) Multiplier(chain common.Chain, contractAddress string) (float64, bool) {
+	asset, ok := r.Lookup(chain, contractAddress)
+	if !ok || !asset.Enabled || !asset.Boosted {
+		return 0, false
+	}
+	return asset.Multiplier, true
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+func TestTrackedAssetValidate(t *testing.T) {
+	token := TrackedAsset{Chain: common.Ethereum, ContractAddress: "0xdac17f958d2ee523a2206206994597c13d831ec7", Kind: AssetKindToken, PriceSource: PriceSourceCMC}
+	assert.NoError(t, token.Validate())
+	nft := TrackedAsset{Chain: common.Ethereum, ContractAddress: "0xa98b29a8f5a247802149c268ecf860b8308b7291", Kind: AssetKindNFT, PriceSource: PriceSourceOpenSea, PriceProviderID: "thorguards"}
+	assert.NoError(t, nft.Validate())
+
+	invalid := []TrackedAsset{
+		{ContractAddress: token.ContractAddress, Kind: AssetKindToken, PriceSource: PriceSourceCMC},
+		{Chain: common.Ethereum, Kind: "coin", PriceSource: PriceSourceCMC},
+		{Chain: common.Ethereum, Kind: AssetKindToken, PriceSource: "coingecko"},
+		{Chain: common.Ethereum, Kind: AssetKindNFT, PriceSource: PriceSourceOpenSea, PriceProviderID: "thorguards"},
+		{Chain: common.Ethereum, ContractAddress: nft.ContractAddress, Kind: AssetKindNFT, PriceSource: PriceSourceCMC},
+		{Chain: common.Ethereum, ContractAddress: nft.ContractAddress, Kind: AssetKindNFT, PriceSource: PriceSourceOpenSea},
+		{Chain: common.Ethereum, Kind: AssetKindToken, PriceSource: PriceSourceCMC, Boosted: true},
+	}
+	for _, asset := range invalid {
+		assert.Error(t, asset.Validate(), "%+v", asset)
+	}
+}
+
+func TestAssetRegistry(t *testing.T) {
+	registry := NewAssetRegistry([]TrackedAsset{
+		{ID: 2, Chain: common.Ethereum, ContractAddress: "0xA98B29A8F5A247802149C268ECF860B8308B7291", Kind: AssetKindNFT, Enabled: true},
+		{ID: 1, Chain: common.Ethereum, ContractAddress: "0xb788144df611029c60b859df47e79b7726c4deba", Kind: AssetKindToken, Enabled: true, Boosted: true, Multiplier: 3},
+		{ID: 3, Chain: common.Ethereum, ContractAddress: "0x514910771af9ca656af840dff83e8264ecf986ca", Kind: AssetKindToken, Enabled: false, Boosted: true, Multiplier: 2},
+		{ID: 4, Chain: common.Base, ContractAddress: "0x0000000000000000000000000000000000000001", Kind: AssetKindNFT, Enabled: false},
+	})
+
+	assert.True(t, registry.Tracks(common.Ethereum, "0xB788144DF611029C60B859DF47E79B7726C4DEBA"))
+	assert.False(t, registry.Tracks(common.Base, "0xb788144df611029c60b859df47e79b7726c4deba"))
+	assert.False(t, registry.Tracks(common.Ethereum, "0x514910771af9ca656af840dff83e8264ecf986ca"))
+	assert.True(t, registry.Disabled(common.Ethereum, "0x514910771AF9CA656AF840DFF83E8264ECF986CA"))
+	assert.False(t, registry.Disabled(common.Ethereum, "0xdac17f958d2ee523a2206206994597c13d831ec7"))
+
+	assert.True(t, registry.IsNFTCollection(common.Ethereum, "0xa98b29a8f5a247802149c268ecf860b8308b7291"))
+	assert.False(t, registry.IsNFTCollection(common.Base, "0x0000000000000000000000000000000000000001"))
+	nfts := registry.NFTCollections()
+	assert.Len(t, nfts, 1)
+	assert.Equal(t, uint(2), nfts[0].ID)
+
+	multiplier, ok := registry.Multiplier(common.Ethereum, "0xb788144df611029c60b859df47e79b7726c4deba")
+	assert.True(t, ok)
+	assert.Equal(t, float64(3), multiplier)
+	_, ok = registry.Multiplier(common.Ethereum, "0x514910771af9ca656af840dff83e8264ecf986ca")
+	assert.False(t, ok)
+
+	var none *AssetRegistry
+	assert.False(t, none.Tracks(common.Ethereum, "0xb788144df611029c60b859df47e79b7726c4deba"))
+	assert.Empty(t, none.NFTCollections())
+	_, ok = none.Multiplier(common.Ethereum, "0xb788144df611029c60b859df47e79b7726c4deba")
+	assert.False(t, ok)
+}
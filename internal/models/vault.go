@@ -37,6 +37,7 @@ type Vault struct {
 	ReferralCount         int64   `gorm:"type:bigint;default:0" json:"referral_count"`
 	CurrentSeasonID       uint    `gorm:"type:bigint;default:0" json:"current_season_id"`
 	NextMilestoneID       int     `gorm:"type:bigint;default:0" json:"next_milestone_id"`
+	Freshness                     // of the lp and nft values
 }
 
 func (*Vault) TableName() string {
@@ -52,6 +53,12 @@ func (v *Vault) GetAddress(chain common.Chain) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("fail to get child public key")
 	}
+	return chainAddress(chain, childPublicKey, v.EDDSA)
+}
+
+// chainAddress returns the address on the chain of the child public key derived for it, EdDSA chains
+// use the EdDSA public key of the vault instead
+func chainAddress(chain common.Chain, childPublicKey, eddsa string) (string, error) {
 	switch chain {
 	case common.THORChain:
 		return address.GetBech32Address(childPublicKey, "thor")
@@ -72,7 +79,7 @@ func (v *Vault) GetAddress(chain common.Chain) (string, error) {
 	case common.Akash:
 		return address.GetBech32Address(childPublicKey, "akash")
 	case common.Solana:
-		return address.GetSolAddress(v.EDDSA)
+		return address.GetSolAddress(eddsa)
 	case common.Bitcoin:
 		return address.GetBitcoinAddress(childPublicKey)
 	case common.Litecoin:
@@ -86,11 +93,11 @@ func (v *Vault) GetAddress(chain common.Chain) (string, error) {
 	case common.Ethereum, common.BscChain, common.Polygon, common.Base, common.Avalanche, common.Arbitrum, common.Blast, common.CronosChain, common.Zksync, common.Optimism:
 		return address.GetEVMAddress(childPublicKey)
 	case common.Polkadot:
-		return address.GetDotAddress(v.EDDSA)
+		return address.GetDotAddress(eddsa)
 	case common.Sui:
-		return address.GetSuiAddress(v.EDDSA)
+		return address.GetSuiAddress(eddsa)
 	case common.Ton:
-		return address.GetTonAddress(v.EDDSA)
+		return address.GetTonAddress(eddsa)
 	case common.XRP:
 		return address.GetXRPAddress(childPublicKey)
 	case common.Tron:
@@ -21,7 +21,8 @@ type VaultResponse struct {
 	SwapVolume            float64       `json:"swap_volume"`
 	ReferralCode          string        `json:"referral_code"`
 	ReferralCount         int64         `json:"referral_count"`
-	SeasonActivities      []SeasonStats `json:"season_stats"` // Needed to highlight user in the leaderboard of each season
+	SeasonActivities      []SeasonStats `json:"season_stats"`        // Needed to highlight user in the leaderboard of each season
+	Staleness             *Staleness    `json:"staleness,omitempty"` // balances and positions that failed to refresh
 }
 
 type SeasonStats struct {
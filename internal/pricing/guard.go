@@ -0,0 +1,61 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/vultisig/airdrop-registry/config"
+)
+
+// SourceUncounted is the source of coins priced at zero, their market is below the minimum of the guard
+const SourceUncounted = "uncounted"
+
+// Guard checks prices against the last good price of their asset, and coins against the minimum market.
+// The zero guard accepts every price.
+type Guard struct {
+	maxChange    float64
+	minMarketCap float64
+	minVolume    float64
+}
+
+func NewGuard(cfg config.PriceGuard) (Guard, error) {
+	if cfg.MaxChange < 0 || cfg.MinMarketCap < 0 || cfg.MinVolume < 0 {
+		return Guard{}, fmt.Errorf("invalid price guard %+v", cfg)
+	}
+	return Guard{
+		maxChange:    cfg.MaxChange,
+		minMarketCap: cfg.MinMarketCap,
+		minVolume:    cfg.MinVolume,
+	}, nil
+}
+
+// Quarantines reports whether the price moved more than the maximum change from the previous good price,
+// an asset without a previous price is never quarantined
+func (g Guard) Quarantines(previous, price float64) bool {
+	if g.maxChange == 0 || previous <= 0 {
+		return false
+	}
+	return math.Abs(Change(previous, price)) > g.maxChange
+}
+
+// ChecksMarket reports whether the guard needs the market of the coins
+func (g Guard) ChecksMarket() bool {
+	return g.minMarketCap > 0 || g.minVolume > 0
+}
+
+// Counts reports whether a coin with the market cap and 24h volume counts towards points, it needs one of
+// the minimums the guard has
+func (g Guard) Counts(marketCap, volume float64) bool {
+	if !g.ChecksMarket() {
+		return true
+	}
+	return (g.minMarketCap > 0 && marketCap >= g.minMarketCap) || (g.minVolume > 0 && volume >= g.minVolume)
+}
+
+// Change returns the relative change from the previous price, 0 without a previous price
+func Change(previous, price float64) float64 {
+	if previous <= 0 {
+		return 0
+	}
+	return (price - previous) / previous
+}
@@ -0,0 +1,40 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/config"
+)
+
+func TestGuard(t *testing.T) {
+	_, err := NewGuard(config.PriceGuard{MaxChange: -0.5})
+	assert.Error(t, err)
+
+	var zero Guard
+	assert.False(t, zero.Quarantines(1, 100))
+	assert.False(t, zero.ChecksMarket())
+	assert.True(t, zero.Counts(0, 0))
+
+	guard, err := NewGuard(config.PriceGuard{MaxChange: 0.5, MinMarketCap: 1_000_000, MinVolume: 50_000})
+	assert.NoError(t, err)
+	assert.False(t, guard.Quarantines(1, 1.5))
+	assert.True(t, guard.Quarantines(1, 1.51))
+	assert.True(t, guard.Quarantines(1, 0.49))
+	assert.False(t, guard.Quarantines(0, 1000))
+	assert.True(t, guard.ChecksMarket())
+	assert.True(t, guard.Counts(1_000_000, 0))
+	assert.True(t, guard.Counts(0, 50_000))
+	assert.False(t, guard.Counts(999_999, 49_999))
+
+	guard, err = NewGuard(config.PriceGuard{MinVolume: 50_000})
+	assert.NoError(t, err)
+	assert.False(t, guard.Counts(1_000_000_000, 0))
+}
+
+func TestChange(t *testing.T) {
+	assert.InDelta(t, 0.25, Change(2, 2.5), 1e-9)
+	assert.InDelta(t, -0.5, Change(2, 1), 1e-9)
+	assert.Equal(t, float64(0), Change(0, 1))
+}
@@ -0,0 +1,287 @@
+// Package pricing routes the price of every coin to its price sources. A coin falls back to its next source
+// when one fails, and a coin asked to several sources takes the median of the prices that agree.
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+const (
+	SourceCMC         = "cmc"          // CoinMarketCap quote of the cmc id
+	SourceCoinGecko   = "coingecko"    // CoinGecko price of the coin id
+	SourceLiFi        = "lifi"         // LI.FI price of the chain:contract token
+	SourceMidgard     = "midgard"      // THORChain midgard price of the pool asset
+	SourceMayaMidgard = "maya_midgard" // MAYAChain midgard price of CACAO
+	SourceFixed       = "fixed"        // the id itself
+	SourceOpenSea     = "opensea"      // OpenSea floor price of the nft collection, not routable
+)
+
+// PriceSource quotes USD prices by the ids the source knows the assets by. Ids without a price are left out
+// of the result, an error means the whole call failed.
+type PriceSource interface {
+	Name() string
+	Prices(ctx context.Context, ids []string) (map[string]float64, error)
+}
+
+type batchSource struct {
+	name   string
+	prices func(ctx context.Context, ids []string) (map[string]float64, error)
+}
+
+// NewBatchSource returns a source quoting all ids in one call
+func NewBatchSource(name string, prices func(ctx context.Context, ids []string) (map[string]float64, error)) PriceSource {
+	return &batchSource{name: name, prices: prices}
+}
+
+func (s *batchSource) Name() string {
+	return s.name
+}
+
+func (s *batchSource) Prices(ctx context.Context, ids []string) (map[string]float64, error) {
+	return s.prices(ctx, ids)
+}
+
+type singleSource struct {
+	name  string
+	price func(ctx context.Context, id string) (float64, error)
+}
+
+// NewSingleSource returns a source quoting one id per call
+func NewSingleSource(name string, price func(ctx context.Context, id string) (float64, error)) PriceSource {
+	return &singleSource{name: name, price: price}
+}
+
+func (s *singleSource) Name() string {
+	return s.name
+}
+
+// Prices leaves out the ids whose call failed, it fails when all of them did
+func (s *singleSource) Prices(ctx context.Context, ids []string) (map[string]float64, error) {
+	prices := make(map[string]float64, len(ids))
+	var errs []error
+	for _, id := range ids {
+		price, err := s.price(ctx, id)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", id, err))
+			continue
+		}
+		prices[id] = price
+	}
+	if len(prices) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return prices, nil
+}
+
+// fixedSource prices an asset at its id, for assets without a market
+type fixedSource struct{}
+
+func (fixedSource) Name() string {
+	return SourceFixed
+}
+
+func (fixedSource) Prices(ctx context.Context, ids []string) (map[string]float64, error) {
+	prices := make(map[string]float64, len(ids))
+	for _, id := range ids {
+		price, err := strconv.ParseFloat(id, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fixed price %q: %w", id, err)
+		}
+		prices[id] = price
+	}
+	return prices, nil
+}
+
+// Quote is a price and the source that produced it
+type Quote struct {
+	Price  float64
+	Source string
+}
+
+// SourceRef is an asset at a source
+type SourceRef struct {
+	Source string
+	ID     string
+}
+
+// Route prices the coins with the ticker on the chain
+type Route struct {
+	Chain   common.Chain
+	Ticker  string
+	Median  bool
+	Sources []SourceRef
+}
+
+type routeKey struct {
+	chain  common.Chain
+	ticker string
+}
+
+// Oracle prices coins through their route, or through the default sources by their cmc id
+type Oracle struct {
+	sources   map[string]PriceSource
+	defaults  []string
+	tolerance float64
+	routes    []Route
+	routed    map[routeKey]bool
+}
+
+// NewOracle validates the routes of the config against the sources, the fixed source is always available
+func NewOracle(cfg config.Pricing, sources ...PriceSource) (*Oracle, error) {
+	if cfg.Tolerance < 0 {
+		return nil, fmt.Errorf("invalid pricing tolerance %v", cfg.Tolerance)
+	}
+	o := &Oracle{
+		sources:   map[string]PriceSource{SourceFixed: fixedSource{}},
+		defaults:  cfg.DefaultSources,
+		tolerance: cfg.Tolerance,
+		routed:    make(map[routeKey]bool, len(cfg.Routes)),
+	}
+	for _, source := range sources {
+		o.sources[source.Name()] = source
+	}
+	for _, name := range cfg.DefaultSources {
+		if _, ok := o.sources[name]; !ok || name == SourceFixed {
+			return nil, fmt.Errorf("invalid default price source %s", name)
+		}
+	}
+	for _, r := range cfg.Routes {
+		chain, err := common.ParseChain(r.Chain)
+		if err != nil {
+			return nil, fmt.Errorf("invalid price route of %s: %w", r.Ticker, err)
+		}
+		if r.Ticker == "" || len(r.Sources) == 0 {
+			return nil, fmt.Errorf("price route on %s needs a ticker and sources", r.Chain)
+		}
+		route := Route{Chain: chain, Ticker: r.Ticker, Median: r.Median}
+		for _, ref := range r.Sources {
+			if _, ok := o.sources[ref.Source]; !ok {
+				return nil, fmt.Errorf("unknown price source %s of %s on %s", ref.Source, r.Ticker, r.Chain)
+			}
+			if ref.Source == SourceFixed {
+				if _, err := strconv.ParseFloat(ref.ID, 64); err != nil {
+					return nil, fmt.Errorf("invalid fixed price %q of %s on %s", ref.ID, r.Ticker, r.Chain)
+				}
+			}
+			route.Sources = append(route.Sources, SourceRef{Source: ref.Source, ID: ref.ID})
+		}
+		key := routeKey{chain: chain, ticker: r.Ticker}
+		if o.routed[key] {
+			return nil, fmt.Errorf("duplicate price route of %s on %s", r.Ticker, r.Chain)
+		}
+		o.routed[key] = true
+		o.routes = append(o.routes, route)
+	}
+	return o, nil
+}
+
+// Routes returns the configured routes
+func (o *Oracle) Routes() []Route {
+	return o.routes
+}
+
+// Routed reports whether the coins with the ticker on the chain have a route
+func (o *Oracle) Routed(chain common.Chain, ticker string) bool {
+	return o.routed[routeKey{chain: chain, ticker: ticker}]
+}
+
+// RoutePrice prices the route from its first source with a price, or from the median of its sources
+func (o *Oracle) RoutePrice(ctx context.Context, route Route) (Quote, error) {
+	var quotes []Quote
+	var errs []error
+	for _, ref := range route.Sources {
+		prices, err := o.sources[ref.Source].Prices(ctx, []string{ref.ID})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ref.Source, err))
+			continue
+		}
+		price, ok := prices[ref.ID]
+		if !ok || price <= 0 {
+			errs = append(errs, fmt.Errorf("%s: no price for %s", ref.Source, ref.ID))
+			continue
+		}
+		quotes = append(quotes, Quote{Price: price, Source: ref.Source})
+		if !route.Median {
+			break
+		}
+	}
+	if len(quotes) == 0 {
+		return Quote{}, fmt.Errorf("no price for %s on %s: %w", route.Ticker, route.Chain, errors.Join(errs...))
+	}
+	return Aggregate(quotes, o.tolerance), nil
+}
+
+// DefaultPrices prices the cmc ids through the default sources, an id a source has no price for is asked to
+// the next one. It fails when no id got a price and a source failed.
+func (o *Oracle) DefaultPrices(ctx context.Context, ids []string) (map[string]Quote, error) {
+	quotes := make(map[string]Quote, len(ids))
+	remaining := ids
+	var errs []error
+	for _, name := range o.defaults {
+		if len(remaining) == 0 {
+			break
+		}
+		prices, err := o.sources[name].Prices(ctx, remaining)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		var missing []string
+		for _, id := range remaining {
+			if price, ok := prices[id]; ok && price > 0 {
+				quotes[id] = Quote{Price: price, Source: name}
+			} else {
+				missing = append(missing, id)
+			}
+		}
+		remaining = missing
+	}
+	if len(quotes) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return quotes, nil
+}
+
+// Aggregate picks the price of quotes from several sources, given in the order of the route. When at least two
+// quotes are within the tolerance of the median of all quotes, it takes the median of those, otherwise the
+// first quote.
+func Aggregate(quotes []Quote, tolerance float64) Quote {
+	if len(quotes) == 1 {
+		return quotes[0]
+	}
+	prices := make([]float64, len(quotes))
+	for i, quote := range quotes {
+		prices[i] = quote.Price
+	}
+	m := median(prices)
+	var agreeing []float64
+	var names []string
+	for _, quote := range quotes {
+		if quote.Price >= m*(1-tolerance) && quote.Price <= m*(1+tolerance) {
+			agreeing = append(agreeing, quote.Price)
+			names = append(names, quote.Source)
+		}
+	}
+	if len(agreeing) < 2 {
+		return quotes[0]
+	}
+	return Quote{Price: median(agreeing), Source: "median:" + strings.Join(names, ",")}
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
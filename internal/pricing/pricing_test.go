@@ -0,0 +1,128 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/common"
+)
+
+func stubSource(name string, prices map[string]float64, err error) PriceSource {
+	return NewBatchSource(name, func(ctx context.Context, ids []string) (map[string]float64, error) {
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[string]float64)
+		for _, id := range ids {
+			if price, ok := prices[id]; ok {
+				result[id] = price
+			}
+		}
+		return result, nil
+	})
+}
+
+func TestNewOracle(t *testing.T) {
+	sources := []PriceSource{stubSource(SourceCMC, nil, nil)}
+	_, err := NewOracle(config.Pricing{DefaultSources: []string{SourceCMC}}, sources...)
+	assert.NoError(t, err)
+
+	invalid := []config.Pricing{
+		{DefaultSources: []string{"coinpaprika"}},
+		{DefaultSources: []string{SourceFixed}},
+		{Tolerance: -1},
+		{Routes: []config.PriceRoute{{Chain: "Atlantis", Ticker: "X", Sources: []config.PriceRouteSource{{Source: SourceCMC, ID: "1"}}}}},
+		{Routes: []config.PriceRoute{{Chain: "Ethereum", Ticker: "X"}}},
+		{Routes: []config.PriceRoute{{Chain: "Ethereum", Ticker: "X", Sources: []config.PriceRouteSource{{Source: SourceLiFi, ID: "eth:0x1"}}}}},
+		{Routes: []config.PriceRoute{{Chain: "MayaChain", Ticker: "MAYA", Sources: []config.PriceRouteSource{{Source: SourceFixed, ID: "forty"}}}}},
+		{Routes: []config.PriceRoute{
+			{Chain: "MayaChain", Ticker: "MAYA", Sources: []config.PriceRouteSource{{Source: SourceFixed, ID: "40"}}},
+			{Chain: "MayaChain", Ticker: "MAYA", Sources: []config.PriceRouteSource{{Source: SourceFixed, ID: "41"}}},
+		}},
+	}
+	for _, cfg := range invalid {
+		_, err := NewOracle(cfg, sources...)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
+
+func TestRoutePrice(t *testing.T) {
+	oracle, err := NewOracle(config.Pricing{
+		Tolerance: 0.05,
+		Routes: []config.PriceRoute{
+			{Chain: "MayaChain", Ticker: "MAYA", Sources: []config.PriceRouteSource{{Source: SourceFixed, ID: "40"}}},
+			{Chain: "Solana", Ticker: "KWEEN", Sources: []config.PriceRouteSource{{Source: SourceCMC, ID: "1"}, {Source: SourceCoinGecko, ID: "kween"}}},
+			{Chain: "THORChain", Ticker: "RUJIRA", Median: true, Sources: []config.PriceRouteSource{
+				{Source: SourceCMC, ID: "2"}, {Source: SourceCoinGecko, ID: "rujira"}, {Source: SourceLiFi, ID: "thor:rujira"},
+			}},
+			{Chain: "THORChain", Ticker: "THOR.TCY", Median: true, Sources: []config.PriceRouteSource{
+				{Source: SourceCoinGecko, ID: "tcy"}, {Source: SourceLiFi, ID: "thor:tcy"},
+			}},
+			{Chain: "Ethereum", Ticker: "vTHOR", Sources: []config.PriceRouteSource{{Source: SourceCMC, ID: "3"}, {Source: SourceLiFi, ID: "eth:vthor"}}},
+		},
+	},
+		stubSource(SourceCMC, map[string]float64{"2": 0.5}, nil),
+		stubSource(SourceCoinGecko, map[string]float64{"kween": 0.01, "rujira": 0.52, "tcy": 0.2}, nil),
+		stubSource(SourceLiFi, map[string]float64{"thor:rujira": 0.9, "thor:tcy": 0.3}, fmt.Errorf("lifi is down")),
+	)
+	assert.NoError(t, err)
+	routes := oracle.Routes()
+	assert.Len(t, routes, 5)
+	assert.True(t, oracle.Routed(common.Solana, "KWEEN"))
+	assert.False(t, oracle.Routed(common.Solana, "JUP"))
+
+	quote, err := oracle.RoutePrice(context.Background(), routes[0])
+	assert.NoError(t, err)
+	assert.Equal(t, Quote{Price: 40, Source: SourceFixed}, quote)
+
+	// cmc has no price, falls back to coingecko
+	quote, err = oracle.RoutePrice(context.Background(), routes[1])
+	assert.NoError(t, err)
+	assert.Equal(t, Quote{Price: 0.01, Source: SourceCoinGecko}, quote)
+
+	// lifi fails, cmc and coingecko agree
+	quote, err = oracle.RoutePrice(context.Background(), routes[2])
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.51, quote.Price, 1e-9)
+	assert.Equal(t, "median:cmc,coingecko", quote.Source)
+
+	// a single source answers the median route
+	quote, err = oracle.RoutePrice(context.Background(), routes[3])
+	assert.NoError(t, err)
+	assert.Equal(t, Quote{Price: 0.2, Source: SourceCoinGecko}, quote)
+
+	_, err = oracle.RoutePrice(context.Background(), routes[4])
+	assert.ErrorContains(t, err, "lifi is down")
+}
+
+func TestDefaultPrices(t *testing.T) {
+	oracle, err := NewOracle(config.Pricing{DefaultSources: []string{SourceCMC, SourceCoinGecko}},
+		stubSource(SourceCMC, map[string]float64{"1": 100, "2": 0}, nil),
+		stubSource(SourceCoinGecko, map[string]float64{"2": 2}, nil),
+	)
+	assert.NoError(t, err)
+	quotes, err := oracle.DefaultPrices(context.Background(), []string{"1", "2", "3"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Quote{
+		"1": {Price: 100, Source: SourceCMC},
+		"2": {Price: 2, Source: SourceCoinGecko},
+	}, quotes)
+
+	oracle, err = NewOracle(config.Pricing{DefaultSources: []string{SourceCMC}}, stubSource(SourceCMC, nil, fmt.Errorf("cmc is down")))
+	assert.NoError(t, err)
+	_, err = oracle.DefaultPrices(context.Background(), []string{"1"})
+	assert.ErrorContains(t, err, "cmc is down")
+}
+
+func TestAggregate(t *testing.T) {
+	assert.Equal(t, Quote{Price: 1, Source: "a"}, Aggregate([]Quote{{Price: 1, Source: "a"}}, 0.05))
+	// b, c and d agree, a is an outlier
+	assert.Equal(t, Quote{Price: 2.02, Source: "median:b,c,d"},
+		Aggregate([]Quote{{Price: 10, Source: "a"}, {Price: 2, Source: "b"}, {Price: 2.02, Source: "c"}, {Price: 2.04, Source: "d"}}, 0.05))
+	// no two sources agree, the first source wins
+	assert.Equal(t, Quote{Price: 1, Source: "a"}, Aggregate([]Quote{{Price: 1, Source: "a"}, {Price: 2, Source: "b"}}, 0.05))
+}
@@ -0,0 +1,115 @@
+package replay
+
+import (
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/scoring"
+)
+
+// Scenario is one way of scoring a season. Balances keep the token multipliers recorded by the worker
+// unless RescoreTokens is set, then they are rescored with the token multipliers of the formula.
+// NFT values are always taken as recorded since they already include the collection multipliers.
+type Scenario struct {
+	Formula       scoring.Formula
+	RescoreTokens bool
+}
+
+func (s Scenario) eventValue(event models.PointEvent, coin *models.CoinDBModel) float64 {
+	value := event.Value
+	if s.RescoreTokens && event.Source == models.PointSourceBalance && coin != nil {
+		value = event.Balance * event.Price * float64(event.Multiplier) * s.Formula.TokenMultiplier(*coin)
+	}
+	return value * s.Formula.Weight(event.Source)
+}
+
+type vaultState struct {
+	totalPoints     float64
+	nextMilestoneID int
+	swapVolume      float64
+	referralCount   int64
+}
+
+// points mirrors the season points of a vault as returned by /seasons/points
+func (v *vaultState) points(formula scoring.Formula) float64 {
+	return formula.SeasonPoints(v.totalPoints, v.referralCount, v.swapVolume)
+}
+
+// Replay scores the recorded point events of a season under a baseline and an alternative scenario.
+// It only keeps state in memory.
+type Replay struct {
+	baseline      Scenario
+	alternative   Scenario
+	baseState     map[uint]*vaultState
+	altState      map[uint]*vaultState
+	swapVolume    map[uint]float64
+	referralCount map[uint]int64
+	jobs          int
+}
+
+func NewReplay(baseline, alternative Scenario) *Replay {
+	return &Replay{
+		baseline:      baseline,
+		alternative:   alternative,
+		baseState:     make(map[uint]*vaultState),
+		altState:      make(map[uint]*vaultState),
+		swapVolume:    make(map[uint]float64),
+		referralCount: make(map[uint]int64),
+	}
+}
+
+// AddJob scores all events of one job, jobs must be added oldest first. coins holds the coins
+// referenced by balance events.
+func (r *Replay) AddJob(events []models.PointEvent, coins map[uint]models.CoinDBModel) {
+	baseValues := make(map[uint]float64)
+	altValues := make(map[uint]float64)
+	for _, event := range events {
+		switch event.Source {
+		case models.PointSourceVolume:
+			r.swapVolume[event.VaultID] += event.Balance
+			continue
+		case models.PointSourceReferral:
+			r.referralCount[event.VaultID] = int64(event.Balance)
+			continue
+		}
+		var coin *models.CoinDBModel
+		if c, ok := coins[event.CoinID]; ok {
+			coin = &c
+		}
+		baseValues[event.VaultID] += r.baseline.eventValue(event, coin)
+		altValues[event.VaultID] += r.alternative.eventValue(event, coin)
+	}
+	accrue(r.baseline, r.baseState, baseValues)
+	accrue(r.alternative, r.altState, altValues)
+	r.jobs++
+}
+
+func accrue(scenario Scenario, states map[uint]*vaultState, values map[uint]float64) {
+	for vaultID, value := range values {
+		state, ok := states[vaultID]
+		if !ok {
+			state = &vaultState{}
+			states[vaultID] = state
+		}
+		state.totalPoints += scenario.Formula.DailyPoints(value)
+	}
+	// milestones are checked for every vault after each job, like updateVaultsMilestone
+	for _, state := range states {
+		var prize float64
+		state.nextMilestoneID, prize = scenario.Formula.UnlockMilestones(state.totalPoints, state.nextMilestoneID)
+		state.totalPoints += prize
+	}
+}
+
+// Jobs returns the number of jobs replayed so far
+func (r *Replay) Jobs() int {
+	return r.jobs
+}
+
+// Score returns the total points and next milestone of the vault under the baseline scenario, as the
+// worker stores them on the vault
+func (r *Replay) Score(vaultID uint) (float64, int) {
+	state, ok := r.baseState[vaultID]
+	if !ok {
+		return 0, 0
+	}
+	return state.totalPoints, state.nextMilestoneID
+}
@@ -0,0 +1,102 @@
+package replay
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/scoring"
+)
+
+func TestReplay(t *testing.T) {
+	job := models.Job{Multiplier: 1}
+	job.ID = 1
+	coins := map[uint]models.CoinDBModel{
+		10: {CoinBase: models.CoinBase{Chain: common.Ethereum, Ticker: "VULT", ContractAddress: "0xb788144df611029c60b859df47e79b7726c4deba"}},
+		20: {CoinBase: models.CoinBase{Chain: common.Bitcoin, Ticker: "BTC"}},
+	}
+	events := []models.PointEvent{
+		models.NewPointEvent(job, 1, models.PointSourceBalance, 10, 100, 1, 1), // 100
+		models.NewPointEvent(job, 2, models.PointSourceBalance, 20, 1, 400, 1), // 400
+		models.NewPointEvent(job, 2, models.PointSourceVolume, 0, 2500, 0, 0),
+	}
+	season := config.AirdropSeason{
+		ID: 1,
+		Tokens: []config.Token{
+			{Chain: common.Ethereum.String(), Name: "VULT", ContractAddress: "0xb788144df611029c60b859df47e79b7726c4deba", Multiplier: 9},
+		},
+	}
+	baseline, err := scoring.NewFormula(config.AirdropSeason{ID: 1})
+	assert.NoError(t, err)
+	alternative, err := scoring.NewFormula(season)
+	assert.NoError(t, err)
+	r := NewReplay(
+		Scenario{Formula: baseline},
+		Scenario{Formula: alternative, RescoreTokens: true},
+	)
+	r.AddJob(events, coins)
+	assert.Equal(t, 1, r.Jobs())
+
+	report := r.Report(1, []models.Vault{vault(1, "one"), vault(2, "two"), vault(3, "idle")}, 5)
+	assert.Len(t, report.Vaults, 3)
+	byID := make(map[uint]VaultDiff)
+	for _, d := range report.Vaults {
+		byID[d.VaultID] = d
+	}
+	// baseline: vault 1 sqrt(100)=10, vault 2 sqrt(400)=20 with a 1.1 swap multiplier
+	assert.InDelta(t, 10, byID[1].BaselinePoints, 1e-9)
+	assert.InDelta(t, 22, byID[2].BaselinePoints, 1e-9)
+	assert.Equal(t, 2, byID[1].BaselineRank)
+	assert.Equal(t, 1, byID[2].BaselineRank)
+	// alternative boosts VULT nine times: sqrt(900)=30
+	assert.InDelta(t, 30, byID[1].AlternativePoints, 1e-9)
+	assert.Equal(t, 1, byID[1].AlternativeRank)
+	assert.Equal(t, 1, byID[1].RankDelta)
+	assert.Equal(t, -1, byID[2].RankDelta)
+	assert.Equal(t, 0, byID[3].RankDelta)
+	assert.Equal(t, uint(1), report.Vaults[0].VaultID)
+	assert.Len(t, report.TopGainers, 1)
+	assert.Len(t, report.TopLosers, 1)
+	assert.Equal(t, uint(2), report.TopLosers[0].VaultID)
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.WriteCSV(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 4)
+	assert.True(t, strings.HasPrefix(lines[1], "1,one,10,2,30,1,20,1"))
+}
+
+func TestReplayMilestones(t *testing.T) {
+	job := models.Job{Multiplier: 1}
+	job.ID = 1
+	formula, err := scoring.NewFormula(config.AirdropSeason{
+		Formula:    config.Formula{Aggregation: string(scoring.AggregationLinear)},
+		Milestones: []config.Milestone{{Minimum: 50, Prize: 5}, {Minimum: 100, Prize: 10}},
+	})
+	assert.NoError(t, err)
+	scenario := Scenario{Formula: formula}
+	r := NewReplay(scenario, scenario)
+	event := models.NewPointEvent(job, 1, models.PointSourceLP, 0, 60, 1, 1)
+	r.AddJob([]models.PointEvent{event}, nil)
+	r.AddJob([]models.PointEvent{event}, nil)
+	report := r.Report(1, []models.Vault{vault(1, "one")}, 5)
+	// 60 + 5 after the first job, 125 + 10 after the second
+	assert.InDelta(t, 135, report.Vaults[0].BaselinePoints, 1e-9)
+	points, nextMilestoneID := r.Score(1)
+	assert.InDelta(t, 135, points, 1e-9)
+	assert.Equal(t, 2, nextMilestoneID)
+	points, nextMilestoneID = r.Score(2)
+	assert.Zero(t, points)
+	assert.Zero(t, nextMilestoneID)
+}
+
+func vault(id uint, name string) models.Vault {
+	v := models.Vault{Name: name}
+	v.ID = id
+	return v
+}
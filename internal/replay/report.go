@@ -0,0 +1,130 @@
+package replay
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// VaultDiff compares the points and rank of a vault under both scenarios, a positive RankDelta means
+// the vault moves up the leaderboard under the alternative scenario
+type VaultDiff struct {
+	VaultID           uint    `json:"vault_id"`
+	Name              string  `json:"name"`
+	BaselinePoints    float64 `json:"baseline_points"`
+	BaselineRank      int     `json:"baseline_rank"`
+	AlternativePoints float64 `json:"alternative_points"`
+	AlternativeRank   int     `json:"alternative_rank"`
+	PointsDelta       float64 `json:"points_delta"`
+	RankDelta         int     `json:"rank_delta"`
+}
+
+type Report struct {
+	SeasonID   uint        `json:"season_id"`
+	Jobs       int         `json:"jobs"`
+	Vaults     []VaultDiff `json:"vaults"` // ordered by rank movement, gainers first
+	TopGainers []VaultDiff `json:"top_gainers"`
+	TopLosers  []VaultDiff `json:"top_losers"`
+}
+
+// Report ranks the given vaults under both scenarios and lists the top count gainers and losers
+func (r *Replay) Report(seasonID uint, vaults []models.Vault, count int) Report {
+	diffs := make([]VaultDiff, len(vaults))
+	for i, vault := range vaults {
+		diffs[i] = VaultDiff{
+			VaultID:           vault.ID,
+			Name:              vault.Name,
+			BaselinePoints:    r.state(r.baseState, vault.ID).points(r.baseline.Formula),
+			AlternativePoints: r.state(r.altState, vault.ID).points(r.alternative.Formula),
+		}
+		diffs[i].PointsDelta = diffs[i].AlternativePoints - diffs[i].BaselinePoints
+	}
+	rank(diffs, func(d VaultDiff) float64 { return d.BaselinePoints }, func(d *VaultDiff, rank int) { d.BaselineRank = rank })
+	rank(diffs, func(d VaultDiff) float64 { return d.AlternativePoints }, func(d *VaultDiff, rank int) { d.AlternativeRank = rank })
+	for i := range diffs {
+		diffs[i].RankDelta = diffs[i].BaselineRank - diffs[i].AlternativeRank
+	}
+	sort.SliceStable(diffs, func(i, j int) bool {
+		if diffs[i].RankDelta != diffs[j].RankDelta {
+			return diffs[i].RankDelta > diffs[j].RankDelta
+		}
+		return diffs[i].PointsDelta > diffs[j].PointsDelta
+	})
+	report := Report{
+		SeasonID:   seasonID,
+		Jobs:       r.jobs,
+		Vaults:     diffs,
+		TopGainers: []VaultDiff{},
+		TopLosers:  []VaultDiff{},
+	}
+	for i := 0; i < len(diffs) && len(report.TopGainers) < count && diffs[i].RankDelta > 0; i++ {
+		report.TopGainers = append(report.TopGainers, diffs[i])
+	}
+	for i := len(diffs) - 1; i >= 0 && len(report.TopLosers) < count && diffs[i].RankDelta < 0; i-- {
+		report.TopLosers = append(report.TopLosers, diffs[i])
+	}
+	return report
+}
+
+func (r *Replay) state(states map[uint]*vaultState, vaultID uint) *vaultState {
+	state := vaultState{
+		swapVolume:    r.swapVolume[vaultID],
+		referralCount: r.referralCount[vaultID],
+	}
+	if s, ok := states[vaultID]; ok {
+		state.totalPoints = s.totalPoints
+	}
+	return &state
+}
+
+// rank assigns consecutive ranks by points descending, ties keep vault id order like UpdateVaultRanks
+func rank(diffs []VaultDiff, points func(VaultDiff) float64, set func(*VaultDiff, int)) {
+	idx := make([]int, len(diffs))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		a, b := diffs[idx[i]], diffs[idx[j]]
+		if points(a) != points(b) {
+			return points(a) > points(b)
+		}
+		return a.VaultID < b.VaultID
+	})
+	for r, i := range idx {
+		set(&diffs[i], r+1)
+	}
+}
+
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes one row per vault, ordered by rank movement
+func (r Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"vault_id", "name", "baseline_points", "baseline_rank", "alternative_points", "alternative_rank", "points_delta", "rank_delta"}); err != nil {
+		return err
+	}
+	for _, d := range r.Vaults {
+		if err := writer.Write([]string{
+			strconv.FormatUint(uint64(d.VaultID), 10),
+			d.Name,
+			strconv.FormatFloat(d.BaselinePoints, 'f', -1, 64),
+			strconv.Itoa(d.BaselineRank),
+			strconv.FormatFloat(d.AlternativePoints, 'f', -1, 64),
+			strconv.Itoa(d.AlternativeRank),
+			strconv.FormatFloat(d.PointsDelta, 'f', -1, 64),
+			strconv.Itoa(d.RankDelta),
+		}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,204 @@
+// Package scoring evaluates the scoring formula of an airdrop season. The point worker, the season
+// handlers and the replay command all score vaults through it.
+package scoring
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// Aggregation turns the value a vault accrued during a job into points
+type Aggregation string
+
+const (
+	AggregationSqrt   Aggregation = "sqrt"
+	AggregationLinear Aggregation = "linear"
+	AggregationLog    Aggregation = "log"
+)
+
+func ParseAggregation(s string) (Aggregation, error) {
+	switch Aggregation(s) {
+	case "":
+		return AggregationSqrt, nil
+	case AggregationSqrt, AggregationLinear, AggregationLog:
+		return Aggregation(s), nil
+	}
+	return "", fmt.Errorf("unknown aggregation %s", s)
+}
+
+func (a Aggregation) Apply(value float64) float64 {
+	if value <= 0 {
+		return 0
+	}
+	switch a {
+	case AggregationLinear:
+		return value
+	case AggregationLog:
+		return math.Log1p(value)
+	default:
+		return math.Sqrt(value)
+	}
+}
+
+const (
+	// referral count at which the referral bonus is complete
+	referralBase = 500
+	// bonus of the swap volume multiplier per square root of USD volume
+	volumeFactor = 0.002
+)
+
+// Formula is the resolved scoring formula of a season
+type Formula struct {
+	Aggregation           Aggregation
+	BalanceWeight         float64
+	LPWeight              float64
+	NFTWeight             float64
+	VolumeWeight          float64
+	ReferralWeight        float64
+	DailyValueCap         float64 // 0 for no cap
+	ReferralMultiplierCap float64
+	VolumeMultiplierCap   float64 // 0 for no cap
+	Milestones            []config.Milestone
+	Tokens                []config.Token
+	NFTs                  []config.NFT
+	Samples               int // balance samples per day, 0 credits the read of the job only
+	SampleAggregation     SampleAggregation
+	Assets                *models.AssetRegistry // multipliers of boosted assets the season does not list, nil for none
+}
+
+// NewFormula resolves the formula of the season, missing settings keep the default formula
+func NewFormula(season config.AirdropSeason) (Formula, error) {
+	aggregation, err := ParseAggregation(season.Formula.Aggregation)
+	if err != nil {
+		return Formula{}, fmt.Errorf("invalid formula of season %d: %w", season.ID, err)
+	}
+	sampleAggregation, err := ParseSampleAggregation(season.Sampling.Aggregation)
+	if err != nil {
+		return Formula{}, fmt.Errorf("invalid sampling of season %d: %w", season.ID, err)
+	}
+	if season.Sampling.Samples < 0 {
+		return Formula{}, fmt.Errorf("invalid sampling of season %d: negative samples", season.ID)
+	}
+	weights := season.Formula.Weights
+	caps := season.Formula.Caps
+	return Formula{
+		Aggregation:           aggregation,
+		BalanceWeight:         valueOr(weights.Balance, 1),
+		LPWeight:              valueOr(weights.LP, 1),
+		NFTWeight:             valueOr(weights.NFT, 1),
+		VolumeWeight:          valueOr(weights.Volume, 1),
+		ReferralWeight:        valueOr(weights.Referral, 1),
+		DailyValueCap:         caps.DailyValue,
+		ReferralMultiplierCap: valueOr(caps.ReferralMultiplier, 2),
+		VolumeMultiplierCap:   caps.VolumeMultiplier,
+		Milestones:            season.Milestones,
+		Tokens:                season.Tokens,
+		NFTs:                  season.NFTs,
+		Samples:               season.Sampling.Samples,
+		SampleAggregation:     sampleAggregation,
+	}, nil
+}
+
+func valueOr(v *float64, fallback float64) float64 {
+	if v == nil {
+		return fallback
+	}
+	return *v
+}
+
+// Weight returns the weight of point events of the given source, volume and referral events carry no value
+func (f Formula) Weight(source models.PointSource) float64 {
+	switch source {
+	case models.PointSourceBalance:
+		return f.BalanceWeight
+	case models.PointSourceLP:
+		return f.LPWeight
+	case models.PointSourceNFT:
+		return f.NFTWeight
+	}
+	return 0
+}
+
+// Weights returns the weight of each value carrying point event source
+func (f Formula) Weights() map[models.PointSource]float64 {
+	return map[models.PointSource]float64{
+		models.PointSourceBalance: f.BalanceWeight,
+		models.PointSourceLP:      f.LPWeight,
+		models.PointSourceNFT:     f.NFTWeight,
+	}
+}
+
+// DailyPoints returns the points a vault earns for the weighted value it accrued during a job
+func (f Formula) DailyPoints(value float64) float64 {
+	if f.DailyValueCap > 0 && value > f.DailyValueCap {
+		value = f.DailyValueCap
+	}
+	return f.Aggregation.Apply(value)
+}
+
+// ReferralMultiplier is MIN(cap, 1+weight*(LOG(1+referralCount)/LOG(1+500)))
+func (f Formula) ReferralMultiplier(referralCount int64) float64 {
+	multiplier := 1 + f.ReferralWeight*(math.Log(1+float64(referralCount))/math.Log(1+referralBase))
+	if multiplier > f.ReferralMultiplierCap {
+		multiplier = f.ReferralMultiplierCap
+	}
+	return multiplier
+}
+
+// VolumeMultiplier is 1+weight*0.002*SQRT(swapVolume), capped when a cap is set
+func (f Formula) VolumeMultiplier(swapVolume float64) float64 {
+	multiplier := 1 + f.VolumeWeight*volumeFactor*math.Sqrt(swapVolume)
+	if f.VolumeMultiplierCap > 0 && multiplier > f.VolumeMultiplierCap {
+		multiplier = f.VolumeMultiplierCap
+	}
+	return multiplier
+}
+
+// SeasonPoints returns the season points of a vault from its accumulated points, referrals and swap volume
+func (f Formula) SeasonPoints(totalPoints float64, referralCount int64, swapVolume float64) float64 {
+	return totalPoints * f.ReferralMultiplier(referralCount) * f.VolumeMultiplier(swapVolume)
+}
+
+// UnlockMilestones returns the id of the next locked milestone and the prizes of the milestones a vault
+// with totalPoints unlocks, starting at nextMilestoneID
+func (f Formula) UnlockMilestones(totalPoints float64, nextMilestoneID int) (int, float64) {
+	var prize float64
+	for i := nextMilestoneID; i < len(f.Milestones); i++ {
+		if totalPoints >= float64(f.Milestones[i].Minimum) {
+			nextMilestoneID = i + 1
+			prize += float64(f.Milestones[i].Prize)
+		}
+	}
+	return nextMilestoneID, prize
+}
+
+// TokenMultiplier returns the boost of the coin in the season, 1 when it is not boosted
+func (f Formula) TokenMultiplier(coin models.CoinDBModel) float64 {
+	for _, token := range f.Tokens {
+		if token.Chain == coin.Chain.String() && token.Name == coin.Ticker && coin.ContractAddress == token.ContractAddress {
+			return token.Multiplier
+		}
+	}
+	return f.assetMultiplier(coin)
+}
+
+// NFTMultiplier returns the boost of the nft collection in the season, 1 when it is not boosted
+func (f Formula) NFTMultiplier(coin models.CoinDBModel) float64 {
+	for _, collection := range f.NFTs {
+		if collection.Chain == coin.Chain.String() && collection.ContractAddress == coin.ContractAddress {
+			return collection.Multiplier
+		}
+	}
+	return f.assetMultiplier(coin)
+}
+
+// assetMultiplier returns the multiplier of the coin when it is a boosted tracked asset, 1 otherwise
+func (f Formula) assetMultiplier(coin models.CoinDBModel) float64 {
+	if multiplier, ok := f.Assets.Multiplier(coin.Chain, coin.ContractAddress); ok {
+		return multiplier
+	}
+	return 1
+}
@@ -0,0 +1,136 @@
+package scoring
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+func defaultFormula(t *testing.T) Formula {
+	formula, err := NewFormula(config.AirdropSeason{})
+	assert.NoError(t, err)
+	return formula
+}
+
+func TestAggregation(t *testing.T) {
+	assert.Equal(t, float64(3), AggregationSqrt.Apply(9))
+	assert.Equal(t, float64(9), AggregationLinear.Apply(9))
+	assert.InDelta(t, math.Log(10), AggregationLog.Apply(9), 1e-9)
+	assert.Equal(t, float64(0), AggregationSqrt.Apply(-1))
+	aggregation, err := ParseAggregation("")
+	assert.NoError(t, err)
+	assert.Equal(t, AggregationSqrt, aggregation)
+	_, err = ParseAggregation("cube")
+	assert.Error(t, err)
+}
+
+func TestReferralMultiplier(t *testing.T) {
+	formula := defaultFormula(t)
+	testCases := []struct {
+		input          int64
+		expectedOutput float64
+	}{
+		{0, 1},
+		{1, 1.1114992922647913},
+		{10, 1.3857241771164996},
+		{500, 2},
+	}
+	for _, tc := range testCases {
+		result := formula.ReferralMultiplier(tc.input)
+		if result != tc.expectedOutput {
+			t.Errorf("Expected %f for input %d, but got %f", tc.expectedOutput, tc.input, result)
+		}
+	}
+}
+
+func TestVolumeMultiplier(t *testing.T) {
+	formula := defaultFormula(t)
+	testCases := []struct {
+		input          float64
+		expectedOutput float64
+	}{
+		{0, 1},
+		{400, 1.04},
+		{900, 1.06},
+		{1600, 1.08},
+		{2500, 1.1},
+		{1000000, 3},
+	}
+	for _, tc := range testCases {
+		result := formula.VolumeMultiplier(tc.input)
+		if result != tc.expectedOutput {
+			t.Errorf("Expected %f for input %f, but got %f", tc.expectedOutput, tc.input, result)
+		}
+	}
+}
+
+func TestConfiguredFormula(t *testing.T) {
+	half := 0.5
+	zero := float64(0)
+	referralCap := 1.5
+	formula, err := NewFormula(config.AirdropSeason{
+		ID: 2,
+		Formula: config.Formula{
+			Aggregation: "log",
+			Weights:     config.FormulaWeights{LP: &half, NFT: &zero, Volume: &half},
+			Caps:        config.FormulaCaps{DailyValue: 1000, ReferralMultiplier: &referralCap, VolumeMultiplier: 1.5},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), formula.Weight(models.PointSourceBalance))
+	assert.Equal(t, 0.5, formula.Weight(models.PointSourceLP))
+	assert.Equal(t, float64(0), formula.Weight(models.PointSourceNFT))
+	assert.Equal(t, float64(0), formula.Weight(models.PointSourceVolume))
+	assert.InDelta(t, math.Log1p(1000), formula.DailyPoints(5000), 1e-9)
+	assert.Equal(t, 1.5, formula.ReferralMultiplier(500))
+	assert.InDelta(t, 1.02, formula.VolumeMultiplier(400), 1e-9)
+	assert.Equal(t, 1.5, formula.VolumeMultiplier(1000000))
+
+	_, err = NewFormula(config.AirdropSeason{Formula: config.Formula{Aggregation: "cube"}})
+	assert.Error(t, err)
+}
+
+func TestUnlockMilestones(t *testing.T) {
+	formula, err := NewFormula(config.AirdropSeason{
+		Milestones: []config.Milestone{{Minimum: 100, Prize: 10}, {Minimum: 200, Prize: 20}, {Minimum: 300, Prize: 30}},
+	})
+	assert.NoError(t, err)
+	next, prize := formula.UnlockMilestones(250, 0)
+	assert.Equal(t, 2, next)
+	assert.Equal(t, float64(30), prize)
+	next, prize = formula.UnlockMilestones(250, 2)
+	assert.Equal(t, 2, next)
+	assert.Equal(t, float64(0), prize)
+}
+
+func TestTokenMultiplier(t *testing.T) {
+	formula, err := NewFormula(config.AirdropSeason{
+		Tokens: []config.Token{{Chain: common.Ethereum.String(), Name: "VULT", ContractAddress: "0xb788144df611029c60b859df47e79b7726c4deba", Multiplier: 3}},
+		NFTs:   []config.NFT{{Token: config.Token{Chain: common.Ethereum.String(), ContractAddress: "0xa98b29a8f5a247802149c268ecf860b8308b7291", Multiplier: 2}}},
+	})
+	assert.NoError(t, err)
+	vult := models.CoinDBModel{CoinBase: models.CoinBase{Chain: common.Ethereum, Ticker: "VULT", ContractAddress: "0xb788144df611029c60b859df47e79b7726c4deba"}}
+	eth := models.CoinDBModel{CoinBase: models.CoinBase{Chain: common.Ethereum, Ticker: "ETH"}}
+	nft := models.CoinDBModel{CoinBase: models.CoinBase{Chain: common.Ethereum, ContractAddress: "0xa98b29a8f5a247802149c268ecf860b8308b7291"}}
+	assert.Equal(t, float64(3), formula.TokenMultiplier(vult))
+	assert.Equal(t, float64(1), formula.TokenMultiplier(eth))
+	assert.Equal(t, float64(2), formula.NFTMultiplier(nft))
+
+	formula.Assets = models.NewAssetRegistry([]models.TrackedAsset{
+		{Chain: common.Ethereum, ContractAddress: "0xB788144DF611029C60B859DF47E79B7726C4DEBA", Enabled: true, Boosted: true, Multiplier: 5},
+		{Chain: common.Ethereum, ContractAddress: "0xdac17f958d2ee523a2206206994597c13d831ec7", Enabled: true, Boosted: true, Multiplier: 1.5},
+		{Chain: common.Ethereum, ContractAddress: "0x514910771af9ca656af840dff83e8264ecf986ca", Enabled: false, Boosted: true, Multiplier: 4},
+	})
+	usdt := models.CoinDBModel{CoinBase: models.CoinBase{Chain: common.Ethereum, Ticker: "USDT", ContractAddress: "0xdAC17F958D2ee523a2206206994597C13D831ec7"}}
+	link := models.CoinDBModel{CoinBase: models.CoinBase{Chain: common.Ethereum, Ticker: "LINK", ContractAddress: "0x514910771af9ca656af840dff83e8264ecf986ca"}}
+	// the season multiplier wins over the one of the tracked asset
+	assert.Equal(t, float64(3), formula.TokenMultiplier(vult))
+	assert.Equal(t, 1.5, formula.TokenMultiplier(usdt))
+	assert.Equal(t, float64(1), formula.TokenMultiplier(link))
+	assert.Equal(t, float64(1), formula.TokenMultiplier(eth))
+}
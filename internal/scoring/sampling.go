@@ -0,0 +1,63 @@
+package scoring
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SampleAggregation turns the balance samples of a coin taken since the previous job into the balance
+// the job credits
+type SampleAggregation string
+
+const (
+	SampleTWAP SampleAggregation = "twap"
+	SampleMin  SampleAggregation = "min"
+)
+
+func ParseSampleAggregation(s string) (SampleAggregation, error) {
+	switch SampleAggregation(s) {
+	case "":
+		return SampleTWAP, nil
+	case SampleTWAP, SampleMin:
+		return SampleAggregation(s), nil
+	}
+	return "", fmt.Errorf("unknown sample aggregation %s", s)
+}
+
+// Sample is a balance read at a point in time
+type Sample struct {
+	At      time.Time
+	Balance float64
+}
+
+// Aggregate returns the balance credited for the samples, the read of the job being the latest one.
+// The time weighted average holds every balance until the next sample, so the latest read only
+// counts on its own when no earlier sample was taken.
+func (a SampleAggregation) Aggregate(samples []Sample) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := make([]Sample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].At.Before(sorted[j].At)
+	})
+	if a == SampleMin {
+		balance := sorted[0].Balance
+		for _, sample := range sorted[1:] {
+			balance = min(balance, sample.Balance)
+		}
+		return balance
+	}
+	last := sorted[len(sorted)-1]
+	total := last.At.Sub(sorted[0].At)
+	if total <= 0 {
+		return last.Balance
+	}
+	weighted := 0.0
+	for i := 0; i < len(sorted)-1; i++ {
+		weighted += sorted[i].Balance * float64(sorted[i+1].At.Sub(sorted[i].At))
+	}
+	return weighted / float64(total)
+}
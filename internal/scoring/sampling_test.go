@@ -0,0 +1,47 @@
+package scoring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/config"
+)
+
+func TestSampleAggregation(t *testing.T) {
+	start := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	// funds parked for an hour before the read of the job
+	samples := []Sample{
+		{At: start.Add(23 * time.Hour), Balance: 1000},
+		{At: start, Balance: 10},
+		{At: start.Add(6 * time.Hour), Balance: 20},
+		{At: start.Add(24 * time.Hour), Balance: 1000},
+	}
+	// 10 for 6 hours, 20 for 17 hours and 1000 for an hour
+	assert.InDelta(t, float64(60+340+1000)/24, SampleTWAP.Aggregate(samples), 1e-9)
+	assert.Equal(t, float64(10), SampleMin.Aggregate(samples))
+	assert.Equal(t, start.Add(23*time.Hour), samples[0].At, "samples are not reordered")
+
+	// the read of the job counts on its own without samples
+	assert.Equal(t, float64(5), SampleTWAP.Aggregate([]Sample{{At: start, Balance: 5}}))
+	assert.Equal(t, float64(0), SampleTWAP.Aggregate(nil))
+
+	aggregation, err := ParseSampleAggregation("")
+	assert.NoError(t, err)
+	assert.Equal(t, SampleTWAP, aggregation)
+	_, err = ParseSampleAggregation("max")
+	assert.Error(t, err)
+}
+
+func TestSamplingFormula(t *testing.T) {
+	formula, err := NewFormula(config.AirdropSeason{Sampling: config.Sampling{Samples: 4, Aggregation: "min"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, formula.Samples)
+	assert.Equal(t, SampleMin, formula.SampleAggregation)
+	assert.Equal(t, 0, defaultFormula(t).Samples)
+	_, err = NewFormula(config.AirdropSeason{Sampling: config.Sampling{Aggregation: "avg"}})
+	assert.Error(t, err)
+	_, err = NewFormula(config.AirdropSeason{Sampling: config.Sampling{Samples: -1}})
+	assert.Error(t, err)
+}
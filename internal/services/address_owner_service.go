@@ -0,0 +1,153 @@
+package services
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// ClaimAddresses records the vault as deriving the addresses and resolves their owners again. An address
+// derived by several vaults is credited to the first registered of them that joined the airdrop, and its
+// vaults are recorded as colliding. It returns the addresses credited to other vaults with their owner.
+func (s *Storage) ClaimAddresses(vault *models.Vault, addresses map[common.Chain]string) (map[models.AddressKey]uint, error) {
+	foreign := make(map[models.AddressKey]uint)
+	if len(addresses) == 0 {
+		return foreign, nil
+	}
+	keys := make([]models.AddressKey, 0, len(addresses))
+	for chain, address := range addresses {
+		keys = append(keys, models.AddressKey{Chain: chain, Address: address})
+	}
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	// the lock serializes the vaults claiming the same addresses
+	if _, err := getAddressOwners(tx.Clauses(clause.Locking{Strength: "UPDATE"}), keys); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	claimants, err := getAddressClaimants(tx, keys)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	owners := make([]models.AddressOwner, len(keys))
+	var collisions []models.AddressCollision
+	for i, key := range keys {
+		others := make([]models.AddressClaimant, 0, len(claimants[key])+1)
+		for _, claimant := range claimants[key] {
+			if claimant.VaultID != vault.ID {
+				others = append(others, claimant)
+			}
+		}
+		owner := models.SelectAddressOwner(append(others, models.AddressClaimant{VaultID: vault.ID, JoinAirdrop: vault.JoinAirdrop}))
+		owners[i] = models.AddressOwner{Chain: key.Chain, Address: key.Address, VaultID: owner}
+		if len(others) == 0 {
+			continue
+		}
+		collisions = append(collisions, models.AddressCollision{Chain: key.Chain, Address: key.Address, VaultID: vault.ID})
+		for _, claimant := range others {
+			collisions = append(collisions, models.AddressCollision{Chain: key.Chain, Address: key.Address, VaultID: claimant.VaultID})
+		}
+		if owner != vault.ID {
+			foreign[key] = owner
+		}
+	}
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "chain"}, {Name: "address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"vault_id"}),
+	}).Create(&owners).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to claim addresses of vault %d: %w", vault.ID, err)
+	}
+	if len(collisions) > 0 {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&collisions).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to record address collisions of vault %d: %w", vault.ID, err)
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit addresses of vault %d: %w", vault.ID, err)
+	}
+	return foreign, nil
+}
+
+// getAddressClaimants returns the registered vaults deriving each of the addresses
+func getAddressClaimants(db *gorm.DB, keys []models.AddressKey) (map[models.AddressKey][]models.AddressClaimant, error) {
+	pairs := make([][]interface{}, len(keys))
+	for i, key := range keys {
+		pairs[i] = []interface{}{key.Chain, key.Address}
+	}
+	var rows []struct {
+		Chain       common.Chain
+		Address     string
+		VaultID     uint
+		JoinAirdrop bool
+	}
+	err := db.Table("vault_addresses").
+		Select("vault_addresses.chain, vault_addresses.address, vault_addresses.vault_id, vaults.join_airdrop").
+		Joins("JOIN vaults ON vaults.id = vault_addresses.vault_id AND vaults.deleted_at IS NULL").
+		Where("(vault_addresses.chain, vault_addresses.address) IN ?", pairs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vaults deriving addresses: %w", err)
+	}
+	claimants := make(map[models.AddressKey][]models.AddressClaimant, len(keys))
+	for _, row := range rows {
+		key := models.AddressKey{Chain: row.Chain, Address: row.Address}
+		claimants[key] = append(claimants[key], models.AddressClaimant{VaultID: row.VaultID, JoinAirdrop: row.JoinAirdrop})
+	}
+	return claimants, nil
+}
+
+// GetAddressOwners returns the vault credited for each of the addresses, addresses no vault claimed are left out
+func (s *Storage) GetAddressOwners(keys []models.AddressKey) (map[models.AddressKey]uint, error) {
+	return getAddressOwners(s.db, keys)
+}
+
+func getAddressOwners(db *gorm.DB, keys []models.AddressKey) (map[models.AddressKey]uint, error) {
+	owners := make(map[models.AddressKey]uint, len(keys))
+	if len(keys) == 0 {
+		return owners, nil
+	}
+	pairs := make([][]interface{}, len(keys))
+	for i, key := range keys {
+		pairs[i] = []interface{}{key.Chain, key.Address}
+	}
+	var rows []models.AddressOwner
+	if err := db.Where("(chain, address) IN ?", pairs).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get address owners: %w", err)
+	}
+	for _, row := range rows {
+		owners[models.AddressKey{Chain: row.Chain, Address: row.Address}] = row.VaultID
+	}
+	return owners, nil
+}
+
+// GetAddressCollisions reports the addresses derived by several vaults
+func (s *Storage) GetAddressCollisions() ([]models.AddressCollisionReport, error) {
+	var collisions []models.AddressCollision
+	if err := s.db.Order("id").Find(&collisions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get address collisions: %w", err)
+	}
+	var keys []models.AddressKey
+	seen := make(map[models.AddressKey]bool)
+	for _, collision := range collisions {
+		key := models.AddressKey{Chain: collision.Chain, Address: collision.Address}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	owners, err := s.GetAddressOwners(keys)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewAddressCollisionReports(collisions, owners), nil
+}
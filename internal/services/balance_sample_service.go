@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// CreateSampleRounds stores the planned rounds of a day, rounds another instance planned first are kept
+func (s *Storage) CreateSampleRounds(rounds []models.SampleRound) error {
+	if len(rounds) == 0 {
+		return nil
+	}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rounds).Error; err != nil {
+		return fmt.Errorf("failed to create sample rounds: %w", err)
+	}
+	return nil
+}
+
+// ClaimSampleRound hands the earliest unclaimed round due within maxDelay before now to owner, it
+// returns nil when no round is due. Rounds missed for longer are skipped.
+func (s *Storage) ClaimSampleRound(owner string, now time.Time, maxDelay time.Duration) (*models.SampleRound, error) {
+	for {
+		var round models.SampleRound
+		err := s.db.Where("claimed_by = '' AND sample_at <= ? AND sample_at > ?", now, now.Add(-maxDelay)).
+			Order("sample_at").
+			First(&round).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to get sample round: %w", err)
+		}
+		result := s.db.Model(&models.SampleRound{}).Where("id = ? AND claimed_by = ''", round.ID).Update("claimed_by", owner)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to claim sample round %d: %w", round.ID, result.Error)
+		}
+		if result.RowsAffected == 1 {
+			round.ClaimedBy = owner
+			return &round, nil
+		}
+		// another instance claimed it first
+	}
+}
+
+func (s *Storage) CompleteSampleRound(id uint, completedAt time.Time) error {
+	if err := s.db.Model(&models.SampleRound{}).Where("id = ?", id).Update("completed_at", completedAt).Error; err != nil {
+		return fmt.Errorf("failed to complete sample round %d: %w", id, err)
+	}
+	return nil
+}
+
+func (s *Storage) AddBalanceSamples(samples []models.BalanceSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	if err := s.db.CreateInBatches(&samples, 500).Error; err != nil {
+		return fmt.Errorf("failed to add balance samples: %w", err)
+	}
+	return nil
+}
+
+// GetBalanceSamples returns the samples of the coins taken within [from, to] by coin id, oldest first
+func (s *Storage) GetBalanceSamples(coinIDs []uint, from, to time.Time) (map[uint][]models.BalanceSample, error) {
+	samples := make(map[uint][]models.BalanceSample)
+	if len(coinIDs) == 0 {
+		return samples, nil
+	}
+	var rows []models.BalanceSample
+	if err := s.db.Where("coin_id IN ? AND sampled_at >= ? AND sampled_at <= ?", coinIDs, from, to).Order("sampled_at").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get balance samples: %w", err)
+	}
+	for _, row := range rows {
+		samples[row.CoinID] = append(samples[row.CoinID], row)
+	}
+	return samples, nil
+}
+
+// DeleteSamplesBefore removes the balance samples and sample rounds older than the given time
+func (s *Storage) DeleteSamplesBefore(before time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	if err := s.db.WithContext(ctx).Where("sampled_at < ?", before).Delete(&models.BalanceSample{}).Error; err != nil {
+		return fmt.Errorf("failed to delete balance samples: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Where("day < ?", before).Delete(&models.SampleRound{}).Error; err != nil {
+		return fmt.Errorf("failed to delete sample rounds: %w", err)
+	}
+	return nil
+}
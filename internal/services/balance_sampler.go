@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/scoring"
+)
+
+const (
+	// sample rounds not started within this delay are skipped, their time is no longer random
+	maxSampleDelay = time.Hour
+	// samples are kept for jobs that run late
+	sampleRetention = 30 * 24 * time.Hour
+)
+
+// sampler samples the balances of all coins at the randomized rounds of the current season
+func (p *PointWorker) sampler() {
+	p.logger.Info("start balance sampler")
+	defer p.logger.Info("balance sampler stopped")
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.stopChan:
+			return
+		case <-time.After(time.Minute):
+			if err := p.sampleBalances(); err != nil {
+				p.logger.Errorf("failed to sample balances: %v", err)
+				p.countError("sample")
+			}
+		}
+	}
+}
+
+func (p *PointWorker) sampleBalances() error {
+	formula, err := p.formula()
+	if err != nil {
+		return err
+	}
+	if formula.Samples == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	day := now.Truncate(24 * time.Hour)
+	if !p.sampleDay.Equal(day) {
+		if err := p.storage.CreateSampleRounds(models.PlanSampleRounds(day, formula.Samples, rand.N[time.Duration])); err != nil {
+			return err
+		}
+		if err := p.storage.DeleteSamplesBefore(day.Add(-sampleRetention)); err != nil {
+			return err
+		}
+		p.sampleDay = day
+	}
+	round, err := p.storage.ClaimSampleRound(p.instanceID, now, maxSampleDelay)
+	if err != nil || round == nil {
+		return err
+	}
+	p.logger.Infof("instance %s sampling round %d of %s", p.instanceID, round.Round, round.Day.Format("2006-01-02"))
+	if err := p.refreshAssets(); err != nil {
+		p.logger.Errorf("failed to refresh tracked assets: %v", err)
+		p.countError("assets")
+	}
+	p.sampleRound()
+	return p.storage.CompleteSampleRound(round.ID, time.Now())
+}
+
+// sampleRound stores the current balance of every coin, coins whose balance fails to fetch are skipped
+func (p *PointWorker) sampleRound() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-p.stopChan:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	// cosmos addresses are fetched once per round
+	resolver := p.balanceResolver.Fork()
+	batchSize := int(max(1, p.cfg.Worker.BalanceBatchSize))
+	batches := make(chan []models.CoinDBModel)
+	workerWg := &sync.WaitGroup{}
+	for i := 0; i < int(max(1, p.cfg.Worker.Concurrency)); i++ {
+		workerWg.Add(1)
+		go func() {
+			defer workerWg.Done()
+			for batch := range batches {
+				var samples []models.BalanceSample
+				for _, result := range resolver.GetBalancesWithRetry(ctx, batch) {
+					if result.Err != nil {
+						p.countError("sample_fetch")
+						continue
+					}
+					samples = append(samples, models.BalanceSample{CoinID: result.Coin.ID, SampledAt: time.Now(), Balance: result.Balance})
+				}
+				if err := p.storage.AddBalanceSamples(samples); err != nil {
+					p.logger.Errorf("failed to store balance samples: %v", err)
+					p.countError("sample")
+				}
+			}
+		}()
+	}
+	defer workerWg.Wait()
+	defer close(batches)
+	currentID := uint64(0)
+	for {
+		if !p.waitIfPaused(nil) || ctx.Err() != nil {
+			return
+		}
+		coins, err := p.storage.GetCoinsWithPage(currentID, 1000)
+		if err != nil {
+			p.logger.Errorf("failed to get coins: %v", err)
+			return
+		}
+		if len(coins) == 0 {
+			return
+		}
+		currentID = uint64(coins[len(coins)-1].ID)
+		for len(coins) > 0 {
+			size := min(batchSize, len(coins))
+			select {
+			case batches <- coins[:size]:
+			case <-ctx.Done():
+				return
+			}
+			coins = coins[size:]
+		}
+	}
+}
+
+// balanceSamples returns the samples of the coins taken since the previous job up to the end of the job's
+// date, nil when the season credits the read of the job only
+func (p *PointWorker) balanceSamples(coins []models.CoinDBModel, job models.Job, formula scoring.Formula) (map[uint][]scoring.Sample, error) {
+	if formula.Samples == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, len(coins))
+	for i, coin := range coins {
+		ids[i] = coin.ID
+	}
+	// a rerun of an earlier day leaves out the samples taken after it
+	to := time.Now()
+	if end := job.JobDate.AddDate(0, 0, 1); end.Before(to) {
+		to = end
+	}
+	rows, err := p.storage.GetBalanceSamples(ids, job.JobDate.AddDate(0, 0, -int(max(1, job.Multiplier))), to)
+	if err != nil {
+		return nil, err
+	}
+	samples := make(map[uint][]scoring.Sample, len(rows))
+	for coinID, coinRows := range rows {
+		for _, row := range coinRows {
+			samples[coinID] = append(samples[coinID], scoring.Sample{At: row.SampledAt, Balance: row.Balance})
+		}
+	}
+	return samples, nil
+}
@@ -50,21 +50,36 @@ func (s *Storage) GetCoins(vaultID uint) ([]models.CoinDBModel, error) {
 	}
 	return coins, nil
 }
-func (s *Storage) UpdateCoinPrice(chain common.Chain, ticker string, priceUSD float64) error {
-	qry := `UPDATE coins SET price_usd = ? WHERE chain = ? AND ticker = ?`
+
+// GetCoinsByIDs returns the coins with the given ids, including deleted ones
+func (s *Storage) GetCoinsByIDs(ids []uint) ([]models.CoinDBModel, error) {
+	var coins []models.CoinDBModel
+	if len(ids) == 0 {
+		return coins, nil
+	}
+	if err := s.db.Unscoped().Where("id IN ?", ids).Find(&coins).Error; err != nil {
+		return coins, fmt.Errorf("failed to get coins: %w", err)
+	}
+	return coins, nil
+}
+
+// UpdateCoinPrice sets the price of the coins with the ticker on the chain and the source it came from
+func (s *Storage) UpdateCoinPrice(chain common.Chain, ticker string, priceUSD float64, source string) error {
+	qry := `UPDATE coins SET price_usd = ?, price_source = ? WHERE chain = ? AND ticker = ?`
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
-	if err := s.db.WithContext(ctx).Exec(qry, priceUSD, chain.String(), ticker).Error; err != nil {
+	if err := s.db.WithContext(ctx).Exec(qry, priceUSD, source, chain.String(), ticker).Error; err != nil {
 		return fmt.Errorf("failed to update coin price: %w", err)
 	}
 	return nil
 }
 
-func (s *Storage) UpdateCoinPriceByCMCID(cmcID int, priceUSD float64) error {
-	qry := `UPDATE coins SET price_usd = ? WHERE cmc_id = ? `
+// UpdateCoinPriceByCMCID sets the price of the coins with the cmc id and the source it came from
+func (s *Storage) UpdateCoinPriceByCMCID(cmcID int, priceUSD float64, source string) error {
+	qry := `UPDATE coins SET price_usd = ?, price_source = ? WHERE cmc_id = ? `
 	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
-	if err := s.db.WithContext(ctx).Exec(qry, priceUSD, cmcID).Error; err != nil {
+	if err := s.db.WithContext(ctx).Exec(qry, priceUSD, source, cmcID).Error; err != nil {
 		return fmt.Errorf("failed to update coin price: %w", err)
 	}
 	return nil
@@ -92,12 +107,74 @@ func (s *Storage) GetCoinsWithPage(startId, limit uint64) ([]models.CoinDBModel,
 	}
 	return coins, nil
 }
-func (s *Storage) UpdateCoinBalance(coinID uint64, balance float64) error {
-	qry := `UPDATE coins SET balance = ?, usd_value = balance * price_usd  WHERE id = ?`
+
+// UpdateCoinBalance stores the fetched balance of the coin and resets its failure streak
+func (s *Storage) UpdateCoinBalance(coinID uint64, balance float64, fetchedAt time.Time) error {
+	qry := `UPDATE coins SET balance = ?, usd_value = balance * price_usd, last_fetched_at = ?, failure_streak = 0  WHERE id = ?`
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	if err := s.db.WithContext(ctx).Exec(qry, balance, coinID).Error; err != nil {
+	if err := s.db.WithContext(ctx).Exec(qry, balance, fetchedAt, coinID).Error; err != nil {
 		return fmt.Errorf("failed to update coin balance: %w", err)
 	}
 	return nil
 }
+
+// IncrementCoinFailureStreak records a failed balance fetch of the coin
+func (s *Storage) IncrementCoinFailureStreak(coinID uint) error {
+	qry := `UPDATE coins SET failure_streak = failure_streak + 1  WHERE id = ?`
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.db.WithContext(ctx).Exec(qry, coinID).Error; err != nil {
+		return fmt.Errorf("failed to update coin failure streak: %w", err)
+	}
+	return nil
+}
+
+// FixCoins writes the corrected reference data of the coins in a single transaction. A ticker another coin
+// of the address already has on the chain is left unchanged, the ids of those coins are returned.
+func (s *Storage) FixCoins(fixes []models.CoinFix) ([]uint, error) {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	var keptTicker []uint
+	for _, fix := range fixes {
+		updates := make(map[string]any)
+		if fix.CMCId != 0 {
+			updates["cmc_id"] = fix.CMCId
+		}
+		if fix.Decimals != 0 {
+			updates["decimals"] = fix.Decimals
+		}
+		if fix.Logo != "" {
+			updates["logo"] = fix.Logo
+		}
+		if fix.Ticker != "" {
+			var taken int64
+			// soft deleted coins still hold their ticker in the unique index
+			err := tx.Unscoped().Model(&models.CoinDBModel{}).
+				Where("chain = ? AND address = ? AND ticker = ? AND id <> ?", fix.Chain, fix.Address, fix.Ticker, fix.CoinID).
+				Count(&taken).Error
+			if err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("failed to check ticker of coin %d: %w", fix.CoinID, err)
+			}
+			if taken > 0 {
+				keptTicker = append(keptTicker, fix.CoinID)
+			} else {
+				updates["ticker"] = fix.Ticker
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if err := tx.Model(&models.CoinDBModel{}).Where("id = ?", fix.CoinID).Updates(updates).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("failed to fix coin %d: %w", fix.CoinID, err)
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit coin fixes: %w", err)
+	}
+	return keptTicker, nil
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+func (s *Storage) SaveVaultAddresses(addresses []models.DerivedAddress) error {
+	if len(addresses) == 0 {
+		return nil
+	}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&addresses).Error; err != nil {
+		return fmt.Errorf("failed to save vault addresses: %w", err)
+	}
+	return nil
+}
+
+func (s *Storage) GetVaultAddresses(vaultID uint) ([]models.DerivedAddress, error) {
+	var addresses []models.DerivedAddress
+	if err := s.db.Where("vault_id = ?", vaultID).Find(&addresses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get addresses of vault %d: %w", vaultID, err)
+	}
+	return addresses, nil
+}
+
+// EnsureVaultAddresses returns the stored addresses of the vault, deriving and storing the chains it has
+// no address for yet. Chains failing to derive are left out and reported with models.ErrDeriveAddress
+// next to the other addresses.
+func (s *Storage) EnsureVaultAddresses(vault *models.Vault) ([]models.DerivedAddress, error) {
+	addresses, err := s.GetVaultAddresses(vault.ID)
+	if err != nil {
+		return nil, err
+	}
+	missing := models.MissingChains(addresses)
+	if len(missing) == 0 {
+		return addresses, nil
+	}
+	derived, deriveErr := vault.DeriveAddresses(missing)
+	if err := s.SaveVaultAddresses(derived); err != nil {
+		return nil, err
+	}
+	return append(addresses, derived...), deriveErr
+}
+
+// GetAddressesOnChain returns a page of the stored addresses on the chain with an id above fromID
+func (s *Storage) GetAddressesOnChain(chain common.Chain, fromID uint, limit int) ([]models.DerivedAddress, error) {
+	var addresses []models.DerivedAddress
+	if err := s.db.Where("chain = ? AND id > ?", chain, fromID).Order("id").Limit(limit).Find(&addresses).Error; err != nil {
+		return nil, fmt.Errorf("failed to get addresses on chain %s: %w", chain, err)
+	}
+	return addresses, nil
+}
+
+// LookupAddresses returns the vault of each address that belongs to a vault that joined the airdrop, the
+// lowest vault id when several do. Addresses are keyed in lower case, the case insensitive collation of
+// the column matches them in any case.
+func (s *Storage) LookupAddresses(keys []models.AddressKey) (map[models.AddressKey]uint, error) {
+	vaults := make(map[models.AddressKey]uint, len(keys))
+	if len(keys) == 0 {
+		return vaults, nil
+	}
+	pairs := make([][]interface{}, len(keys))
+	for i, key := range keys {
+		pairs[i] = []interface{}{key.Chain, strings.ToLower(key.Address)}
+	}
+	var rows []models.DerivedAddress
+	err := s.db.Table("vault_addresses").
+		Select("vault_addresses.chain, LOWER(vault_addresses.address) AS address, MIN(vault_addresses.vault_id) AS vault_id").
+		Joins("JOIN vaults ON vaults.id = vault_addresses.vault_id AND vaults.deleted_at IS NULL").
+		Where("vaults.join_airdrop = ? AND (vault_addresses.chain, vault_addresses.address) IN ?", true, pairs).
+		Group("vault_addresses.chain, LOWER(vault_addresses.address)").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up addresses: %w", err)
+	}
+	for _, row := range rows {
+		vaults[models.AddressKey{Chain: row.Chain, Address: row.Address}] = row.VaultID
+	}
+	return vaults, nil
+}
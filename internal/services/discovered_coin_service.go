@@ -0,0 +1,45 @@
+package services
+
+import (
+	"fmt"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// GetDiscoveredCoins returns the tokens the worker added to the vault, newest first
+func (s *Storage) GetDiscoveredCoins(vaultID uint) ([]models.DiscoveredCoin, error) {
+	var discovered []models.DiscoveredCoin
+	if err := s.db.Where("vault_id = ?", vaultID).Order("id DESC").Find(&discovered).Error; err != nil {
+		return nil, fmt.Errorf("failed to get discovered coins of vault %d: %w", vaultID, err)
+	}
+	return discovered, nil
+}
+
+// AddDiscoveredCoin adds the coin to its vault and records its discovery. It reports false without
+// adding anything when the vault already has a coin with the same ticker on the address.
+func (s *Storage) AddDiscoveredCoin(coin *models.CoinDBModel, discovered *models.DiscoveredCoin) (bool, error) {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return false, fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(coin)
+	if result.Error != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("failed to add discovered coin: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+	discovered.CoinID = coin.ID
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(discovered).Error; err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("failed to record discovered coin: %w", err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return false, fmt.Errorf("failed to commit discovered coin: %w", err)
+	}
+	return true, nil
+}
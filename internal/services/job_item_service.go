@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// CompleteJobItem marks the item as processed by the job and runs fn in the same transaction.
+// When the item has already been processed fn is not called and false is returned.
+func (s *Storage) CompleteJobItem(jobID uint, itemType models.JobItemType, itemID uint, fn func(tx *Storage) error) (bool, error) {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return false, fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	item := models.JobItem{
+		JobID:    jobID,
+		ItemType: itemType,
+		ItemID:   itemID,
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&item)
+	if result.Error != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("failed to mark %s %d processed: %w", itemType, itemID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+	if err := fn(&Storage{db: tx}); err != nil {
+		tx.Rollback()
+		return false, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return false, fmt.Errorf("failed to commit %s %d: %w", itemType, itemID, err)
+	}
+	return true, nil
+}
+
+// GetProcessedJobItems returns the subset of the given ids that the job already processed
+func (s *Storage) GetProcessedJobItems(jobID uint, itemType models.JobItemType, ids []uint) (map[uint]bool, error) {
+	processed := make(map[uint]bool)
+	if len(ids) == 0 {
+		return processed, nil
+	}
+	var itemIDs []uint
+	if err := s.db.Model(&models.JobItem{}).
+		Where("job_id = ? AND item_type = ? AND item_id IN ?", jobID, itemType, ids).
+		Pluck("item_id", &itemIDs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get processed %s items: %w", itemType, err)
+	}
+	for _, id := range itemIDs {
+		processed[id] = true
+	}
+	return processed, nil
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// CreateJobPhases adds the phases of the job and their shards, sharded phases are split into ranges of
+// the given size up to an open ended last shard. Concurrent calls seeing different ids may add overlapping
+// shards, the job items keep their ids from being credited twice.
+func (s *Storage) CreateJobPhases(job *models.Job, size uint) error {
+	var count int64
+	if err := s.db.Model(&models.JobPhase{}).Where("job_id = ?", job.ID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count job phases: %w", err)
+	}
+	if count == int64(len(models.JobPhases)) {
+		return nil
+	}
+	var maxVaultID, maxCoinID uint
+	if err := s.db.Raw("SELECT COALESCE(MAX(id), 0) FROM vaults").Scan(&maxVaultID).Error; err != nil {
+		return fmt.Errorf("failed to get max vault id: %w", err)
+	}
+	if err := s.db.Raw("SELECT COALESCE(MAX(id), 0) FROM coins").Scan(&maxCoinID).Error; err != nil {
+		return fmt.Errorf("failed to get max coin id: %w", err)
+	}
+	var phases []models.JobPhase
+	var shards []models.JobShard
+	for _, phase := range models.JobPhases {
+		phases = append(phases, models.JobPhase{
+			JobID:  job.ID,
+			Phase:  phase,
+			Status: models.JobPhasePending,
+		})
+		switch phase {
+		case models.JobPhaseVaults, models.JobPhaseDiscovery:
+			shards = append(shards, models.NewJobShards(job.ID, phase, maxVaultID, size)...)
+		case models.JobPhaseBalances:
+			shards = append(shards, models.NewJobShards(job.ID, phase, maxCoinID, size)...)
+		default:
+			shards = append(shards, models.JobShard{
+				JobID:  job.ID,
+				Phase:  phase,
+				Status: models.JobShardPending,
+			})
+		}
+	}
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&phases).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to create job phases: %w", err)
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&shards).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to create job shards: %w", err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit job phases: %w", err)
+	}
+	return nil
+}
+
+// GetJobPhases returns the phases of the job in creation order
+func (s *Storage) GetJobPhases(jobID uint) ([]models.JobPhase, error) {
+	var phases []models.JobPhase
+	if err := s.db.Where("job_id = ?", jobID).Order("id").Find(&phases).Error; err != nil {
+		return nil, fmt.Errorf("failed to get job phases: %w", err)
+	}
+	return phases, nil
+}
+
+// GetJobProgress returns the phases of the job and how many of the registered vaults and coins it processed
+func (s *Storage) GetJobProgress(job *models.Job) (models.JobProgress, error) {
+	phases, err := s.GetJobPhases(job.ID)
+	if err != nil {
+		return models.JobProgress{}, err
+	}
+	var counts []struct {
+		ItemType models.JobItemType
+		Count    int64
+	}
+	if err := s.db.Model(&models.JobItem{}).Select("item_type, COUNT(*) AS count").
+		Where("job_id = ?", job.ID).Group("item_type").Scan(&counts).Error; err != nil {
+		return models.JobProgress{}, fmt.Errorf("failed to count job items: %w", err)
+	}
+	processed := make(map[models.JobItemType]int64)
+	for _, count := range counts {
+		processed[count.ItemType] = count.Count
+	}
+	var vaults, coins int64
+	if err := s.db.Model(&models.Vault{}).Count(&vaults).Error; err != nil {
+		return models.JobProgress{}, fmt.Errorf("failed to count vaults: %w", err)
+	}
+	if err := s.db.Model(&models.CoinDBModel{}).Count(&coins).Error; err != nil {
+		return models.JobProgress{}, fmt.Errorf("failed to count coins: %w", err)
+	}
+	return models.NewJobProgress(job, phases, processed, vaults, coins, time.Now()), nil
+}
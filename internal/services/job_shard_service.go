@@ -0,0 +1,143 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// ClaimJobShard leases the next unfinished shard of the job to owner. Only shards of phases whose
+// dependencies have succeeded are handed out, it returns nil when there is nothing to claim or the
+// job was cancelled.
+func (s *Storage) ClaimJobShard(jobID uint, owner string, lease time.Duration) (*models.JobShard, error) {
+	var cancelled int64
+	if err := s.db.Model(&models.Job{}).Where("id = ? AND is_cancelled = ?", jobID, true).Count(&cancelled).Error; err != nil {
+		return nil, fmt.Errorf("failed to check job %d: %w", jobID, err)
+	}
+	if cancelled > 0 {
+		return nil, nil
+	}
+	phases, err := s.GetJobPhases(jobID)
+	if err != nil {
+		return nil, err
+	}
+	ready := models.ReadyJobPhases(phases)
+	if len(ready) == 0 {
+		return nil, nil
+	}
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	var shard models.JobShard
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("job_id = ? AND phase IN ? AND status <> ?", jobID, ready, models.JobShardDone).
+		Where("status = ? OR owner = ? OR lease_expires_at < NOW()", models.JobShardPending, owner).
+		Order("id").
+		First(&shard).Error
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim job shard: %w", err)
+	}
+	if err := tx.Model(&shard).Updates(map[string]interface{}{
+		"status":           models.JobShardRunning,
+		"owner":            owner,
+		"lease_expires_at": gorm.Expr("DATE_ADD(NOW(), INTERVAL ? SECOND)", int64(lease.Seconds())),
+	}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to lease job shard %d: %w", shard.ID, err)
+	}
+	// started_at is assigned first so it still sees the previous status
+	qry := `UPDATE job_phases SET started_at = IF(status = ?, started_at, NOW()), status = ?, finished_at = NULL
+		WHERE job_id = ? AND phase = ?`
+	if err := tx.Exec(qry, models.JobPhaseRunning, models.JobPhaseRunning, jobID, shard.Phase).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to start job phase %s: %w", shard.Phase, err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit job shard %d: %w", shard.ID, err)
+	}
+	shard.Status = models.JobShardRunning
+	shard.Owner = owner
+	return &shard, nil
+}
+
+// RenewJobShardLease extends the lease of the shard, it returns false when the owner no longer holds it
+// or the job was cancelled
+func (s *Storage) RenewJobShardLease(shard *models.JobShard, lease time.Duration) (bool, error) {
+	qry := `UPDATE job_shards SET lease_expires_at = DATE_ADD(NOW(), INTERVAL ? SECOND), current_id = ?
+		WHERE id = ? AND owner = ? AND status = ?
+		AND NOT EXISTS (SELECT 1 FROM jobs WHERE jobs.id = job_shards.job_id AND jobs.is_cancelled = ?)`
+	result := s.db.Exec(qry, int64(lease.Seconds()), shard.CurrentID, shard.ID, shard.Owner, models.JobShardRunning, true)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to renew lease of job shard %d: %w", shard.ID, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CompleteJobShard marks the shard done and its phase succeeded once all of the phase's shards are done.
+// Every item of a processed shard has its job item marker, so the shard is done even if its lease was
+// taken over in the meantime.
+func (s *Storage) CompleteJobShard(shard *models.JobShard) error {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	qry := `UPDATE job_shards SET status = ?, current_id = ?, lease_expires_at = NULL WHERE id = ?`
+	if err := tx.Exec(qry, models.JobShardDone, shard.CurrentID, shard.ID).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to complete job shard %d: %w", shard.ID, err)
+	}
+	qry = `UPDATE job_phases SET status = ?, finished_at = NOW(), error = ''
+		WHERE job_id = ? AND phase = ?
+		AND NOT EXISTS (SELECT 1 FROM job_shards WHERE job_id = ? AND phase = ? AND status <> ? AND deleted_at IS NULL)`
+	if err := tx.Exec(qry, models.JobPhaseSucceeded, shard.JobID, shard.Phase, shard.JobID, shard.Phase, models.JobShardDone).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to complete job phase %s: %w", shard.Phase, err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit job shard %d: %w", shard.ID, err)
+	}
+	shard.Status = models.JobShardDone
+	return nil
+}
+
+// FailJobShard releases the shard so it is retried and records the error on its phase
+func (s *Storage) FailJobShard(shard *models.JobShard, cause error) error {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	qry := `UPDATE job_shards SET status = ?, owner = '', current_id = ?, lease_expires_at = NULL WHERE id = ? AND owner = ?`
+	if err := tx.Exec(qry, models.JobShardPending, shard.CurrentID, shard.ID, shard.Owner).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to release job shard %d: %w", shard.ID, err)
+	}
+	qry = `UPDATE job_phases SET status = ?, finished_at = NOW(), error = ? WHERE job_id = ? AND phase = ?`
+	if err := tx.Exec(qry, models.JobPhaseFailed, cause.Error(), shard.JobID, shard.Phase).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to fail job phase %s: %w", shard.Phase, err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit job shard %d: %w", shard.ID, err)
+	}
+	shard.Status = models.JobShardPending
+	return nil
+}
+
+// GetJobShards returns all shards of the job
+func (s *Storage) GetJobShards(jobID uint) ([]models.JobShard, error) {
+	var shards []models.JobShard
+	if err := s.db.Where("job_id = ?", jobID).Order("id").Find(&shards).Error; err != nil {
+		return nil, fmt.Errorf("failed to get job shards: %w", err)
+	}
+	return shards, nil
+}
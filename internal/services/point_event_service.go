@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// AddPointEvent appends the given event to the points ledger, an event already recorded for the same
+// (job, vault, source, coin) is left untouched
+func (s *Storage) AddPointEvent(event *models.PointEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(event).Error; err != nil {
+		return fmt.Errorf("failed to add point event: %w", err)
+	}
+	return nil
+}
+
+// GetPointEvents returns the latest point events of the given vault, newest first
+func (s *Storage) GetPointEvents(vaultID uint, limit int) ([]models.PointEvent, error) {
+	var events []models.PointEvent
+	if err := s.db.Where("vault_id = ?", vaultID).Order("job_id desc, id asc").Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to get point events for vault %d: %w", vaultID, err)
+	}
+	return events, nil
+}
+
+// ApplyPointEvents adds the ledger total of each vault for the given job, weighted by source, to its
+// total_vault_value. It runs once per job, calling it again for an applied job is a no-op.
+func (s *Storage) ApplyPointEvents(job *models.Job, weights map[models.PointSource]float64) error {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	result := tx.Exec(`UPDATE jobs SET points_applied = true WHERE id = ? AND points_applied = false`, job.ID)
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to mark job points applied: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		job.PointsApplied = true
+		return nil
+	}
+	weight := "CASE source"
+	var args []interface{}
+	for source, w := range weights {
+		weight += " WHEN ? THEN ?"
+		args = append(args, source, w)
+	}
+	weight += " ELSE 0 END"
+	qry := `UPDATE vaults
+		JOIN (
+			SELECT vault_id, SUM(value * ` + weight + `) AS total_value
+			FROM point_events
+			WHERE job_id = ? AND deleted_at IS NULL
+			GROUP BY vault_id
+		) AS job_events ON vaults.id = job_events.vault_id
+		SET vaults.total_vault_value = vaults.total_vault_value + job_events.total_value`
+	if err := tx.Exec(qry, append(args, job.ID)...).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to apply point events: %w", err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit point events: %w", err)
+	}
+	job.PointsApplied = true
+	return nil
+}
+
+// GetPointEventsByJob returns a page of the events recorded by the job ordered by id
+func (s *Storage) GetPointEventsByJob(jobID uint, startID uint, limit int) ([]models.PointEvent, error) {
+	var events []models.PointEvent
+	if err := s.db.Where("job_id = ? AND id > ?", jobID, startID).Order("id").Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to get point events for job %d: %w", jobID, err)
+	}
+	return events, nil
+}
+
+// GetVaultPointEventsByJobs returns the events the jobs recorded for the vault
+func (s *Storage) GetVaultPointEventsByJobs(vaultID uint, jobIDs []uint) ([]models.PointEvent, error) {
+	var events []models.PointEvent
+	if len(jobIDs) == 0 {
+		return events, nil
+	}
+	if err := s.db.Where("vault_id = ? AND job_id IN ?", vaultID, jobIDs).Order("id").Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to get point events for vault %d: %w", vaultID, err)
+	}
+	return events, nil
+}
+
+// GetJobsWithoutPointEvents returns the ids of the jobs that recorded no point event, such as jobs run
+// before the ledger
+func (s *Storage) GetJobsWithoutPointEvents(jobIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(jobIDs) == 0 {
+		return ids, nil
+	}
+	qry := `SELECT id FROM jobs WHERE id IN ? AND NOT EXISTS
+		(SELECT 1 FROM point_events WHERE point_events.job_id = jobs.id AND point_events.deleted_at IS NULL)`
+	if err := s.db.Raw(qry, jobIDs).Scan(&ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get jobs without point events: %w", err)
+	}
+	return ids, nil
+}
+
+// GetSuccessfulJobs returns the finished jobs dated within [from, to), oldest first
+func (s *Storage) GetSuccessfulJobs(from, to time.Time) ([]models.Job, error) {
+	var jobs []models.Job
+	if err := s.db.Where("is_success = ? AND job_date >= ? AND job_date < ?", true, from, to).Order("job_date").Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+	return jobs, nil
+}
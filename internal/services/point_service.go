@@ -1,11 +1,15 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -16,6 +20,9 @@ import (
 	"github.com/vultisig/airdrop-registry/internal/common"
 	"github.com/vultisig/airdrop-registry/internal/liquidity"
 	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/pricing"
+	"github.com/vultisig/airdrop-registry/internal/scoring"
+	"github.com/vultisig/airdrop-registry/internal/tokens"
 	"github.com/vultisig/airdrop-registry/internal/utils"
 	"github.com/vultisig/airdrop-registry/internal/volume"
 )
@@ -23,24 +30,36 @@ import (
 const MinBalanceForValidReferral = 50 // 50 USDT
 // PointWorker is a worker that processes points
 type PointWorker struct {
-	logger                 *logrus.Logger
-	storage                *Storage
-	priceResolver          *PriceResolver
-	balanceResolver        *balance.BalanceResolver
-	lpResolver             *liquidity.LiquidityPositionResolver
-	saverResolver          *liquidity.SaverPositionResolver
-	referralResolver       *ReferralResolverService
-	volumeResolver         *volume.VolumeResolver
-	startCoinID            int64
-	wg                     *sync.WaitGroup
-	stopChan               chan struct{}
-	cfg                    *config.Config
-	isJobInProgress        bool
-	isVolumeFetched        bool // flag to indicate if volume fetched successfully
-	whitelistNFTCollection []models.NFTCollection
-}
-
-func NewPointWorker(cfg *config.Config, storage *Storage, priceResolver *PriceResolver, balanceResolver *balance.BalanceResolver, volumeResolver *volume.VolumeResolver, referralResolver *ReferralResolverService) (*PointWorker, error) {
+	logger             *logrus.Logger
+	storage            *Storage
+	priceResolver      *PriceResolver
+	balanceResolver    *balance.BalanceResolver
+	oracle             *pricing.Oracle
+	guard              pricing.Guard
+	lpResolver         *liquidity.LiquidityPositionResolver
+	saverResolver      *liquidity.SaverPositionResolver
+	referralResolver   *ReferralResolverService
+	volumeResolver     *volume.VolumeResolver
+	startCoinID        int64
+	wg                 *sync.WaitGroup
+	stopChan           chan struct{}
+	cfg                *config.Config
+	controlMu          sync.Mutex
+	currentJob         *models.Job                                  // job this instance works on, nil when idle
+	jobCancelled       chan struct{}                                // closed when the current job is cancelled
+	resumed            chan struct{}                                // closed on resume, nil while the worker is not paused
+	errorCounts        map[string]int64                             // errors since the instance started by kind
+	instanceID         string                                       // owner of the shards claimed by this instance
+	shardSize          uint                                         // number of ids per shard
+	leaseDuration      time.Duration                                // shards not renewed within this duration can be taken over
+	preparedJobID      uint                                         // job whose bond providers and volume are loaded
+	sampleDay          time.Time                                    // day whose sample rounds this instance planned
+	assets             atomic.Pointer[models.AssetRegistry]         // tracked assets, reloaded for every shard and sample round
+	discoveryServices  map[common.Chain]tokens.AutoDiscoveryService // nil when discovery is disabled
+	discoveryAllowlist map[models.TokenKey]bool
+}
+
+func NewPointWorker(cfg *config.Config, storage *Storage, priceResolver *PriceResolver, balanceResolver *balance.BalanceResolver, volumeResolver *volume.VolumeResolver, referralResolver *ReferralResolverService, discoveryServices map[common.Chain]tokens.AutoDiscoveryService) (*PointWorker, error) {
 
 	if nil == storage {
 		return nil, fmt.Errorf("storage is nil")
@@ -49,32 +68,79 @@ func NewPointWorker(cfg *config.Config, storage *Storage, priceResolver *PriceRe
 		return nil, fmt.Errorf("priceResolver is nil")
 	}
 
-	return &PointWorker{
-		logger:           logrus.WithField("module", "point_worker").Logger,
-		storage:          storage,
-		priceResolver:    priceResolver,
-		balanceResolver:  balanceResolver,
-		lpResolver:       liquidity.NewLiquidtyPositionResolver(),
-		referralResolver: referralResolver,
-		saverResolver:    liquidity.NewSaverPositionResolver(),
-		volumeResolver:   volumeResolver,
-		startCoinID:      cfg.Worker.StartID,
-		stopChan:         make(chan struct{}),
-		wg:               &sync.WaitGroup{},
-		cfg:              cfg,
-		whitelistNFTCollection: []models.NFTCollection{
-			{
-				Chain:             common.Ethereum,
-				CollectionAddress: "0xa98b29a8f5a247802149c268ecf860b8308b7291",
-				CollectionSlug:    "thorguards",
-			},
-		},
-	}, nil
+	instanceID := cfg.Worker.InstanceID
+	if instanceID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname: %w", err)
+		}
+		instanceID = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	shardSize := uint(cfg.Worker.ShardSize)
+	if shardSize == 0 {
+		shardSize = 5000
+	}
+	leaseDuration := time.Duration(cfg.Worker.LeaseSeconds) * time.Second
+	if leaseDuration < 30*time.Second {
+		leaseDuration = 30 * time.Second
+	}
+	discoveryAllowlist, err := parseDiscoveryAllowlist(cfg.Worker.Discovery.Allowlist)
+	if err != nil {
+		return nil, err
+	}
+	oracle, err := pricing.NewOracle(cfg.Pricing, priceResolver.PriceSources()...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pricing config: %w", err)
+	}
+	guard, err := pricing.NewGuard(cfg.Pricing.Guard)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pricing config: %w", err)
+	}
+	priceResolver.SetPriceGuard(guard)
+
+	p := &PointWorker{
+		logger:             logrus.WithField("module", "point_worker").Logger,
+		storage:            storage,
+		priceResolver:      priceResolver,
+		balanceResolver:    balanceResolver,
+		oracle:             oracle,
+		guard:              guard,
+		lpResolver:         liquidity.NewLiquidtyPositionResolver(),
+		referralResolver:   referralResolver,
+		saverResolver:      liquidity.NewSaverPositionResolver(),
+		volumeResolver:     volumeResolver,
+		startCoinID:        cfg.Worker.StartID,
+		stopChan:           make(chan struct{}),
+		wg:                 &sync.WaitGroup{},
+		cfg:                cfg,
+		instanceID:         instanceID,
+		shardSize:          shardSize,
+		leaseDuration:      leaseDuration,
+		errorCounts:        make(map[string]int64),
+		discoveryServices:  discoveryServices,
+		discoveryAllowlist: discoveryAllowlist,
+	}
+	if err := p.refreshAssets(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// refreshAssets reloads the tracked assets and hands them to the balance resolver
+func (p *PointWorker) refreshAssets() error {
+	assets, err := p.storage.GetAssetRegistry()
+	if err != nil {
+		return err
+	}
+	p.assets.Store(assets)
+	p.balanceResolver.SetAssets(assets)
+	return nil
 }
 
 func (p *PointWorker) Run() error {
-	p.wg.Add(1)
+	p.wg.Add(2)
 	go p.scheduler()
+	go p.sampler()
 	return nil
 }
 func (p *PointWorker) scheduler() {
@@ -92,282 +158,609 @@ func (p *PointWorker) scheduler() {
 }
 
 func (p *PointWorker) ensureJobs() {
-	lastJob, err := p.storage.GetLastJob()
+	job, err := p.nextJob(p.storage)
+	if err != nil {
+		p.logger.Errorf("failed to get next job: %v", err)
+		return
+	}
+	if job == nil {
+		return
+	}
+	if err := p.startJob(job); err != nil && !errors.Is(err, ErrJobInProgress) {
+		p.logger.Errorf("failed to start job %d: %v", job.ID, err)
+	}
+}
+
+// jobStore is the part of the storage picking the job of an idle instance
+type jobStore interface {
+	GetLastJob() (*models.Job, error)
+	CreateJob(job *models.Job) error
+	GetUnfinishedJob() (*models.Job, error)
+}
+
+// nextJob creates the job of today once the last job finished a day ago or more, and returns the job an idle
+// instance works on, nil when all jobs finished. The oldest unfinished job goes first, so a rerun of an earlier
+// day is resumed by any instance, also after the instance that started it crashed.
+func (p *PointWorker) nextJob(store jobStore) (*models.Job, error) {
+	lastJob, err := store.GetLastJob()
 	if err != nil {
 		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			p.logger.Errorf("failed to get last job: %v", err)
-			return
+			return nil, fmt.Errorf("failed to get last job: %w", err)
 		}
 		// create a job for today
-		lastJob = &models.Job{
+		job := &models.Job{
 			JobDate:    time.Now(),
 			Multiplier: 1,
 			IsSuccess:  false,
 		}
-		if err := p.storage.CreateJob(lastJob); err != nil {
-			p.logger.Errorf("failed to create job: %v", err)
-			return
+		if err := store.CreateJob(job); err != nil {
+			// another instance might have created it first
+			p.logger.Warnf("failed to create job: %v", err)
+		}
+	} else if lastJob.IsSuccess || lastJob.IsCancelled {
+		// the last job has finished, a new one starts 24 hours after it
+		if multiplier := lastJob.DaysSince(); multiplier >= 1 {
+			job := &models.Job{
+				JobDate:    time.Now(),
+				Multiplier: multiplier,
+				IsSuccess:  false,
+			}
+			if err := store.CreateJob(job); err != nil {
+				// another instance might have created it first
+				p.logger.Warnf("failed to create job: %v", err)
+			}
 		}
 	}
+	return store.GetUnfinishedJob()
+}
 
-	lastJob, err = p.storage.GetLastJob()
-	if err != nil {
-		p.logger.Errorf("failed to get last job: %v", err)
-		return
-	}
-
-	if !lastJob.IsSuccess {
-		p.startJob(lastJob)
-		return
-	}
-
-	multiplier := lastJob.DaysSince()
-	if multiplier < 1 {
-		// last job has been finished , but not 24 hours yet
-		return
+// startJob works on the job until none of its shards is ready, unless the instance already works on a job
+func (p *PointWorker) startJob(job *models.Job) error {
+	p.controlMu.Lock()
+	defer p.controlMu.Unlock()
+	if p.currentJob != nil {
+		return ErrJobInProgress
 	}
-	newJob := &models.Job{
-		JobDate:    time.Now(),
-		Multiplier: multiplier,
-		IsSuccess:  false,
+	if err := p.storage.CreateJobPhases(job, p.shardSize); err != nil {
+		return fmt.Errorf("failed to create phases for job %d: %w", job.ID, err)
 	}
-	if err := p.storage.CreateJob(newJob); err != nil {
-		p.logger.Errorf("failed to create job: %v", err)
-		return
-	}
-	p.startJob(newJob)
+	p.logger.Infof("instance %s working on job %s", p.instanceID, job.JobDate.Format("2006-01-02"))
+	p.currentJob = job
+	p.jobCancelled = make(chan struct{})
+	p.wg.Add(1)
+	go p.taskProvider(job, p.jobCancelled)
+	return nil
 }
 
-func (p *PointWorker) startJob(job *models.Job) {
-	if p.isJobInProgress {
-		return
+// prepareJob loads the bond providers and swap volume the vaults and balances phases need,
+// once per job on each instance. It fails when the volume cannot be loaded, so the shard is
+// handed back instead of crediting the vaults no swap volume.
+func (p *PointWorker) prepareJob(ctx context.Context, job *models.Job) error {
+	if p.preparedJobID == job.ID {
+		return nil
 	}
-	if job.CurrentID == 0 {
-		p.logger.Infof("start job %s", job.JobDate.Format("2006-01-02"))
-	} else {
-		p.logger.Infof("continue job %s from %d", job.JobDate.Format("2006-01-02"), job.CurrentID)
+	// cosmos addresses are fetched once per job
+	p.balanceResolver.ResetCosmosBalances()
+	// refresh bond providers
+	if err := p.balanceResolver.GetTHORChainBondProviders(ctx); err != nil {
+		p.logger.Errorf("failed to get thorchain bond providers: %v", err)
 	}
-
-	if job.CurrentVaultID == 0 {
-		p.logger.Infof("start lp calculation job %s", job.JobDate.Format("2006-01-02"))
-	} else {
-		p.logger.Infof("continue lp calculation job %s from %d", job.JobDate.Format("2006-01-02"), job.CurrentVaultID)
+	if err := p.balanceResolver.GetTHORChainRuneProviders(ctx); err != nil {
+		p.logger.Errorf("failed to get thorchain rune providers: %v", err)
 	}
 
-	if err := p.updateCoinPrice(); err != nil {
-		p.logger.Errorf("failed to update coin prices: %e", err)
-		return
-	}
 	//default value for lastVolumeFetch is first of June 2025
 	lastVolumeFetch := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC).Unix()
-	lastVolumeJob, err := p.storage.GetLastVolumeFetch()
+	// instances of the same job load the same range
+	lastVolumeJob, err := p.storage.GetLastVolumeFetch(job)
 	if err == nil {
 		lastVolumeFetch = models.GetDate(lastVolumeJob.JobDate)
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-		p.logger.Errorf("failed to get last volume fetch: %e", err)
-		return
+		return fmt.Errorf("failed to get last volume fetch: %w", err)
 	}
-
 	// TODO: make sure logic for from/to is correct
-	if err := p.volumeResolver.LoadVolume(lastVolumeFetch, models.GetDate(job.JobDate)); err != nil {
-		p.logger.Errorf("failed to load volume: %e", err)
-	} else {
-		p.logger.Infof("volume fetch completed successfully (from %d to %d)", lastVolumeFetch, models.GetDate(job.JobDate))
-		p.isVolumeFetched = true
+	if err := p.volumeResolver.LoadVolume(ctx, lastVolumeFetch, models.GetDate(job.JobDate)); err != nil {
+		return fmt.Errorf("failed to load volume: %w", err)
+	}
+	p.logger.Infof("volume fetch completed successfully (from %d to %d)", lastVolumeFetch, models.GetDate(job.JobDate))
+	p.preparedJobID = job.ID
+	if err := p.storage.UpdateIsVolumeFetched(job); err != nil {
+		//TODO: handler error properly
+		p.logger.Errorf("failed to update is_volume_fetched: %v", err)
 	}
+	return nil
+}
 
-	p.wg.Add(1)
-	workChan := make(chan models.CoinDBModel)
-	// worker channel for lp calculation (key is vault id and value is vault addresses)
-	positionWorkerChan := make(chan models.VaultAddress)
-	go p.taskProvider(job, workChan, positionWorkerChan)
+func (p *PointWorker) Stop() {
+	close(p.stopChan)
+	p.wg.Wait()
+}
 
-	// We have 2 type of concurrent workers, one for updating balance and one for updating position
-	for i := 0; i < 2; i++ {
-		p.wg.Add(1)
-		idx := i
-		go p.activePositionWorker(idx, positionWorkerChan, *job)
+// interrupted reports whether the worker is stopping or lost the lease of its current shard, callers
+// outside a shard pass the cancellation of the job instead
+func (p *PointWorker) interrupted(leaseLost <-chan struct{}) bool {
+	select {
+	case <-p.stopChan:
+		return true
+	case <-leaseLost:
+		return true
+	default:
+		return false
 	}
-	for i := 0; i < int(p.cfg.Worker.Concurrency); i++ {
+}
+
+// runWorkers starts count workers and returns a wait group that is done once all of them returned
+func (p *PointWorker) runWorkers(count int, worker func(idx int)) *sync.WaitGroup {
+	workerWg := &sync.WaitGroup{}
+	for i := 0; i < count; i++ {
 		p.wg.Add(1)
+		workerWg.Add(1)
 		idx := i
-		go p.taskWorker(idx, workChan, *job)
+		go func() {
+			defer workerWg.Done()
+			worker(idx)
+		}()
 	}
+	return workerWg
+}
 
+// taskProvider claims shards of the job until none is ready, other instances work on the remaining ones.
+// Items of a shard that was taken over are skipped when the job already processed them.
+func (p *PointWorker) taskProvider(job *models.Job, cancelled <-chan struct{}) {
+	defer p.wg.Done()
+	defer func() {
+		p.controlMu.Lock()
+		p.currentJob = nil
+		p.jobCancelled = nil
+		p.controlMu.Unlock()
+	}()
+	for !p.interrupted(cancelled) {
+		// a paused instance does not claim shards
+		if !p.waitIfPaused(cancelled) {
+			return
+		}
+		shard, err := p.storage.ClaimJobShard(job.ID, p.instanceID, p.leaseDuration)
+		if err != nil {
+			p.logger.Errorf("failed to claim shard of job %d: %v", job.ID, err)
+			p.countError("claim")
+			return
+		}
+		if shard == nil {
+			p.logger.Infof("no shard of job %d ready to claim", job.ID)
+			return
+		}
+		if !p.runShard(job, shard, cancelled) {
+			return
+		}
+	}
 }
 
-func (p *PointWorker) Stop() {
-	close(p.stopChan)
-	p.wg.Wait()
+// runShard runs the shard while renewing its lease and records the outcome on its phase,
+// it returns false when the shard was not completed
+func (p *PointWorker) runShard(job *models.Job, shard *models.JobShard, cancelled <-chan struct{}) bool {
+	p.logger.Infof("claimed %s shard (%d, %d] of job %d", shard.Phase, shard.StartID, shard.EndID, job.ID)
+	if err := p.refreshAssets(); err != nil {
+		// the previously loaded assets are kept
+		p.logger.Errorf("failed to refresh tracked assets: %v", err)
+		p.countError("assets")
+	}
+	done := make(chan struct{})
+	leaseLost := make(chan struct{})
+	defer close(done)
+	go p.heartbeat(shard, done, cancelled, leaseLost)
+	ctx, cancel := p.shardContext(leaseLost)
+	defer cancel()
+
+	completed, err := p.runPhase(ctx, job, shard, leaseLost)
+	if err != nil {
+		p.logger.Errorf("phase %s of job %d failed: %v", shard.Phase, job.ID, err)
+		p.countError("phase")
+		if err := p.storage.FailJobShard(shard, err); err != nil {
+			p.logger.Errorf("failed to record failure of shard %d: %v", shard.ID, err)
+		}
+		return false
+	}
+	// workers might have quit before draining their channels
+	if !completed || p.interrupted(leaseLost) {
+		return false
+	}
+	if err := p.storage.CompleteJobShard(shard); err != nil {
+		p.logger.Errorf("failed to complete shard %d: %v", shard.ID, err)
+		return false
+	}
+	return true
 }
 
-func (p *PointWorker) taskProvider(job *models.Job, workChan chan models.CoinDBModel, positionWorkerChan chan models.VaultAddress) {
-	defer p.wg.Done()
-	p.isJobInProgress = true
-	defer func() {
-		p.isJobInProgress = false
+// shardContext returns a context for the requests of a shard, it is cancelled when the worker stops or
+// loses the lease of the shard so shutdown does not wait for retries and slow upstreams
+func (p *PointWorker) shardContext(leaseLost <-chan struct{}) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-p.stopChan:
+		case <-leaseLost:
+		case <-ctx.Done():
+		}
+		cancel()
 	}()
-	currentVaultId := job.CurrentVaultID
-	currentID := uint64(job.CurrentID)
-	// refresh bond providers
-	if err := p.balanceResolver.GetTHORChainBondProviders(); err != nil {
-		p.logger.Errorf("failed to get thorchain bond providers: %v", err)
+	return ctx, cancel
+}
+
+// runPhase runs the part of the phase covered by the shard, it returns false when interrupted.
+// Every phase can be run again after a failure or crash without crediting vaults twice.
+func (p *PointWorker) runPhase(ctx context.Context, job *models.Job, shard *models.JobShard, leaseLost <-chan struct{}) (bool, error) {
+	switch shard.Phase {
+	case models.JobPhasePrices:
+		if err := p.updateCoinPrice(ctx, job); err != nil {
+			return false, fmt.Errorf("failed to update coin prices: %w", err)
+		}
+	case models.JobPhaseVaults:
+		if err := p.prepareJob(ctx, job); err != nil {
+			return false, err
+		}
+		positionWorkerChan := make(chan models.VaultAddress)
+		// We have 2 type of concurrent workers, one for updating balance and one for updating position
+		workerWg := p.runWorkers(2, func(idx int) {
+			p.activePositionWorker(ctx, idx, positionWorkerChan, *job)
+		})
+		completed := p.provideVaults(ctx, job, shard, positionWorkerChan, leaseLost)
+		close(positionWorkerChan)
+		workerWg.Wait()
+		return completed, nil
+	case models.JobPhaseBalances:
+		if err := p.prepareJob(ctx, job); err != nil {
+			return false, err
+		}
+		// a batch that cannot be processed fails the shard, so its next lease processes the coins again
+		batchCtx, fail := context.WithCancelCause(ctx)
+		defer fail(nil)
+		workChan := make(chan []models.CoinDBModel)
+		workerWg := p.runWorkers(int(p.cfg.Worker.Concurrency), func(idx int) {
+			p.taskWorker(batchCtx, idx, workChan, *job, fail)
+		})
+		completed := p.provideCoins(job, shard, workChan, batchCtx.Done())
+		close(workChan)
+		workerWg.Wait()
+		if err := context.Cause(batchCtx); err != nil && ctx.Err() == nil {
+			return false, err
+		}
+		return completed, nil
+	case models.JobPhaseDiscovery:
+		return p.discoverTokens(ctx, job, shard, leaseLost), nil
+	case models.JobPhasePoints:
+		formula, err := p.formula()
+		if err != nil {
+			return false, err
+		}
+		if err := p.storage.ApplyPointEvents(job, formula.Weights()); err != nil {
+			return false, err
+		}
+	case models.JobPhaseVaultBalance:
+		if err := p.storage.UpdateVaultBalance(); err != nil {
+			return false, fmt.Errorf("failed to update vault balance: %w", err)
+		}
+	case models.JobPhaseTotalPoints:
+		if p.cfg.GetCurrentSeason().ID > 0 {
+			p.logger.Infof("update vaults total point based on new formula for season %d", p.cfg.GetCurrentSeason().ID)
+			formula, err := p.formula()
+			if err != nil {
+				return false, err
+			}
+			if err := p.updateVaultsTotalPoints(formula); err != nil {
+				return false, fmt.Errorf("failed to update vault total points: %w", err)
+			}
+		}
+	case models.JobPhaseMilestones:
+		if p.cfg.GetCurrentSeason().ID > 0 {
+			formula, err := p.formula()
+			if err != nil {
+				return false, err
+			}
+			if err := p.updateVaultsMilestone(formula); err != nil {
+				return false, fmt.Errorf("failed to update vaults milestones: %w", err)
+			}
+		}
+	case models.JobPhaseRanks:
+		if err := p.storage.UpdateVaultRanks(); err != nil {
+			return false, fmt.Errorf("failed to update vault ranks: %w", err)
+		}
+	case models.JobPhaseSnapshots:
+		if err := p.storage.WriteDailySnapshots(job); err != nil {
+			return false, err
+		}
+		if err := p.storage.MarkJobSuccess(job); err != nil {
+			return false, err
+		}
+	default:
+		return false, fmt.Errorf("unknown phase %s", shard.Phase)
 	}
-	if err := p.balanceResolver.GetTHORChainRuneProviders(); err != nil {
-		p.logger.Errorf("failed to get thorchain rune providers: %v", err)
+	return true, nil
+}
+
+// heartbeat renews the lease of the shard until done is closed, leaseLost is closed when the lease
+// could not be renewed in time and another instance may take the shard over, or when the job is cancelled
+func (p *PointWorker) heartbeat(shard *models.JobShard, done, cancelled <-chan struct{}, leaseLost chan<- struct{}) {
+	ticker := time.NewTicker(p.leaseDuration / 3)
+	defer ticker.Stop()
+	lastRenewal := time.Now()
+	for {
+		select {
+		case <-done:
+			return
+		case <-cancelled:
+			close(leaseLost)
+			return
+		case <-ticker.C:
+			renewed, err := p.storage.RenewJobShardLease(shard, p.leaseDuration)
+			if err != nil {
+				p.logger.Errorf("failed to renew lease of shard %d: %v", shard.ID, err)
+				if time.Since(lastRenewal) < p.leaseDuration {
+					continue
+				}
+			}
+			if !renewed {
+				p.logger.Warnf("lost lease of shard %d", shard.ID)
+				close(leaseLost)
+				return
+			}
+			lastRenewal = time.Now()
+		}
 	}
+}
+
+// provideVaults processes the vaults of the shard and sends their addresses to the position workers,
+// it returns false when interrupted
+func (p *PointWorker) provideVaults(ctx context.Context, job *models.Job, shard *models.JobShard, positionWorkerChan chan<- models.VaultAddress, leaseLost <-chan struct{}) bool {
+	currentVaultId := shard.StartID
 	for {
+		if p.interrupted(leaseLost) {
+			return false
+		}
 		vaults, err := p.storage.GetVaultsWithPage(currentVaultId, 1000)
 		if err != nil {
 			p.logger.Errorf("failed to get vaults: %v", err)
 			continue
 		}
-		if len(vaults) == 0 {
-			p.logger.Info("no more vaults to process")
-			break
+		if len(vaults) == 0 || !shard.Contains(vaults[0].ID) {
+			p.logger.Infof("no more vaults to process in shard %d", shard.ID)
+			return true
 		}
+		ids := make([]uint, len(vaults))
 		for i, vault := range vaults {
-			if vault.CurrentSeasonID < p.cfg.GetCurrentSeason().ID {
-				p.logger.Infof("vault %d is not in current season, commiting old season points", vault.ID)
-				if err := p.storage.CommitSeasonPoints(vault, p.cfg.GetCurrentSeason().ID); err != nil {
-					p.logger.Errorf("failed to commit season points for vault %d: %v", vault.ID, err)
-					continue
-				}
+			ids[i] = vault.ID
+		}
+		processedVaults, err := p.storage.GetProcessedJobItems(job.ID, models.JobItemVault, ids)
+		if err != nil {
+			p.logger.Errorf("failed to get processed vaults: %v", err)
+			continue
+		}
+		processedPositions, err := p.storage.GetProcessedJobItems(job.ID, models.JobItemPosition, ids)
+		if err != nil {
+			p.logger.Errorf("failed to get processed positions: %v", err)
+			continue
+		}
+		for i, vault := range vaults {
+			if !shard.Contains(vault.ID) {
+				return true
 			}
-			// Fetch referral count
-			vaults[i].ReferralCount, err = p.getValidReferralCount(vault.ECDSA, vault.EDDSA)
-			if err != nil {
-				p.logger.Errorf("failed to get referral count for vault %d: %v", vault.ID, err)
+			currentVaultId = vault.ID
+			shard.CurrentID = vault.ID
+			if processedVaults[vault.ID] && processedPositions[vault.ID] {
 				continue
 			}
-
-			err = p.storage.UpdateReferralCount(&vaults[i])
-			if err != nil {
-				p.logger.Errorf("failed to update referral count for vault %d: %v", vault.ID, err)
-				continue
+			if !p.waitIfPaused(leaseLost) {
+				return false
 			}
-
-			coins, err := p.storage.GetCoins(vault.ID)
+			vaultAddress, err := p.processVault(ctx, job, &vaults[i], processedVaults[vault.ID])
 			if err != nil {
-				p.logger.Errorf("failed to get coins for vault: %v", err)
+				p.logger.Errorf("failed to process vault %d: %v", vault.ID, err)
+				p.countError("vault")
 				continue
 			}
-			var totalVolume float64
-			address := make(map[string]interface{})
-			//generate vault address for all chains
-			for _, chain := range common.GetAllChains() {
-				//generate address for the given chains
-				addr, err := vault.GetAddress(chain)
-				if err != nil {
-					p.logger.Errorf("failed to get address for vault %d on chain %s: %v", vault.ID, chain, err)
-					continue
-				}
-				found := false
-				for _, coin := range coins {
-					if coin.Address == addr {
-						found = true
-					}
-				}
-				if !found {
-					// if address not found in coins, add it
-					coins = append(coins, models.CoinDBModel{
-						CoinBase: models.CoinBase{
-							Chain:    chain,
-							Address:  addr,
-							IsNative: true,
-						},
-						VaultID: vault.ID,
-					})
+			if !processedPositions[vault.ID] && len(vaultAddress.GetAllAddress()) > 0 {
+				select {
+				case positionWorkerChan <- vaultAddress:
+				case <-p.stopChan:
+					return false
+				case <-leaseLost:
+					return false
 				}
 			}
+		}
+	}
+}
 
-			// fetch volume for each coin
-			for _, coin := range coins {
-				if _, ok := address[coin.Address]; ok {
-					continue // skip if address already exists
-				}
-				coinVolume := p.volumeResolver.GetVolume(coin.Address)
-				if coinVolume > 0 {
-					totalVolume += coinVolume
-				}
-				address[coin.Address] = nil
-			}
-			err = p.storage.UpdateVolume(vault.ID, totalVolume)
-			if err != nil {
-				p.logger.Errorf("failed to update volume for vault %d: %v", vault.ID, err)
-				continue
+// processVault credits the referral count and swap volume of the vault unless the job already did,
+// and returns the vault addresses on all chains
+func (p *PointWorker) processVault(ctx context.Context, job *models.Job, vault *models.Vault, processed bool) (models.VaultAddress, error) {
+	vaultAddress := models.NewVaultAddress(vault.ID)
+	if vault.CurrentSeasonID < p.cfg.GetCurrentSeason().ID {
+		p.logger.Infof("vault %d is not in current season, commiting old season points", vault.ID)
+		if err := p.storage.CommitSeasonPoints(*vault, p.cfg.GetCurrentSeason().ID); err != nil {
+			return vaultAddress, fmt.Errorf("failed to commit season points: %w", err)
+		}
+	}
+	coins, err := p.storage.GetCoins(vault.ID)
+	if err != nil {
+		return vaultAddress, fmt.Errorf("failed to get coins for vault: %w", err)
+	}
+	// addresses of the vault on all chains, derived once and stored
+	derived, err := p.storage.EnsureVaultAddresses(vault)
+	if err != nil {
+		if !errors.Is(err, models.ErrDeriveAddress) {
+			return vaultAddress, err
+		}
+		p.logger.Errorf("failed to get addresses for vault %d: %v", vault.ID, err)
+	}
+	addresses := models.AddressMap(derived)
+	foreign, err := p.storage.ClaimAddresses(vault, addresses)
+	if err != nil {
+		return vaultAddress, err
+	}
+	for key, owner := range foreign {
+		p.logger.Warnf("%s address %s of vault %d is credited to vault %d", key.Chain, key.Address, vault.ID, owner)
+	}
+	for chain, addr := range addresses {
+		found := false
+		for _, coin := range coins {
+			if coin.Address == addr {
+				found = true
 			}
+		}
+		if !found {
+			// if address not found in coins, add it
+			coins = append(coins, models.CoinDBModel{
+				CoinBase: models.CoinBase{
+					Chain:    chain,
+					Address:  addr,
+					IsNative: true,
+				},
+				VaultID: vault.ID,
+			})
+		}
+	}
+	// an address derived by several vaults counts for the vault it is credited to only
+	coins = slices.DeleteFunc(coins, func(coin models.CoinDBModel) bool {
+		_, ok := foreign[models.AddressKey{Chain: coin.Chain, Address: coin.Address}]
+		return ok
+	})
+	for _, coin := range coins {
+		vaultAddress.SetAddress(coin.Chain, coin.Address)
+	}
+	if processed {
+		return vaultAddress, nil
+	}
 
-			vaultAddress := models.NewVaultAddress(vault.ID)
-			for _, coin := range coins {
-				vaultAddress.SetAddress(coin.Chain, coin.Address)
-			}
-			if len(coins) > 0 {
-				positionWorkerChan <- vaultAddress
+	// Fetch referral count
+	vault.ReferralCount, err = p.getValidReferralCount(ctx, vault.ECDSA, vault.EDDSA)
+	if err != nil {
+		return vaultAddress, fmt.Errorf("failed to get referral count: %w", err)
+	}
+	// fetch volume for each coin
+	var totalVolume float64
+	address := make(map[string]interface{})
+	for _, coin := range coins {
+		if _, ok := address[coin.Address]; ok {
+			continue // skip if address already exists
+		}
+		coinVolume := p.volumeResolver.GetVolume(coin.Address)
+		if coinVolume > 0 {
+			totalVolume += coinVolume
+		}
+		address[coin.Address] = nil
+	}
+	// swap volume is accumulated, so it must be added once per job
+	_, err = p.storage.CompleteJobItem(job.ID, models.JobItemVault, vault.ID, func(s *Storage) error {
+		if err := s.UpdateReferralCount(vault); err != nil {
+			return err
+		}
+		if err := s.UpdateVolume(vault.ID, totalVolume); err != nil {
+			return err
+		}
+		// volume and referrals do not accrue value, they are recorded so the job can be replayed
+		if totalVolume > 0 {
+			event := models.NewPointEvent(*job, vault.ID, models.PointSourceVolume, 0, totalVolume, 0, 0)
+			if err := s.AddPointEvent(&event); err != nil {
+				return fmt.Errorf("failed to add volume point event: %w", err)
 			}
-			currentVaultId = vault.ID
-			job.CurrentVaultID = vault.ID
-			if err := p.storage.UpdateJob(job); err != nil {
-				p.logger.Errorf("failed to update job: %v", err)
+		}
+		if vault.ReferralCount > 0 {
+			event := models.NewPointEvent(*job, vault.ID, models.PointSourceReferral, 0, float64(vault.ReferralCount), 0, 0)
+			if err := s.AddPointEvent(&event); err != nil {
+				return fmt.Errorf("failed to add referral point event: %w", err)
 			}
 		}
+		return nil
+	})
+	return vaultAddress, err
+}
+
+// provideCoins sends the coins of the shard the job has not processed yet to the balance workers,
+// it returns false when interrupted
+func (p *PointWorker) provideCoins(job *models.Job, shard *models.JobShard, workChan chan<- []models.CoinDBModel, leaseLost <-chan struct{}) bool {
+	batchSize := int(max(1, p.cfg.Worker.BalanceBatchSize))
+	send := func(batch []models.CoinDBModel) bool {
+		if !p.waitIfPaused(leaseLost) {
+			return false
+		}
+		select {
+		case workChan <- batch:
+			return true
+		case <-p.stopChan:
+			return false
+		case <-leaseLost:
+			return false
+		}
 	}
+	currentID := uint64(shard.StartID)
 	for {
+		if p.interrupted(leaseLost) {
+			return false
+		}
 		coins, err := p.storage.GetCoinsWithPage(currentID, 1000)
 		if err != nil {
 			p.logger.Errorf("failed to get coins: %v", err)
 			continue
 		}
-
-		for _, coin := range coins {
-			currentID = uint64(coin.ID)
-			job.CurrentID = int64(coin.ID)
-			workChan <- coin
-		}
-
-		if len(coins) == 0 {
-			p.logger.Info("no more coins to process, stopping task provider")
-			// no more to process
-			close(workChan)
-			job.IsSuccess = true
-
+		if len(coins) == 0 || !shard.Contains(coins[0].ID) {
+			p.logger.Infof("no more coins to process in shard %d", shard.ID)
+			return true
 		}
-
-		if err := p.storage.UpdateJob(job); err != nil {
-			p.logger.Errorf("failed to update job: %v", err)
+		ids := make([]uint, len(coins))
+		for i, coin := range coins {
+			ids[i] = coin.ID
 		}
-		if job.IsSuccess {
-			break
-		}
-	}
-	if job.IsSuccess {
-		if err := p.storage.UpdateVaultBalance(); err != nil {
-			p.logger.Errorf("failed to update vault balance: %v", err)
+		processed, err := p.storage.GetProcessedJobItems(job.ID, models.JobItemCoin, ids)
+		if err != nil {
+			p.logger.Errorf("failed to get processed coins: %v", err)
+			continue
 		}
-		if p.cfg.GetCurrentSeason().ID > 0 {
-			p.logger.Infof("update vaults total point based on new formula for season %d", p.cfg.GetCurrentSeason().ID)
-			if err := p.storage.UpdateVaultTotalPoints(); err != nil {
-				p.logger.Errorf("failed to update vault total points: %v", err)
+		// coins of an EVM chain are batched per page, the others are fetched one by one
+		batches := make(map[common.Chain][]models.CoinDBModel)
+		var chains []common.Chain
+		shardDone := false
+		for _, coin := range coins {
+			if !shard.Contains(coin.ID) {
+				shardDone = true
+				break
 			}
-			if err := p.updateVaultsMilestone(); err != nil {
-				p.logger.Errorf("failed to update vaults milestones: %v", err)
+			currentID = uint64(coin.ID)
+			shard.CurrentID = coin.ID
+			if processed[coin.ID] {
+				continue
+			}
+			if !slices.Contains(common.EVMChains, coin.Chain) {
+				if !send([]models.CoinDBModel{coin}) {
+					return false
+				}
+				continue
+			}
+			if _, ok := batches[coin.Chain]; !ok {
+				chains = append(chains, coin.Chain)
+			}
+			batches[coin.Chain] = append(batches[coin.Chain], coin)
+			if len(batches[coin.Chain]) == batchSize {
+				if !send(batches[coin.Chain]) {
+					return false
+				}
+				batches[coin.Chain] = nil
 			}
 		}
-		if err := p.storage.UpdateVaultRanks(); err != nil {
-			p.logger.Errorf("failed to update vault ranks: %v", err)
+		for _, chain := range chains {
+			if len(batches[chain]) == 0 {
+				continue
+			}
+			if !send(batches[chain]) {
+				return false
+			}
 		}
-	}
-	if p.isVolumeFetched {
-		err := p.storage.UpdateIsVolumeFetched(job)
-		if err != nil {
-			//TODO: handler error properly
-			p.logger.Errorf("failed to update is_volume_fetched: %v", err)
-		} else {
-			p.logger.Infof("volume fetched successfully, updated job %d", job.ID)
+		if shardDone {
+			return true
 		}
 	}
 }
-func (p *PointWorker) updateVaultsMilestone() error {
+
+func (p *PointWorker) updateVaultsMilestone(formula scoring.Formula) error {
 	startId := uint(0)
 	for {
 		vaults, err := p.storage.GetVaultsWithPage(startId, 1000)
@@ -379,14 +772,11 @@ func (p *PointWorker) updateVaultsMilestone() error {
 			break
 		}
 		for _, vault := range vaults {
-			for i := 0; i < len(p.cfg.GetCurrentSeason().Milestones); i++ {
-				// if total points is greater than or equal to milestone minimum
-				if vault.TotalPoints >= float64(p.cfg.GetCurrentSeason().Milestones[i].Minimum) {
-					// if this milestone is locked
-					if vault.NextMilestoneID <= i {
-						// unlock milestone: update vault total points and next milestone id
-						p.storage.UpdateVaultMilestone(vault.ID, i+1, float64(p.cfg.GetCurrentSeason().Milestones[i].Prize))
-					}
+			nextMilestoneID, prize := formula.UnlockMilestones(vault.TotalPoints, vault.NextMilestoneID)
+			if nextMilestoneID > vault.NextMilestoneID {
+				// unlock milestones: update vault total points and next milestone id
+				if err := p.storage.UpdateVaultMilestone(vault.ID, nextMilestoneID, prize); err != nil {
+					return err
 				}
 			}
 			startId = vault.ID
@@ -396,7 +786,32 @@ func (p *PointWorker) updateVaultsMilestone() error {
 	return nil
 }
 
-func (p *PointWorker) activePositionWorker(idx int, workerChan <-chan models.VaultAddress, job models.Job) {
+// updateVaultsTotalPoints turns the value each vault accrued during the job into points. The accrued
+// value is reset to zero with it, so running it again adds nothing.
+func (p *PointWorker) updateVaultsTotalPoints(formula scoring.Formula) error {
+	startId := uint(0)
+	for {
+		vaults, err := p.storage.GetVaultsWithPage(startId, 1000)
+		if err != nil {
+			return fmt.Errorf("failed to get vaults: %w", err)
+		}
+		if len(vaults) == 0 {
+			break
+		}
+		for _, vault := range vaults {
+			startId = vault.ID
+			if vault.TotalVaultValue == 0 {
+				continue
+			}
+			if err := p.storage.AddVaultTotalPoints(vault.ID, formula.DailyPoints(vault.TotalVaultValue)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (p *PointWorker) activePositionWorker(ctx context.Context, idx int, workerChan <-chan models.VaultAddress, job models.Job) {
 	p.logger.Infof("active position worker %d started", idx)
 	defer p.wg.Done()
 	for {
@@ -408,16 +823,17 @@ func (p *PointWorker) activePositionWorker(idx int, workerChan <-chan models.Vau
 			if !more {
 				return
 			}
-			if err := p.updatePosition(v, job.Multiplier); err != nil {
+			if err := p.updatePosition(ctx, v, job); err != nil {
 				p.logger.Errorf("failed to update position: %v", err)
-			}
-			if err := p.updateNFTBalance(v, job.Multiplier); err != nil {
-				p.logger.Errorf("failed to update nft balance: %v", err)
+				p.countError("position")
 			}
 		}
 	}
 }
-func (p *PointWorker) taskWorker(idx int, workerChan <-chan models.CoinDBModel, job models.Job) {
+
+// taskWorker updates the balances of the batches it receives. When a batch cannot be prepared it calls fail,
+// and skips the remaining batches once the context is done.
+func (p *PointWorker) taskWorker(ctx context.Context, idx int, workerChan <-chan []models.CoinDBModel, job models.Job, fail context.CancelCauseFunc) {
 	p.logger.Infof("worker %d started", idx)
 	defer p.wg.Done()
 	for {
@@ -425,91 +841,169 @@ func (p *PointWorker) taskWorker(idx int, workerChan <-chan models.CoinDBModel,
 		case <-p.stopChan:
 			p.logger.Infof("worker %d stop signal received, stopping worker", idx)
 			return
-		case t, more := <-workerChan:
+		case batch, more := <-workerChan:
 			if !more {
 				return
 			}
-			if err := p.updateBalance(t, job.Multiplier); err != nil {
-				p.logger.Errorf("failed to update balance: %v", err)
+			if ctx.Err() != nil {
+				continue
+			}
+			formula, err := p.formula()
+			if err != nil {
+				p.logger.Errorf("failed to get formula: %v", err)
+				p.countError("balance")
+				fail(fmt.Errorf("failed to get formula: %w", err))
+				continue
+			}
+			samples, err := p.balanceSamples(batch, job, formula)
+			if err != nil {
+				p.logger.Errorf("failed to get balance samples: %v", err)
+				p.countError("balance")
+				fail(fmt.Errorf("failed to get balance samples: %w", err))
+				continue
+			}
+			owners, err := p.addressOwners(batch)
+			if err != nil {
+				p.logger.Errorf("failed to get address owners: %v", err)
+				p.countError("balance")
+				fail(fmt.Errorf("failed to get address owners: %w", err))
+				continue
+			}
+			for _, result := range p.balanceResolver.GetBalancesWithRetry(ctx, batch) {
+				owner, claimed := owners[models.AddressKey{Chain: result.Coin.Chain, Address: result.Coin.Address}]
+				foreign := claimed && owner != result.Coin.VaultID
+				if err := p.updateBalance(result, samples[result.Coin.ID], formula, foreign, job); err != nil {
+					p.logger.Errorf("failed to update balance: %v", err)
+					p.countError("balance")
+				}
 			}
 		}
 	}
 }
 
-func (p *PointWorker) updatePosition(vaultAddress models.VaultAddress, multiplier int64) error {
-	newlp, err := p.fetchPosition(vaultAddress)
+func (p *PointWorker) updatePosition(ctx context.Context, vaultAddress models.VaultAddress, job models.Job) error {
+	vaultID := vaultAddress.GetVaultID()
+	lpValue, lpFetched, err := p.getPosition(ctx, vaultAddress, job.JobDate)
 	if err != nil {
-		p.logger.Errorf("failed to fetch position for vault id %d , using old position: %v", vaultAddress.GetVaultID(), err)
-		oldLp, err := p.storage.GetLPValue(vaultAddress.GetVaultID())
+		return err
+	}
+	nftValue, nftFetched, err := p.getNFTValue(ctx, vaultAddress, job.JobDate)
+	if err != nil {
+		return err
+	}
+	fetched := lpFetched && nftFetched
+	if !fetched {
+		freshness, err := p.storage.GetPositionFreshness(vaultID)
 		if err != nil {
-			return fmt.Errorf("failed to get vault: %w", err)
+			return err
 		}
-		newlp = oldLp
-	} else {
-		p.logger.Infof("new lp value for vault %d is %d", vaultAddress.GetVaultID(), newlp)
-		if err := p.storage.UpdateLPValue(vaultAddress.GetVaultID(), newlp); err != nil {
-			p.logger.Errorf("failed to update lp value: %v", err)
+		freshness.FailureStreak++
+		if staleDays := freshness.StaleDays(time.Now()); !p.cfg.StaleBalance.Accrues(staleDays) {
+			p.logger.Warnf("positions of vault %d are stale for %d days, they no longer accrue points", vaultID, staleDays)
+			if !lpFetched {
+				lpValue = 0
+			}
+			if !nftFetched {
+				nftValue = 0
+			}
 		}
 	}
-	newPoints := float64(newlp * multiplier)
-	if newlp == 0 {
+	_, err = p.storage.CompleteJobItem(job.ID, models.JobItemPosition, vaultID, func(s *Storage) error {
+		if fetched {
+			if err := s.UpdatePositionFetchedAt(vaultID, time.Now()); err != nil {
+				return err
+			}
+		} else if err := s.IncrementVaultFailureStreak(vaultID); err != nil {
+			return err
+		}
+		if lpFetched {
+			if err := s.UpdateLPValue(vaultID, lpValue); err != nil {
+				return fmt.Errorf("failed to update lp value: %w", err)
+			}
+		}
+		if nftFetched {
+			if err := s.UpdateNFTValue(vaultID, nftValue); err != nil {
+				return fmt.Errorf("failed to update nft value: %w", err)
+			}
+		}
+		if lpValue != 0 {
+			// lp value is already in USD
+			event := models.NewPointEvent(job, vaultID, models.PointSourceLP, 0, float64(lpValue), 1, 1)
+			if err := s.AddPointEvent(&event); err != nil {
+				return fmt.Errorf("failed to add lp point event: %w", err)
+			}
+		}
+		if nftValue != 0 {
+			// nft value is in USD and already includes the season multiplier of each collection
+			event := models.NewPointEvent(job, vaultID, models.PointSourceNFT, 0, float64(nftValue), 1, 1)
+			if err := s.AddPointEvent(&event); err != nil {
+				return fmt.Errorf("failed to add nft point event: %w", err)
+			}
+		}
 		return nil
+	})
+	return err
+}
+
+// getPosition returns the lp value of the vault and whether it was fetched, the stored value is used when fetching fails
+func (p *PointWorker) getPosition(ctx context.Context, vaultAddress models.VaultAddress, date time.Time) (int64, bool, error) {
+	newlp, err := p.fetchPosition(ctx, vaultAddress, date)
+	if err != nil {
+		p.logger.Errorf("failed to fetch position for vault id %d , using old position: %v", vaultAddress.GetVaultID(), err)
+		p.countError("position_fetch")
+		oldLp, err := p.storage.GetLPValue(vaultAddress.GetVaultID())
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to get vault: %w", err)
+		}
+		return oldLp, false, nil
 	}
-	if err := p.storage.IncreaseVaultTotalValue(vaultAddress.GetVaultID(), newPoints); err != nil {
-		return fmt.Errorf("failed to increase vault total points: %w", err)
-	}
-	return nil
+	p.logger.Infof("new lp value for vault %d is %d", vaultAddress.GetVaultID(), newlp)
+	return newlp, true, nil
 }
 
-func (p *PointWorker) updateNFTBalance(vaultAddress models.VaultAddress, multiplier int64) error {
-	var nftValue int64
-	nftValue, err := p.fetchNFTValue(vaultAddress)
+// getNFTValue returns the nft value of the vault and whether it was fetched, the stored value is used when fetching fails
+func (p *PointWorker) getNFTValue(ctx context.Context, vaultAddress models.VaultAddress, date time.Time) (int64, bool, error) {
+	nftValue, err := p.fetchNFTValue(ctx, vaultAddress, date)
 	if err != nil {
 		p.logger.Errorf("failed to fetch nft value for vault id %d , using old nft value: %v", vaultAddress.GetVaultID(), err)
-		nftValue, err = p.storage.GetNFTValue(vaultAddress.GetVaultID())
+		p.countError("nft_fetch")
+		oldValue, err := p.storage.GetNFTValue(vaultAddress.GetVaultID())
 		if err != nil {
-			return fmt.Errorf("failed to get vault: %w", err)
+			return 0, false, fmt.Errorf("failed to get vault: %w", err)
 		}
-	} else {
-		p.logger.Infof("new nft value for vault %d is %d", vaultAddress.GetVaultID(), nftValue)
-		if err := p.storage.UpdateNFTValue(vaultAddress.GetVaultID(), nftValue); err != nil {
-			p.logger.Errorf("failed to update nft value: %v", err)
-		}
-	}
-	newPoints := float64(nftValue * multiplier)
-	if newPoints == 0 {
-		return nil
+		return oldValue, false, nil
 	}
-	if err := p.storage.IncreaseVaultTotalValue(vaultAddress.GetVaultID(), newPoints); err != nil {
-		return fmt.Errorf("failed to increase vault total points: %w", err)
-	}
-	return nil
+	p.logger.Infof("new nft value for vault %d is %d", vaultAddress.GetVaultID(), nftValue)
+	return nftValue, true, nil
 }
 
-func (p *PointWorker) fetchPosition(vaultAddress models.VaultAddress) (int64, error) {
+// fetchPosition values the positions of the vault with the tcy price at the date
+func (p *PointWorker) fetchPosition(ctx context.Context, vaultAddress models.VaultAddress, date time.Time) (int64, error) {
 	backoffRetry := utils.NewBackoffRetry(5)
 	address := strings.Join(vaultAddress.GetAllAddress(), ",")
 	p.logger.Infof("start to update position for vault: %d,  address: %s ", vaultAddress.GetVaultID(), address)
 
-	tcyPrice, err := p.priceResolver.GetMidgardPrices("THOR.TCY")
+	tcyPrice, err := p.priceResolver.PriceAt(ctx, models.CoinAsset(common.THORChain, "THOR.TCY"), date, pricing.SourceMidgard, func(ctx context.Context) (float64, error) {
+		return p.priceResolver.GetMidgardPrices(ctx, "THOR.TCY")
+	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to get tcy price: %w", err)
 	}
 	p.lpResolver.SetTCYPrice(tcyPrice)
 
-	tcmayalp, err := backoffRetry.RetryWithBackoff(p.lpResolver.GetLiquidityPosition, address)
+	tcmayalp, err := backoffRetry.RetryWithBackoff(ctx, p.lpResolver.GetLiquidityPosition, address)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get tc/maya liquidity position for vault:%d : %w", vaultAddress.GetVaultID(), err)
 	}
 	p.logger.Infof("tc/maya liquidity position for vault %d is %f", vaultAddress.GetVaultID(), tcmayalp)
 
-	saver, err := backoffRetry.RetryWithBackoff(p.saverResolver.GetSaverPosition, address)
+	saver, err := backoffRetry.RetryWithBackoff(ctx, p.saverResolver.GetSaverPosition, address)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get saver position for vault:%d : %w", vaultAddress.GetVaultID(), err)
 	}
 	p.logger.Infof("saver position for vault %d is %f", vaultAddress.GetVaultID(), saver)
 
-	tcyStake, err := backoffRetry.RetryWithBackoff(p.lpResolver.GetTCYStakePosition, vaultAddress.GetAddress(common.THORChain))
+	tcyStake, err := backoffRetry.RetryWithBackoff(ctx, p.lpResolver.GetTCYStakePosition, vaultAddress.GetAddress(common.THORChain))
 	if err != nil {
 		return 0, fmt.Errorf("failed to get tcy stake position for vault:%d : %w", vaultAddress.GetVaultID(), err)
 	}
@@ -518,46 +1012,65 @@ func (p *PointWorker) fetchPosition(vaultAddress models.VaultAddress) (int64, er
 	newLP := tcmayalp + saver + tcyStake
 	return int64(newLP), nil
 }
-func (p *PointWorker) fetchNFTValue(vault models.VaultAddress) (int64, error) {
+
+// fetchNFTValue values the nfts of the vault with the floor prices of their collections at the date
+func (p *PointWorker) fetchNFTValue(ctx context.Context, vault models.VaultAddress, date time.Time) (int64, error) {
+	formula, err := p.formula()
+	if err != nil {
+		return 0, err
+	}
 	sum := float64(0)
-	for _, nft := range p.whitelistNFTCollection {
+	for _, nft := range p.assets.Load().NFTCollections() {
 		address := vault.GetAddress(nft.Chain)
 		if address != "" {
 			token := models.CoinDBModel{CoinBase: models.CoinBase{
 				Chain:           nft.Chain,
 				Address:         address,
-				ContractAddress: nft.CollectionAddress,
+				ContractAddress: nft.ContractAddress,
 				Decimals:        0,
 				IsNative:        false,
 			}}
-			balance, err := p.balanceResolver.GetBalanceWithRetry(token)
+			balance, err := p.balanceResolver.GetBalanceWithRetry(ctx, token)
 			if err != nil {
 				return 0, fmt.Errorf("failed to get balance for address:%s : %v", address, err)
 			}
-			price, err := p.priceResolver.GetOpenSeaCollectionMinPrice(nft.CollectionSlug)
+			price, err := p.priceResolver.PriceAt(ctx, models.CollectionAsset(nft.PriceProviderID), date, pricing.SourceOpenSea, func(ctx context.Context) (float64, error) {
+				return p.priceResolver.GetOpenSeaCollectionMinPrice(ctx, nft.PriceProviderID)
+			})
 			if err != nil {
-				return 0, fmt.Errorf("failed to get price for collection:%s : %v", nft.CollectionSlug, err)
+				return 0, fmt.Errorf("failed to get price for collection:%s : %v", nft.PriceProviderID, err)
 			}
-			seasonMultiplier := p.getSeasonMultiplierForNFT(token)
-			sum += balance * float64(seasonMultiplier) * price
+			seasonMultiplier := formula.NFTMultiplier(token)
+			sum += balance * seasonMultiplier * price
 		}
 	}
 	return int64(sum), nil
 }
-func (p *PointWorker) updateBalance(coin models.CoinDBModel, multiplier int64) error {
+
+// updateBalance stores the fetched balance of the coin and credits it to the vault, aggregated with the
+// balances sampled since the previous job when the season samples balances. A coin whose address is
+// credited to another vault is not credited.
+func (p *PointWorker) updateBalance(result balance.BalanceResult, samples []scoring.Sample, formula scoring.Formula, foreign bool, job models.Job) error {
+	coin := result.Coin
 	p.logger.Infof("start to update balance for chain: %s, ticker: %s, address: %s ", coin.Chain, coin.Ticker, coin.Address)
-	coinBalance, err := p.balanceResolver.GetBalanceWithRetry(coin)
-	if err != nil {
-		p.logger.Errorf("failed to get balance for address:%s : %v", coin.Address, err)
+	fetched := true
+	coinBalance := result.Balance
+	if result.Err != nil {
+		p.logger.Errorf("failed to get balance for address:%s : %v", coin.Address, result.Err)
+		p.countError("balance_fetch")
 		prevBalance, errP := strconv.ParseFloat(coin.Balance, 64)
 		if errP != nil {
 			return fmt.Errorf("failed to parse previous balance: %w", errP)
 		}
 		// server failed to get the latest balance , assume his previous balance is correct and use it to accumulate points
 		coinBalance = prevBalance
-	} else {
-		if err := p.storage.UpdateCoinBalance(uint64(coin.ID), coinBalance); err != nil {
-			return fmt.Errorf("failed to update coin balance: %w", err)
+		fetched = false
+		// until it is stale for longer than the policy allows
+		coin.FailureStreak++
+		if staleDays := coin.StaleDays(time.Now()); !p.cfg.StaleBalance.Accrues(staleDays) {
+			p.logger.Warnf("balance of coin %d is stale for %d days, it no longer accrues points", coin.ID, staleDays)
+			coinBalance = 0
+			samples = nil
 		}
 	}
 	if coin.PriceUSD == "" {
@@ -568,91 +1081,189 @@ func (p *PointWorker) updateBalance(coin models.CoinDBModel, multiplier int64) e
 	if err != nil {
 		return fmt.Errorf("failed to parse coin price: %w", err)
 	}
-	seasonMultiplier := p.getSeasonMultiplierForCoin(coin)
-	newPoints := float64(coinBalance * price * float64(multiplier) * float64(seasonMultiplier))
-	if newPoints == 0 {
-		return nil
-	}
-	if err := p.storage.IncreaseVaultTotalValue(coin.VaultID, newPoints); err != nil {
-		return fmt.Errorf("failed to increase vault total points: %w", err)
+	credited := coinBalance
+	if len(samples) > 0 {
+		credited = formula.SampleAggregation.Aggregate(append(samples, scoring.Sample{At: time.Now(), Balance: coinBalance}))
 	}
-	return nil
+	seasonMultiplier := formula.TokenMultiplier(coin)
+	event := models.NewPointEvent(job, coin.VaultID, models.PointSourceBalance, coin.ID, credited, price, seasonMultiplier)
+	_, err = p.storage.CompleteJobItem(job.ID, models.JobItemCoin, coin.ID, func(s *Storage) error {
+		if fetched {
+			if err := s.UpdateCoinBalance(uint64(coin.ID), coinBalance, time.Now()); err != nil {
+				return fmt.Errorf("failed to update coin balance: %w", err)
+			}
+		} else if err := s.IncrementCoinFailureStreak(coin.ID); err != nil {
+			return err
+		}
+		if event.Value == 0 || foreign {
+			return nil
+		}
+		if err := s.AddPointEvent(&event); err != nil {
+			return fmt.Errorf("failed to add balance point event: %w", err)
+		}
+		return nil
+	})
+	return err
 }
 
-func (p *PointWorker) updateCoinPrice() error {
+// updateCoinPrice prices the coins of the vaults in the airdrop through the default price sources by their
+// cmc id, then the coins with a price route through their route. A job of today records the prices it fetches
+// for its date, a job of an earlier day, such as a rerun, uses the prices recorded by its date instead.
+// Fetched prices the guard quarantines are not used, and coins below the minimum market are priced at zero.
+func (p *PointWorker) updateCoinPrice(ctx context.Context, job *models.Job) error {
 	p.logger.Info("start to update coin prices")
+	defer p.logger.Info("finish updating coin prices")
 	coinIdentities, err := p.storage.GetUniqueCoins()
 	if err != nil {
 		return fmt.Errorf("failed to get unique coins: %w", err)
 	}
 	p.logger.Infof("got %d unique coins", len(coinIdentities))
-	if len(coinIdentities) == 0 {
-		return nil
+	var cmcIDs []int
+	coins := make(map[int]models.CoinIdentity, len(coinIdentities))
+	for _, coin := range coinIdentities {
+		if _, ok := coins[coin.CMCId]; ok || coin.CMCId == 0 || p.oracle.Routed(coin.Chain, coin.Ticker) {
+			continue
+		}
+		coins[coin.CMCId] = coin
+		cmcIDs = append(cmcIDs, coin.CMCId)
+	}
+	routes := p.oracle.Routes()
+
+	date := models.PriceDate(job.JobDate)
+	past := date.Before(models.PriceDate(time.Now()))
+	assets := make([]string, 0, len(cmcIDs)+len(routes))
+	for _, cmcID := range cmcIDs {
+		assets = append(assets, models.CMCAsset(cmcID))
+	}
+	for _, route := range routes {
+		assets = append(assets, models.CoinAsset(route.Chain, route.Ticker))
 	}
-	coinPrices, err := p.priceResolver.GetAllTokenPrices(coinIdentities)
+	// the last good prices, an earlier day uses them as they are
+	stored, err := p.priceResolver.PricesAt(assets, date)
 	if err != nil {
-		return fmt.Errorf("failed to get all token prices: %w", err)
+		return fmt.Errorf("failed to get prices at %s: %w", date.Format(time.DateOnly), err)
 	}
-	p.logger.Infof("%+v", coinPrices)
-	for id, coinIden := range coinPrices {
-		if err := p.storage.UpdateCoinPriceByCMCID(id, coinIden); err != nil {
-			p.logger.Errorf("failed to update coin price: %d, err: %v", id, err)
-			// log the error and move on
+	recorded := map[string]models.Price{}
+	if past {
+		recorded = stored
+		p.logger.Infof("job %d prices %d of %d assets at their price recorded by %s", job.ID, len(recorded), len(assets), date.Format(time.DateOnly))
+	}
+	markets := map[int]models.TokenQuote{}
+	if p.guard.ChecksMarket() && len(cmcIDs) > 0 {
+		markets, err = p.priceResolver.GetTokenQuotes(ctx, cmcIDs)
+		if err != nil {
+			return fmt.Errorf("failed to get token markets: %w", err)
+		}
+	}
+	var history []models.Price
+
+	var missing []string
+	for _, cmcID := range cmcIDs {
+		if !p.countsTowardsPoints(cmcID, markets) {
+			p.updateCoinPriceByCMCID(cmcID, 0, pricing.SourceUncounted)
 			continue
 		}
+		if price, ok := recorded[models.CMCAsset(cmcID)]; ok {
+			p.updateCoinPriceByCMCID(cmcID, price.PriceUSD, price.Source)
+			continue
+		}
+		missing = append(missing, strconv.Itoa(cmcID))
 	}
-	cacaoPrice, err := p.priceResolver.GetMidgardCacaoPrices()
-	if err != nil {
-		p.logger.Errorf("failed to get CACAO price: %v", err)
-	} else {
-		if err := p.storage.UpdateCoinPrice(common.MayaChain, "CACAO", cacaoPrice); err != nil {
-			p.logger.Errorf("failed to update CACAO price: %v", err)
+	if len(missing) > 0 {
+		quotes, err := p.oracle.DefaultPrices(ctx, missing)
+		if err != nil {
+			return fmt.Errorf("failed to get all token prices: %w", err)
+		}
+		for id, quote := range quotes {
+			cmcID, _ := strconv.Atoi(id)
+			coin := coins[cmcID]
+			alert := models.PriceAlert{Asset: models.CMCAsset(cmcID), Chain: coin.Chain, Ticker: coin.Ticker, CMCId: cmcID}
+			if p.quarantined(alert, date, quote, stored) {
+				continue
+			}
+			p.updateCoinPriceByCMCID(cmcID, quote.Price, quote.Source)
+			history = append(history, models.Price{Asset: alert.Asset, Date: date, Source: quote.Source, PriceUSD: quote.Price})
 		}
 	}
-	kweenPrice, err := p.priceResolver.GetCoinGeckoPrice("kween", "usd")
-	if err != nil {
-		p.logger.Errorf("failed to get KWEEN price: %v", err)
-	} else {
-		if err := p.storage.UpdateCoinPrice(common.Solana, "KWEEN", kweenPrice); err != nil {
-			p.logger.Errorf("failed to update KWEEN price: %v", err)
+	for _, route := range routes {
+		asset := models.CoinAsset(route.Chain, route.Ticker)
+		price, ok := recorded[asset]
+		quote := pricing.Quote{Price: price.PriceUSD, Source: price.Source}
+		if !ok {
+			quote, err = p.oracle.RoutePrice(ctx, route)
+			if err != nil {
+				p.logger.Errorf("failed to get %s price: %v", route.Ticker, err)
+				p.countError("price")
+				continue
+			}
+			if p.quarantined(models.PriceAlert{Asset: asset, Chain: route.Chain, Ticker: route.Ticker}, date, quote, stored) {
+				continue
+			}
+			history = append(history, models.Price{Asset: asset, Date: date, Source: quote.Source, PriceUSD: quote.Price})
+		}
+		if err := p.storage.UpdateCoinPrice(route.Chain, route.Ticker, quote.Price, quote.Source); err != nil {
+			p.logger.Errorf("failed to update %s price: %v", route.Ticker, err)
+			p.countError("price")
 		}
 	}
 
-	vthorPrice, err := p.priceResolver.GetLiFiPrice("eth", "0x815C23eCA83261b6Ec689b60Cc4a58b54BC24D8D")
-	if err != nil {
-		p.logger.Errorf("failed to get VTHOR price: %v", err)
-	} else {
-		if err := p.storage.UpdateCoinPrice(common.Ethereum, "vTHOR", vthorPrice); err != nil {
-			p.logger.Errorf("failed to update VTHOR price: %v", err)
+	if past {
+		// current prices do not belong to the history of an earlier day
+		if len(history) > 0 {
+			p.logger.Warnf("job %d priced %d assets without a price recorded by %s at their current price", job.ID, len(history), date.Format(time.DateOnly))
 		}
+		return nil
 	}
-	mayaPrice := float64(40)
-	if err := p.storage.UpdateCoinPrice(common.MayaChain, "MAYA", mayaPrice); err != nil {
-		p.logger.Errorf("failed to update VTHOR price: %v", err)
+	if err := p.priceResolver.RecordPrices(history); err != nil {
+		return fmt.Errorf("failed to record prices: %w", err)
 	}
-	tcyPrice, err := p.priceResolver.GetMidgardPrices("THOR.TCY")
-	if err != nil {
-		p.logger.Errorf("failed to get TCY price: %v", err)
-	} else {
-		if err := p.storage.UpdateCoinPrice(common.THORChain, "THOR.TCY", tcyPrice); err != nil {
-			p.logger.Errorf("failed to update TCY price: %v", err)
-		}
+	return nil
+}
+
+// countsTowardsPoints reports whether the coins with the cmc id have the minimum market of the guard
+func (p *PointWorker) countsTowardsPoints(cmcID int, markets map[int]models.TokenQuote) bool {
+	if !p.guard.ChecksMarket() {
+		return true
+	}
+	market, ok := markets[cmcID]
+	if !ok || !p.guard.Counts(market.MarketCap, market.Volume24h) {
+		p.logger.Warnf("cmc id %d is below the minimum market cap and volume, its coins do not count towards points", cmcID)
+		return false
+	}
+	return true
+}
+
+// quarantined reports whether the guard quarantined the fetched price of the asset of the alert, its coins
+// keep their last good price then
+func (p *PointWorker) quarantined(alert models.PriceAlert, date time.Time, quote pricing.Quote, stored map[string]models.Price) bool {
+	previous, ok := stored[alert.Asset]
+	if !ok {
+		return false
 	}
-	rujiraPrice, err := p.priceResolver.GetCoinGeckoPrice("rujira", "usd")
+	alert.Date = date
+	alert.Source = quote.Source
+	alert.PreviousPrice = previous.PriceUSD
+	alert.Price = quote.Price
+	quarantined, err := p.priceResolver.Quarantine(alert)
 	if err != nil {
-		p.logger.Errorf("failed to get Rujira price: %v", err)
-	} else {
-		if err := p.storage.UpdateCoinPrice(common.THORChain, "RUJIRA", rujiraPrice); err != nil {
-			p.logger.Errorf("failed to update Rujira price: %v", err)
-		}
+		p.logger.Error(err)
+		p.countError("price")
 	}
+	if quarantined {
+		p.countError("price_quarantined")
+	}
+	return quarantined
+}
 
-	defer p.logger.Info("finish updating coin prices")
-	return nil
+func (p *PointWorker) updateCoinPriceByCMCID(cmcID int, price float64, source string) {
+	if err := p.storage.UpdateCoinPriceByCMCID(cmcID, price, source); err != nil {
+		p.logger.Errorf("failed to update coin price: %d, err: %v", cmcID, err)
+		p.countError("price")
+	}
 }
 
-func (p *PointWorker) getValidReferralCount(ecdsaKey string, eddsaKey string) (int64, error) {
-	referrals, err := p.referralResolver.GetReferrals(ecdsaKey, eddsaKey)
+func (p *PointWorker) getValidReferralCount(ctx context.Context, ecdsaKey string, eddsaKey string) (int64, error) {
+	referrals, err := p.referralResolver.GetReferrals(ctx, ecdsaKey, eddsaKey)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get referrals: %w", err)
 	}
@@ -680,20 +1291,21 @@ func (p *PointWorker) getValidReferralCount(ecdsaKey string, eddsaKey string) (i
 	return cnt, nil
 }
 
-func (p *PointWorker) getSeasonMultiplierForCoin(coin models.CoinDBModel) float64 {
-	for _, token := range p.cfg.GetCurrentSeason().Tokens {
-		if token.Chain == coin.Chain.String() && token.Name == coin.Ticker && coin.ContractAddress == token.ContractAddress {
-			return token.Multiplier
-		}
+// addressOwners returns the vault credited for the address of each coin
+func (p *PointWorker) addressOwners(coins []models.CoinDBModel) (map[models.AddressKey]uint, error) {
+	keys := make([]models.AddressKey, len(coins))
+	for i, coin := range coins {
+		keys[i] = models.AddressKey{Chain: coin.Chain, Address: coin.Address}
 	}
-	return 1
+	return p.storage.GetAddressOwners(keys)
 }
 
-func (p *PointWorker) getSeasonMultiplierForNFT(coin models.CoinDBModel) float64 {
-	for _, collection := range p.cfg.GetCurrentSeason().NFTs {
-		if collection.Chain == coin.Chain.String() && collection.ContractAddress == coin.ContractAddress {
-			return collection.Multiplier
-		}
+// formula returns the scoring formula of the current season with the multipliers of the tracked assets
+func (p *PointWorker) formula() (scoring.Formula, error) {
+	formula, err := scoring.NewFormula(p.cfg.GetCurrentSeason())
+	if err != nil {
+		return scoring.Formula{}, err
 	}
-	return 1
+	formula.Assets = p.assets.Load()
+	return formula, nil
 }
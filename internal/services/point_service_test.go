@@ -1,9 +1,14 @@
 package services
 
 import (
+	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
 
 	"github.com/vultisig/airdrop-registry/config"
 	"github.com/vultisig/airdrop-registry/internal/common"
@@ -32,10 +37,100 @@ func TestPointService(t *testing.T) {
 	vaultAddress.SetAddress(common.Solana, "CbSjseduYqKiavFxvdeRVH6DBv9Fz4rd59BLAFJz8J9Q")
 	vaultAddress.SetAddress(common.BscChain, "0x562f334890C717f31bAB4c1197C67619FbD0eAFc")
 	for i := 0; i < 1; i++ {
-		newLPValue, err := pointService.fetchPosition(vaultAddress)
+		newLPValue, err := pointService.fetchPosition(context.Background(), vaultAddress, time.Now())
 		if err != nil {
 			t.Error(err)
 		}
 		t.Logf("New LP Value: %v", newLPValue)
 	}
 }
+
+type memoryJobStore struct {
+	jobs []*models.Job
+}
+
+func (s *memoryJobStore) GetLastJob() (*models.Job, error) {
+	var last *models.Job
+	for _, job := range s.jobs {
+		if last == nil || job.JobDate.After(last.JobDate) {
+			last = job
+		}
+	}
+	if last == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return last, nil
+}
+
+func (s *memoryJobStore) CreateJob(job *models.Job) error {
+	for _, existing := range s.jobs {
+		if existing.Date() == job.Date() {
+			return fmt.Errorf("duplicate job of %s", job.Date())
+		}
+	}
+	job.ID = uint(len(s.jobs) + 1)
+	s.jobs = append(s.jobs, job)
+	return nil
+}
+
+func (s *memoryJobStore) GetUnfinishedJob() (*models.Job, error) {
+	var oldest *models.Job
+	for _, job := range s.jobs {
+		if job.IsSuccess || job.IsCancelled {
+			continue
+		}
+		if oldest == nil || job.JobDate.Before(oldest.JobDate) {
+			oldest = job
+		}
+	}
+	return oldest, nil
+}
+
+func TestNextJob(t *testing.T) {
+	worker := PointWorker{logger: logrus.New()}
+	now := time.Now()
+
+	// no job yet
+	store := &memoryJobStore{}
+	job, err := worker.nextJob(store)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), job.ID)
+	assert.Len(t, store.jobs, 1)
+
+	// the last job is unfinished
+	job, err = worker.nextJob(store)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), job.ID)
+
+	// a rerun of an earlier day while today's job succeeded
+	store = &memoryJobStore{jobs: []*models.Job{
+		{Model: gorm.Model{ID: 1}, JobDate: now.AddDate(0, 0, -3)},
+		{Model: gorm.Model{ID: 2}, JobDate: now, IsSuccess: true},
+	}}
+	job, err = worker.nextJob(store)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), job.ID)
+	assert.Len(t, store.jobs, 2)
+
+	// a rerun goes before the job created for today
+	store = &memoryJobStore{jobs: []*models.Job{
+		{Model: gorm.Model{ID: 1}, JobDate: now.AddDate(0, 0, -3)},
+		{Model: gorm.Model{ID: 2}, JobDate: now.AddDate(0, 0, -1), IsSuccess: true},
+	}}
+	job, err = worker.nextJob(store)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), job.ID)
+	assert.Len(t, store.jobs, 3)
+	job, err = store.GetLastJob()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), job.Multiplier)
+
+	// all jobs finished
+	store = &memoryJobStore{jobs: []*models.Job{
+		{Model: gorm.Model{ID: 1}, JobDate: now.AddDate(0, 0, -3), IsCancelled: true},
+		{Model: gorm.Model{ID: 2}, JobDate: now, IsSuccess: true},
+	}}
+	job, err = worker.nextJob(store)
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+var (
+	ErrPriceAlertNotFound   = errors.New("price alert not found")
+	ErrPriceAlertNotPending = errors.New("price alert is not pending")
+)
+
+// SavePriceAlert raises the alert of its asset for its day. A pending alert of that day takes the new price,
+// an approved or rejected one is kept as it is.
+func (s *Storage) SavePriceAlert(alert *models.PriceAlert) error {
+	var existing models.PriceAlert
+	err := s.db.Where("asset = ? AND date = ?", alert.Asset, alert.Date).First(&existing).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		alert.Status = models.PriceAlertPending
+		if err := s.db.Create(alert).Error; err != nil {
+			return fmt.Errorf("failed to create price alert of %s: %w", alert.Asset, err)
+		}
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to get price alert of %s: %w", alert.Asset, err)
+	case existing.Status != models.PriceAlertPending:
+		*alert = existing
+		return nil
+	}
+	alert.ID = existing.ID
+	alert.CreatedAt = existing.CreatedAt
+	alert.Status = models.PriceAlertPending
+	if err := s.db.Save(alert).Error; err != nil {
+		return fmt.Errorf("failed to update price alert %d: %w", alert.ID, err)
+	}
+	return nil
+}
+
+// GetPriceAlerts returns the latest alerts with the status, of any status when empty
+func (s *Storage) GetPriceAlerts(status models.PriceAlertStatus, limit int) ([]models.PriceAlert, error) {
+	var alerts []models.PriceAlert
+	qry := s.db.Order("id DESC").Limit(limit)
+	if status != "" {
+		qry = qry.Where("status = ?", status)
+	}
+	if err := qry.Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("failed to get price alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+// ApprovePriceAlert records the quarantined price for the day of the alert and prices its coins with it,
+// unless a price was recorded for a later day in the meantime
+func (s *Storage) ApprovePriceAlert(id uint) (*models.PriceAlert, error) {
+	return s.resolvePriceAlert(id, models.PriceAlertApproved, func(tx *Storage, alert *models.PriceAlert) error {
+		latest, err := tx.GetPricesAt([]string{alert.Asset}, models.PriceDate(time.Now()))
+		if err != nil {
+			return err
+		}
+		if err := tx.SavePrices([]models.Price{{Asset: alert.Asset, Date: alert.Date, Source: alert.Source, PriceUSD: alert.Price}}); err != nil {
+			return err
+		}
+		if price, ok := latest[alert.Asset]; ok && models.PriceDate(price.Date).After(models.PriceDate(alert.Date)) {
+			return nil
+		}
+		switch {
+		case alert.CMCId != 0:
+			return tx.UpdateCoinPriceByCMCID(alert.CMCId, alert.Price, alert.Source)
+		case alert.Ticker != "":
+			return tx.UpdateCoinPrice(alert.Chain, alert.Ticker, alert.Price, alert.Source)
+		}
+		return nil
+	})
+}
+
+// RejectPriceAlert drops the quarantined price, the coins keep their last good price
+func (s *Storage) RejectPriceAlert(id uint) (*models.PriceAlert, error) {
+	return s.resolvePriceAlert(id, models.PriceAlertRejected, func(tx *Storage, alert *models.PriceAlert) error {
+		return nil
+	})
+}
+
+func (s *Storage) resolvePriceAlert(id uint, status models.PriceAlertStatus, fn func(tx *Storage, alert *models.PriceAlert) error) (*models.PriceAlert, error) {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	var alert models.PriceAlert
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&alert, id).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPriceAlertNotFound
+		}
+		return nil, fmt.Errorf("failed to get price alert %d: %w", id, err)
+	}
+	if alert.Status != models.PriceAlertPending {
+		tx.Rollback()
+		return nil, ErrPriceAlertNotPending
+	}
+	if err := fn(&Storage{db: tx}, &alert); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	now := time.Now()
+	alert.Status = status
+	alert.ResolvedAt = &now
+	if err := tx.Save(&alert).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to resolve price alert %d: %w", id, err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit price alert %d: %w", id, err)
+	}
+	return &alert, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/pricing"
+)
+
+// PriceHistory records the prices of assets by day, and the prices quarantined by the guard
+type PriceHistory interface {
+	SavePrices(prices []models.Price) error
+	GetPricesAt(assets []string, date time.Time) (map[string]models.Price, error)
+	SavePriceAlert(alert *models.PriceAlert) error
+}
+
+// SetPriceHistory makes the resolver answer prices at a date from the history, and record the prices it
+// fetches for today
+func (p *PriceResolver) SetPriceHistory(history PriceHistory) {
+	p.history = history
+}
+
+// SetPriceGuard makes the resolver quarantine the prices the guard rejects
+func (p *PriceResolver) SetPriceGuard(guard pricing.Guard) {
+	p.guard = guard
+}
+
+// Quarantine reports whether the price of the alert moved too far from its previous price, and raises the
+// alert when it did. A quarantined price is neither recorded nor used until an admin approves it.
+func (p *PriceResolver) Quarantine(alert models.PriceAlert) (bool, error) {
+	if !p.guard.Quarantines(alert.PreviousPrice, alert.Price) {
+		return false, nil
+	}
+	alert.Date = models.PriceDate(alert.Date)
+	alert.Change = pricing.Change(alert.PreviousPrice, alert.Price)
+	p.logger.Warnf("quarantined %s price %v from %s, %+.1f%% from its last good price %v",
+		alert.Asset, alert.Price, alert.Source, alert.Change*100, alert.PreviousPrice)
+	if p.history == nil {
+		return true, nil
+	}
+	if err := p.history.SavePriceAlert(&alert); err != nil {
+		return true, fmt.Errorf("failed to raise price alert of %s: %w", alert.Asset, err)
+	}
+	return true, nil
+}
+
+// PricesAt returns the latest price recorded for each asset on or before the date, assets without any are
+// left out
+func (p *PriceResolver) PricesAt(assets []string, date time.Time) (map[string]models.Price, error) {
+	if p.history == nil {
+		return map[string]models.Price{}, nil
+	}
+	return p.history.GetPricesAt(assets, models.PriceDate(date))
+}
+
+// RecordPrices records the prices, for the day of their date
+func (p *PriceResolver) RecordPrices(prices []models.Price) error {
+	if p.history == nil || len(prices) == 0 {
+		return nil
+	}
+	for i := range prices {
+		prices[i].Date = models.PriceDate(prices[i].Date)
+	}
+	return p.history.SavePrices(prices)
+}
+
+// PriceAt returns the price of the asset at the date. The price recorded for that day wins, today is
+// otherwise priced by fetch and recorded, and an earlier day by the latest price recorded before it. An
+// earlier day without any recorded price falls back to fetch without recording it. A fetched price the
+// guard quarantines is replaced by the latest recorded price.
+func (p *PriceResolver) PriceAt(ctx context.Context, asset string, date time.Time, source string, fetch func(ctx context.Context) (float64, error)) (float64, error) {
+	day := models.PriceDate(date)
+	key := fmt.Sprintf("at_%s_%s", asset, day.Format(time.DateOnly))
+	if cached, ok := p.priceCache.Get(key); ok {
+		return cached.(float64), nil
+	}
+	past := day.Before(models.PriceDate(time.Now()))
+	recorded, err := p.PricesAt([]string{asset}, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get recorded price of %s: %w", asset, err)
+	}
+	previous, ok := recorded[asset]
+	if ok && (past || models.PriceDate(previous.Date).Equal(day)) {
+		p.priceCache.Set(key, previous.PriceUSD, time.Hour)
+		return previous.PriceUSD, nil
+	}
+	price, err := fetch(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		quarantined, err := p.Quarantine(models.PriceAlert{Asset: asset, Date: day, Source: source, PreviousPrice: previous.PriceUSD, Price: price})
+		if err != nil {
+			return 0, err
+		}
+		if quarantined {
+			p.priceCache.Set(key, previous.PriceUSD, time.Hour)
+			return previous.PriceUSD, nil
+		}
+	}
+	if past {
+		p.logger.Warnf("no price of %s recorded by %s, using the current price", asset, day.Format(time.DateOnly))
+	} else if err := p.RecordPrices([]models.Price{{Asset: asset, Date: day, Source: source, PriceUSD: price}}); err != nil {
+		return 0, fmt.Errorf("failed to record price of %s: %w", asset, err)
+	}
+	p.priceCache.Set(key, price, time.Hour)
+	return price, nil
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// SavePrices records the prices, replacing the price of the same asset, day and source
+func (s *Storage) SavePrices(prices []models.Price) error {
+	if len(prices) == 0 {
+		return nil
+	}
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "asset"}, {Name: "date"}, {Name: "source"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "price_usd"}),
+	}).Create(&prices).Error
+	if err != nil {
+		return fmt.Errorf("failed to save prices: %w", err)
+	}
+	return nil
+}
+
+// GetPricesAt returns the latest price recorded for each asset on or before the date, assets without any are
+// left out. Of several sources on that day the latest recorded wins.
+func (s *Storage) GetPricesAt(assets []string, date time.Time) (map[string]models.Price, error) {
+	result := make(map[string]models.Price, len(assets))
+	if len(assets) == 0 {
+		return result, nil
+	}
+	var prices []models.Price
+	latest := s.db.Model(&models.Price{}).Select("asset, MAX(date) AS date").
+		Where("asset IN ? AND date <= ?", assets, date).Group("asset")
+	err := s.db.Model(&models.Price{}).
+		Joins("JOIN (?) AS latest ON latest.asset = prices.asset AND latest.date = prices.date", latest).
+		Order("prices.updated_at DESC, prices.id DESC").
+		Find(&prices).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prices at %s: %w", date.Format("2006-01-02"), err)
+	}
+	for _, price := range prices {
+		if _, ok := result[price.Asset]; !ok {
+			result[price.Asset] = price
+		}
+	}
+	return result, nil
+}
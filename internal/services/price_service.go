@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,6 +16,8 @@ import (
 
 	"github.com/vultisig/airdrop-registry/config"
 	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/pricing"
+	"github.com/vultisig/airdrop-registry/internal/utils"
 )
 
 const CMC_Base_URL = "https://api.vultisig.com/cmc/"
@@ -27,6 +30,8 @@ type PriceResolver struct {
 	midgardBaseURL       string
 	priceCache           cache.Cache
 	OpenSeaAPIKey        string
+	history              PriceHistory
+	guard                pricing.Guard
 }
 
 func NewPriceResolver(cfg *config.Config) (*PriceResolver, error) {
@@ -38,7 +43,7 @@ func NewPriceResolver(cfg *config.Config) (*PriceResolver, error) {
 		priceCache:           *cache.New(4*time.Hour, 5*time.Hour),
 		OpenSeaAPIKey:        cfg.OpenSea.APIKey,
 	}
-	result, err := pr.getCMCMap()
+	result, err := pr.getCMCMap(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("fail to get CMC map,err: %w", err)
 	}
@@ -65,9 +70,9 @@ type CmcMapResp struct {
 	Data []CmcMapItem `json:"data"`
 }
 
-func (p *PriceResolver) getCMCMap() (*CmcMapResp, error) {
+func (p *PriceResolver) getCMCMap(ctx context.Context) (*CmcMapResp, error) {
 	url := CMC_Base_URL + "/v1/cryptocurrency/map"
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		p.logger.Error(err)
 		return nil, fmt.Errorf("fail to get map from CMC,err: %w", err)
@@ -101,13 +106,13 @@ func (p *PriceResolver) resolveIds(coinIds []models.CoinIdentity) string {
 	}
 	return strings.Join(ids, ",")
 }
-func (p *PriceResolver) GetCoinGeckoPrice(priceProviderId string, currency string) (float64, error) {
+func (p *PriceResolver) GetCoinGeckoPrice(ctx context.Context, priceProviderId string, currency string) (float64, error) {
 	cacheKey := fmt.Sprintf("cg_%s_%s", priceProviderId, currency)
 	if cachedPrice, ok := p.priceCache.Get(cacheKey); ok {
 		return cachedPrice.(float64), nil
 	}
 	url := fmt.Sprintf("%s?ids=%s&vs_currencies=%s", p.coingeckoBaseAddress, priceProviderId, currency)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		p.logger.Error(err)
 		return 0, fmt.Errorf("fail to get price from CoinGecko,err: %w", err)
@@ -130,9 +135,9 @@ func (p *PriceResolver) GetCoinGeckoPrice(priceProviderId string, currency strin
 	return 0, fmt.Errorf("price not found in response")
 }
 
-func (p *PriceResolver) GetLiFiPrice(chain, contractAddress string) (float64, error) {
+func (p *PriceResolver) GetLiFiPrice(ctx context.Context, chain, contractAddress string) (float64, error) {
 	url := fmt.Sprintf("%s/v1/token?chain=%s&token=%s", p.lifiBaseAddress, chain, contractAddress)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		p.logger.Error(err)
 		return 0, fmt.Errorf("fail to get price from LiQuest,err: %w", err)
@@ -160,12 +165,12 @@ func (p *PriceResolver) GetLiFiPrice(chain, contractAddress string) (float64, er
 	}
 	return price, nil
 }
-func (p *PriceResolver) GetMidgardCacaoPrices() (float64, error) {
+func (p *PriceResolver) GetMidgardCacaoPrices(ctx context.Context) (float64, error) {
 	if cachedPrice, ok := p.priceCache.Get("midgard_cacao"); ok {
 		return cachedPrice.(float64), nil
 	}
 	// fetch from https://midgard.mayachain.info/v2/debug/usd
-	resp, err := http.Get("https://midgard.mayachain.info/v2/debug/usd")
+	resp, err := utils.HTTPGet(ctx, "https://midgard.mayachain.info/v2/debug/usd")
 	if err != nil {
 		p.logger.Error(err)
 		return 0, fmt.Errorf("fail to get price from Midgard,err: %w", err)
@@ -200,10 +205,33 @@ func (p *PriceResolver) GetMidgardCacaoPrices() (float64, error) {
 	}
 	return 0, fmt.Errorf("price not found in response")
 }
-func (p *PriceResolver) GetAllTokenPrices(coinIds []models.CoinIdentity) (map[int]float64, error) {
-	strIds := p.resolveIds(coinIds)
+func (p *PriceResolver) GetAllTokenPrices(ctx context.Context, coinIds []models.CoinIdentity) (map[int]float64, error) {
+	quotes, err := p.getQuotes(ctx, p.resolveIds(coinIds))
+	if err != nil {
+		return nil, err
+	}
+	priceMap := make(map[int]float64, len(quotes))
+	for id, quote := range quotes {
+		priceMap[id] = quote.Price
+	}
+	return priceMap, nil
+}
+
+// GetTokenQuotes returns the symbol, price, market cap and volume of the CMC ids, ids CMC does not know are left out
+func (p *PriceResolver) GetTokenQuotes(ctx context.Context, cmcIDs []int) (map[int]models.TokenQuote, error) {
+	if len(cmcIDs) == 0 {
+		return map[int]models.TokenQuote{}, nil
+	}
+	ids := make([]string, len(cmcIDs))
+	for i, id := range cmcIDs {
+		ids[i] = strconv.Itoa(id)
+	}
+	return p.getQuotes(ctx, strings.Join(ids, ","))
+}
+
+func (p *PriceResolver) getQuotes(ctx context.Context, strIds string) (map[int]models.TokenQuote, error) {
 	url := CMC_Base_URL + "/v2/cryptocurrency/quotes/latest?id=" + strIds
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		p.logger.Error(err)
 		return nil, fmt.Errorf("fail to get prices from CMC,err: %w", err)
@@ -220,7 +248,9 @@ func (p *PriceResolver) GetAllTokenPrices(coinIds []models.CoinIdentity) (map[in
 			Slug   string `json:"slug"`
 			Quote  struct {
 				USD struct {
-					Price float64 `json:"price"`
+					Price     float64 `json:"price"`
+					MarketCap float64 `json:"market_cap"`
+					Volume24h float64 `json:"volume_24h"`
 				} `json:"USD"`
 			} `json:"quote"`
 		} `json:"data"`
@@ -229,11 +259,17 @@ func (p *PriceResolver) GetAllTokenPrices(coinIds []models.CoinIdentity) (map[in
 	if err := json.NewDecoder(resp.Body).Decode(&cmcQuoteResp); err != nil {
 		return nil, fmt.Errorf("error decoding CMC quote response: %w", err)
 	}
-	priceMap := make(map[int]float64)
+	quotes := make(map[int]models.TokenQuote)
 	for _, item := range cmcQuoteResp.Data {
-		priceMap[item.ID] = item.Quote.USD.Price
+		quotes[item.ID] = models.TokenQuote{
+			CMCId:     item.ID,
+			Symbol:    item.Symbol,
+			Price:     item.Quote.USD.Price,
+			MarketCap: item.Quote.USD.MarketCap,
+			Volume24h: item.Quote.USD.Volume24h,
+		}
 	}
-	return priceMap, nil
+	return quotes, nil
 }
 
 type OpenSeaBestCollectionResponse struct {
@@ -248,7 +284,7 @@ type OpenSeaBestCollectionResponse struct {
 	} `json:"listings"`
 }
 
-func (p *PriceResolver) GetOpenSeaCollectionMinPrice(collectionSlug string) (float64, error) {
+func (p *PriceResolver) GetOpenSeaCollectionMinPrice(ctx context.Context, collectionSlug string) (float64, error) {
 	key := fmt.Sprintf("opensea_%s", collectionSlug)
 	//check cache first
 	if cached, ok := p.priceCache.Get(key); ok {
@@ -264,7 +300,7 @@ func (p *PriceResolver) GetOpenSeaCollectionMinPrice(collectionSlug string) (flo
 		return 0, fmt.Errorf("failed to get collection from OpenSea,err: %w", err)
 	}
 	req.Header.Add("x-api-key", p.OpenSeaAPIKey)
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := utils.HTTPDo(ctx, req)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get collection from OpenSea,err: %w", err)
 	}
@@ -296,7 +332,7 @@ func (p *PriceResolver) GetOpenSeaCollectionMinPrice(collectionSlug string) (flo
 	}
 
 	//pricePerEth := float64(openseaResp.Listings[0].Price.Current.Value) / 1e18
-	priceMap, err := p.GetAllTokenPrices([]models.CoinIdentity{
+	priceMap, err := p.GetAllTokenPrices(ctx, []models.CoinIdentity{
 		models.CoinIdentity{
 			CMCId: 1027,
 		},
@@ -312,13 +348,13 @@ func (p *PriceResolver) GetOpenSeaCollectionMinPrice(collectionSlug string) (flo
 	return 0, fmt.Errorf("ETH price not found in response")
 }
 
-func (p *PriceResolver) GetMidgardPrices(asset string) (float64, error) {
+func (p *PriceResolver) GetMidgardPrices(ctx context.Context, asset string) (float64, error) {
 	if cachedPrice, ok := p.priceCache.Get("midgard_" + asset); ok {
 		return cachedPrice.(float64), nil
 	}
 	url := fmt.Sprintf("%s/v2/pools", p.midgardBaseURL)
 
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		return 0, fmt.Errorf("failed to fetch pools from %s: %w", url, err)
 	}
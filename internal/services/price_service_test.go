@@ -1,9 +1,11 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 	"time"
 
@@ -11,6 +13,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/vultisig/airdrop-registry/config"
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/pricing"
 )
 
 func TestGetCMCMap(t *testing.T) {
@@ -42,7 +46,7 @@ func TestGetLifiPrice(t *testing.T) {
 		lifiBaseAddress: mockServer.URL,
 	}
 
-	price, err := priceResolver.GetLiFiPrice("eth", "0x815C23eCA83261b6Ec689b60Cc4a58b54BC24D8D")
+	price, err := priceResolver.GetLiFiPrice(context.Background(), "eth", "0x815C23eCA83261b6Ec689b60Cc4a58b54BC24D8D")
 	if err != nil {
 		t.Fatalf("Failed to get VThor price: %v", err)
 	}
@@ -69,11 +73,11 @@ func TestCacaoPrice(t *testing.T) {
 		coingeckoBaseAddress: mockServer.URL,
 	}
 
-	price, err := priceResolver.GetCoinGeckoPrice("cacao", "usd")
+	price, err := priceResolver.GetCoinGeckoPrice(context.Background(), "cacao", "usd")
 	assert.NoErrorf(t, err, "Failed to get CACAO price: %v", err)
 	assert.Equal(t, float64(0.5), price)
 
-	price, err = priceResolver.GetCoinGeckoPrice("CACAO", "USD")
+	price, err = priceResolver.GetCoinGeckoPrice(context.Background(), "CACAO", "USD")
 	assert.EqualError(t, err, "price not found in response")
 	assert.Equal(t, float64(0), price)
 }
@@ -99,11 +103,125 @@ func TestRujiraPrice(t *testing.T) {
 		coingeckoBaseAddress: mockServer.URL,
 	}
 
-	price, err := priceResolver.GetCoinGeckoPrice("rujira", "usd")
+	price, err := priceResolver.GetCoinGeckoPrice(context.Background(), "rujira", "usd")
 	assert.NoErrorf(t, err, "Failed to get Rujira price: %v", err)
 	assert.Equal(t, float64(0.425753), price)
 
-	price, err = priceResolver.GetCoinGeckoPrice("ruji", "usd")
+	price, err = priceResolver.GetCoinGeckoPrice(context.Background(), "ruji", "usd")
 	assert.EqualError(t, err, "price not found in response")
 	assert.Equal(t, float64(0), price)
 }
+
+// memoryPriceHistory keeps the recorded prices and alerts in memory
+type memoryPriceHistory struct {
+	prices []models.Price
+	alerts []models.PriceAlert
+}
+
+func (h *memoryPriceHistory) SavePriceAlert(alert *models.PriceAlert) error {
+	alert.Status = models.PriceAlertPending
+	h.alerts = append(h.alerts, *alert)
+	return nil
+}
+
+func (h *memoryPriceHistory) SavePrices(prices []models.Price) error {
+	h.prices = append(h.prices, prices...)
+	return nil
+}
+
+func (h *memoryPriceHistory) GetPricesAt(assets []string, date time.Time) (map[string]models.Price, error) {
+	result := make(map[string]models.Price)
+	for _, price := range h.prices {
+		if !slices.Contains(assets, price.Asset) || price.Date.After(date) {
+			continue
+		}
+		if latest, ok := result[price.Asset]; !ok || !price.Date.Before(latest.Date) {
+			result[price.Asset] = price
+		}
+	}
+	return result, nil
+}
+
+func TestPriceAt(t *testing.T) {
+	today := models.PriceDate(time.Now())
+	history := &memoryPriceHistory{prices: []models.Price{
+		{Asset: "opensea:thorguards", Date: today.AddDate(0, 0, -10), Source: "opensea", PriceUSD: 100},
+		{Asset: "opensea:thorguards", Date: today.AddDate(0, 0, -5), Source: "opensea", PriceUSD: 120},
+	}}
+	priceResolver := &PriceResolver{
+		logger:     logrus.WithField("module", "price_resolver").Logger,
+		priceCache: *cache.New(4*time.Minute, 5*time.Minute),
+	}
+	priceResolver.SetPriceHistory(history)
+	fetches := 0
+	fetch := func(ctx context.Context) (float64, error) {
+		fetches++
+		return 150, nil
+	}
+	ctx := context.Background()
+
+	// an earlier day takes the latest price recorded by then
+	price, err := priceResolver.PriceAt(ctx, "opensea:thorguards", today.AddDate(0, 0, -7), "opensea", fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(100), price)
+	price, err = priceResolver.PriceAt(ctx, "opensea:thorguards", today.AddDate(0, 0, -1), "opensea", fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(120), price)
+	assert.Equal(t, 0, fetches)
+
+	// today is fetched and recorded, a rerun of today uses the recorded price
+	price, err = priceResolver.PriceAt(ctx, "opensea:thorguards", time.Now(), "opensea", fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(150), price)
+	assert.Equal(t, 1, fetches)
+	assert.Len(t, history.prices, 3)
+	assert.Equal(t, today, history.prices[2].Date)
+	priceResolver.priceCache.Flush()
+	price, err = priceResolver.PriceAt(ctx, "opensea:thorguards", time.Now(), "opensea", fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(150), price)
+	assert.Equal(t, 1, fetches)
+
+	// an earlier day without a recorded price falls back to the current price, without recording it
+	price, err = priceResolver.PriceAt(ctx, "THORChain:THOR.TCY", today.AddDate(0, 0, -1), "midgard", fetch)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(150), price)
+	assert.Equal(t, 2, fetches)
+	assert.Len(t, history.prices, 3)
+}
+
+func TestPriceAtQuarantine(t *testing.T) {
+	today := models.PriceDate(time.Now())
+	history := &memoryPriceHistory{prices: []models.Price{
+		{Asset: "THORChain:THOR.TCY", Date: today.AddDate(0, 0, -1), Source: "midgard", PriceUSD: 0.2},
+	}}
+	guard, err := pricing.NewGuard(config.PriceGuard{MaxChange: 0.5})
+	assert.NoError(t, err)
+	priceResolver := &PriceResolver{
+		logger:     logrus.WithField("module", "price_resolver").Logger,
+		priceCache: *cache.New(4*time.Minute, 5*time.Minute),
+	}
+	priceResolver.SetPriceHistory(history)
+	priceResolver.SetPriceGuard(guard)
+
+	// a tenfold price keeps the last good price and raises an alert
+	price, err := priceResolver.PriceAt(context.Background(), "THORChain:THOR.TCY", time.Now(), "midgard", func(ctx context.Context) (float64, error) {
+		return 2, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.2, price)
+	assert.Len(t, history.prices, 1)
+	assert.Len(t, history.alerts, 1)
+	assert.Equal(t, models.PriceAlert{Asset: "THORChain:THOR.TCY", Date: today, Source: "midgard", PreviousPrice: 0.2, Price: 2,
+		Change: 9, Status: models.PriceAlertPending}, history.alerts[0])
+
+	// a move within the maximum change is recorded
+	priceResolver.priceCache.Flush()
+	price, err = priceResolver.PriceAt(context.Background(), "THORChain:THOR.TCY", time.Now(), "midgard", func(ctx context.Context) (float64, error) {
+		return 0.25, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.25, price)
+	assert.Len(t, history.prices, 2)
+	assert.Len(t, history.alerts, 1)
+}
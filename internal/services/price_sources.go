@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vultisig/airdrop-registry/internal/pricing"
+)
+
+// PriceSources returns the price sources backed by the resolver
+func (p *PriceResolver) PriceSources() []pricing.PriceSource {
+	return []pricing.PriceSource{
+		pricing.NewBatchSource(pricing.SourceCMC, p.cmcPrices),
+		pricing.NewSingleSource(pricing.SourceCoinGecko, func(ctx context.Context, id string) (float64, error) {
+			return p.GetCoinGeckoPrice(ctx, id, "usd")
+		}),
+		pricing.NewSingleSource(pricing.SourceLiFi, func(ctx context.Context, id string) (float64, error) {
+			chain, contract, ok := strings.Cut(id, ":")
+			if !ok {
+				return 0, fmt.Errorf("invalid lifi id %q, expected chain:contract", id)
+			}
+			return p.GetLiFiPrice(ctx, chain, contract)
+		}),
+		pricing.NewSingleSource(pricing.SourceMidgard, p.GetMidgardPrices),
+		pricing.NewSingleSource(pricing.SourceMayaMidgard, func(ctx context.Context, id string) (float64, error) {
+			return p.GetMidgardCacaoPrices(ctx)
+		}),
+	}
+}
+
+// cmcPrices quotes the cmc ids in a single call
+func (p *PriceResolver) cmcPrices(ctx context.Context, ids []string) (map[string]float64, error) {
+	cmcIDs := make([]int, 0, len(ids))
+	for _, id := range ids {
+		cmcID, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cmc id %q: %w", id, err)
+		}
+		cmcIDs = append(cmcIDs, cmcID)
+	}
+	quotes, err := p.GetTokenQuotes(ctx, cmcIDs)
+	if err != nil {
+		return nil, err
+	}
+	prices := make(map[string]float64, len(quotes))
+	for id, quote := range quotes {
+		prices[strconv.Itoa(id)] = quote.Price
+	}
+	return prices, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// GetQuests returns the quests stored in the quests table, disabled ones included
+func (s *Storage) GetQuests() ([]models.Quest, error) {
+	var quests []models.Quest
+	if err := s.db.Order("id").Find(&quests).Error; err != nil {
+		return nil, fmt.Errorf("failed to get quests: %w", err)
+	}
+	return quests, nil
+}
+
+// SaveQuest stores the quest, replacing the stored quest with its slug
+func (s *Storage) SaveQuest(quest *models.Quest) error {
+	err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "slug"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "rule", "chain", "ticker", "contract_address", "min_amount",
+			"registered_before", "api_keys", "allowed_ips", "response", "points", "disabled"}),
+	}).Create(quest).Error
+	if err != nil {
+		return fmt.Errorf("failed to save quest %s: %w", quest.Slug, err)
+	}
+	return nil
+}
+
+// AddQuestVerification records a call to the verification endpoint of a quest
+func (s *Storage) AddQuestVerification(verification *models.QuestVerification) error {
+	if err := s.db.Create(verification).Error; err != nil {
+		return fmt.Errorf("failed to add quest verification: %w", err)
+	}
+	return nil
+}
+
+// GetQuestVerifications returns up to limit verifications of the quest with an id below beforeID, newest
+// first, a zero beforeID starts from the newest
+func (s *Storage) GetQuestVerifications(slug string, beforeID uint, limit int) ([]models.QuestVerification, error) {
+	var verifications []models.QuestVerification
+	qry := s.db.Where("quest_slug = ?", slug)
+	if beforeID > 0 {
+		qry = qry.Where("id < ?", beforeID)
+	}
+	if err := qry.Order("id DESC").Limit(limit).Find(&verifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to get verifications of quest %s: %w", slug, err)
+	}
+	return verifications, nil
+}
+
+// CompleteQuest records the vault as having completed the quest and adds the points of the quest to the
+// vault the first time only. It reports whether this was the first completion.
+func (s *Storage) CompleteQuest(slug string, vaultID uint, points float64) (bool, error) {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return false, fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	completion := models.QuestCompletion{QuestSlug: slug, VaultID: vaultID, Points: points}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&completion)
+	if result.Error != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("failed to complete quest %s for vault %d: %w", slug, vaultID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return false, nil
+	}
+	if points > 0 {
+		qry := `UPDATE vaults SET total_points = total_points + ? WHERE id = ?`
+		if err := tx.Exec(qry, points, vaultID).Error; err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("failed to add quest points to vault %d: %w", vaultID, err)
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		return false, fmt.Errorf("failed to commit quest %s for vault %d: %w", slug, vaultID, err)
+	}
+	return true, nil
+}
+
+// GetVaultQuestPoints returns the points the vault was awarded for quests completed in [from, to)
+func (s *Storage) GetVaultQuestPoints(vaultID uint, from, to time.Time) (float64, error) {
+	var points float64
+	err := s.db.Model(&models.QuestCompletion{}).
+		Select("COALESCE(SUM(points), 0)").
+		Where("vault_id = ? AND created_at >= ? AND created_at < ?", vaultID, from, to).
+		Scan(&points).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get quest points of vault %d: %w", vaultID, err)
+	}
+	return points, nil
+}
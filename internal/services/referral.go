@@ -2,12 +2,14 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/sirupsen/logrus"
 	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/utils"
 )
 
 type ReferralResolverService struct {
@@ -24,7 +26,7 @@ func NewReferralResolverService(baseAddress, apiKey string) *ReferralResolverSer
 	}
 }
 
-func (v *ReferralResolverService) GetReferrals(ecdsaKey string, eddsaKey string) ([]models.Referral, error) {
+func (v *ReferralResolverService) GetReferrals(ctx context.Context, ecdsaKey string, eddsaKey string) ([]models.Referral, error) {
 	url := fmt.Sprintf("%s/user/referrals?eddsaKey=%s&ecdsaKey=%s&apiKey=%s",
 		v.baseAddress,
 		eddsaKey,
@@ -32,7 +34,7 @@ func (v *ReferralResolverService) GetReferrals(ecdsaKey string, eddsaKey string)
 		v.apiKey,
 	)
 
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		v.logger.WithError(err).Error("Failed to fetch referrals from API")
 		return nil, err
@@ -55,7 +57,7 @@ func (v *ReferralResolverService) GetReferrals(ecdsaKey string, eddsaKey string)
 	return apiResponse.Items, nil
 }
 
-func (v *ReferralResolverService) GetAllAchievements(achievementsRequest models.AchievementsRequest) ([]models.AchievementsResponse, error) {
+func (v *ReferralResolverService) GetAllAchievements(ctx context.Context, achievementsRequest models.AchievementsRequest) ([]models.AchievementsResponse, error) {
 	url := fmt.Sprintf("%s/achievements/list",
 		v.baseAddress,
 	)
@@ -65,7 +67,7 @@ func (v *ReferralResolverService) GetAllAchievements(achievementsRequest models.
 		return nil, fmt.Errorf("error marshalling request: %w", err)
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(reqBody))
+	resp, err := utils.HTTPPost(ctx, url, "application/json", bytes.NewBuffer(reqBody))
 	if err != nil {
 		v.logger.WithError(err).Error("Failed to fetch from API")
 		return nil, err
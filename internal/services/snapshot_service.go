@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// WriteDailySnapshots records the current state of every vault and every coin with a balance for the
+// date of the job. Writing them again on the same day overwrites the snapshots of the date, a job of an
+// earlier day only adds the snapshots its date is missing so the ones taken on that day are kept.
+func (s *Storage) WriteDailySnapshots(job *models.Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	date := job.Date()
+	overwrite := date >= time.Now().Format(time.DateOnly)
+	vaultUpdate := " ON DUPLICATE KEY UPDATE updated_at = NOW(), job_id = VALUES(job_id), balance = VALUES(balance), lp_value = VALUES(lp_value)," +
+		" nft_value = VALUES(nft_value), swap_volume = VALUES(swap_volume), total_points = VALUES(total_points), `rank` = VALUES(`rank`)"
+	coinUpdate := ` ON DUPLICATE KEY UPDATE updated_at = NOW(), job_id = VALUES(job_id), balance = VALUES(balance),
+			price_usd = VALUES(price_usd), usd_value = VALUES(usd_value)`
+	if !overwrite {
+		vaultUpdate = " ON DUPLICATE KEY UPDATE id = id"
+		coinUpdate = " ON DUPLICATE KEY UPDATE id = id"
+	}
+	qry := "INSERT INTO vault_daily_snapshots (created_at, updated_at, vault_id, date, job_id, balance, lp_value, nft_value, swap_volume, total_points, `rank`)" +
+		" SELECT NOW(), NOW(), id, ?, ?, balance, lp_value, nft_value, swap_volume, total_points, `rank` FROM vaults WHERE deleted_at IS NULL" +
+		vaultUpdate
+	if err := s.db.WithContext(ctx).Exec(qry, date, job.ID).Error; err != nil {
+		return fmt.Errorf("failed to write vault snapshots: %w", err)
+	}
+	// balances and prices are stored as strings on coins
+	qry = `INSERT INTO coin_daily_snapshots (created_at, updated_at, coin_id, vault_id, date, job_id, balance, price_usd, usd_value)
+		SELECT NOW(), NOW(), id, vault_id, ?, ?,
+			CAST(COALESCE(NULLIF(balance, ''), '0') AS DECIMAL(65,30)),
+			CAST(COALESCE(NULLIF(price_usd, ''), '0') AS DECIMAL(65,30)),
+			CAST(COALESCE(NULLIF(usd_value, ''), '0') AS DECIMAL(65,30))
+		FROM coins WHERE deleted_at IS NULL AND balance <> '' AND balance <> '0'` + coinUpdate
+	if err := s.db.WithContext(ctx).Exec(qry, date, job.ID).Error; err != nil {
+		return fmt.Errorf("failed to write coin snapshots: %w", err)
+	}
+	return nil
+}
+
+// GetVaultSnapshots returns the snapshots of the vault dated within [from, to], oldest first
+func (s *Storage) GetVaultSnapshots(vaultID uint, from, to time.Time) ([]models.VaultDailySnapshot, error) {
+	var snapshots []models.VaultDailySnapshot
+	if err := s.db.Where("vault_id = ? AND date >= ? AND date <= ?", vaultID, from, to).Order("date").Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("failed to get snapshots for vault %d: %w", vaultID, err)
+	}
+	return snapshots, nil
+}
+
+// GetCoinSnapshots returns the snapshots of the coin dated within [from, to], oldest first
+func (s *Storage) GetCoinSnapshots(coinID uint, from, to time.Time) ([]models.CoinDailySnapshot, error) {
+	var snapshots []models.CoinDailySnapshot
+	if err := s.db.Where("coin_id = ? AND date >= ? AND date <= ?", coinID, from, to).Order("date").Find(&snapshots).Error; err != nil {
+		return nil, fmt.Errorf("failed to get snapshots for coin %d: %w", coinID, err)
+	}
+	return snapshots, nil
+}
@@ -1,15 +1,19 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 
 	"github.com/vultisig/airdrop-registry/config"
 	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/tokens"
 )
 
 type Storage struct {
@@ -20,27 +24,61 @@ func NewStorage(cfg *config.Config) (*Storage, error) {
 	if nil == cfg {
 		return nil, fmt.Errorf("config is nil")
 	}
-	mysqlConfig := cfg.MySQL
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		mysqlConfig.User, mysqlConfig.Password, mysqlConfig.Host, mysqlConfig.Port, mysqlConfig.Database)
-
-	database, err := gorm.Open(mysql.Open(dsn),
-		&gorm.Config{
-			Logger: logger.Default.LogMode(logger.Error),
-		})
+	database, err := openDatabase(cfg, cfg.MySQL.User, cfg.MySQL.Password, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
 
-	err = database.AutoMigrate(&models.Vault{}, &models.CoinDBModel{}, &models.Job{}, &models.VaultShareAppearance{}, &models.VaultSeasonStats{})
+	err = database.AutoMigrate(&models.Vault{}, &models.CoinDBModel{}, &models.Job{}, &models.VaultShareAppearance{}, &models.VaultSeasonStats{}, &models.PointEvent{}, &models.JobItem{}, &models.JobShard{}, &models.JobPhase{}, &models.VaultDailySnapshot{}, &models.CoinDailySnapshot{}, &models.BalanceSample{}, &models.SampleRound{}, &models.AddressOwner{}, &models.AddressCollision{}, &models.DerivedAddress{}, &models.Quest{}, &models.QuestVerification{}, &models.QuestCompletion{}, &models.DiscoveredCoin{}, &models.TrackedAsset{}, &models.Price{}, &models.PriceAlert{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	s := &Storage{db: database}
+	assets, err := tokens.PredefinedTrackedAssets()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.SeedTrackedAssets(assets); err != nil {
+		return nil, err
+	}
+
 	log.Println("connected to mysql database")
+	return s, nil
+}
+
+// NewReadOnlyStorage connects for tools that only read, it neither migrates nor seeds the database. The
+// session is read only, and connects as the read only user when one is configured.
+func NewReadOnlyStorage(cfg *config.Config) (*Storage, error) {
+	if nil == cfg {
+		return nil, fmt.Errorf("config is nil")
+	}
+	user, password := cfg.MySQL.User, cfg.MySQL.Password
+	if cfg.MySQL.ReadOnlyUser != "" {
+		user, password = cfg.MySQL.ReadOnlyUser, cfg.MySQL.ReadOnlyPassword
+	}
+	database, err := openDatabase(cfg, user, password, "&transaction_read_only=1")
+	if err != nil {
+		return nil, err
+	}
 	return &Storage{db: database}, nil
 }
 
+func openDatabase(cfg *config.Config, user, password, params string) (*gorm.DB, error) {
+	mysqlConfig := cfg.MySQL
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local%s",
+		user, password, mysqlConfig.Host, mysqlConfig.Port, mysqlConfig.Database, params)
+
+	database, err := gorm.Open(mysql.Open(dsn),
+		&gorm.Config{
+			Logger: logger.Default.LogMode(logger.Error),
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return database, nil
+}
+
 func (s *Storage) Close() error {
 	sqlDB, err := s.db.DB()
 	if err != nil {
@@ -60,13 +98,22 @@ func (s *Storage) CreateJob(job *models.Job) error {
 
 func (s *Storage) GetLastJob() (*models.Job, error) {
 	var job models.Job
-	result := s.db.Model(&models.Job{}).Last(&job)
+	// jobs rerun for a past date are created after newer ones
+	result := s.db.Model(&models.Job{}).Order("job_date DESC").First(&job)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return &job, nil
 }
 
+func (s *Storage) GetJob(id uint) (*models.Job, error) {
+	var job models.Job
+	if err := s.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
 func (s *Storage) UpdateJob(job *models.Job) error {
 	result := s.db.Save(job)
 	if result.Error != nil {
@@ -75,10 +122,10 @@ func (s *Storage) UpdateJob(job *models.Job) error {
 	return nil
 }
 
-// Get last time volume fetch successful
-func (c *Storage) GetLastVolumeFetch() (*models.Job, error) {
+// Get last time volume fetch successful before the given job
+func (c *Storage) GetLastVolumeFetch(before *models.Job) (*models.Job, error) {
 	var job models.Job
-	result := c.db.Model(&models.Job{}).Where("is_volume_fetched = ?", true).Order("job_date DESC").First(&job)
+	result := c.db.Model(&models.Job{}).Where("is_volume_fetched = ? AND job_date < ?", true, before.Date()).Order("job_date DESC").First(&job)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -94,6 +141,75 @@ func (s *Storage) UpdateIsVolumeFetched(job *models.Job) error {
 	return nil
 }
 
+// MarkJobSuccess flags the job as finished without overwriting fields other instances may have updated
+func (s *Storage) MarkJobSuccess(job *models.Job) error {
+	if err := s.db.Model(job).Update("is_success", true).Error; err != nil {
+		return fmt.Errorf("failed to mark job %d success: %w", job.ID, err)
+	}
+	return nil
+}
+
+// CancelJob stops every instance from working on the job, the scheduler moves on to the next day's job
+func (s *Storage) CancelJob(job *models.Job) error {
+	if err := s.db.Model(job).Update("is_cancelled", true).Error; err != nil {
+		return fmt.Errorf("failed to cancel job %d: %w", job.ID, err)
+	}
+	return nil
+}
+
+// ResetJob prepares the unfinished job of the date, such as a cancelled job, to run again. Its phases and
+// shards are created again when it starts, while the items it already processed are kept so vaults and
+// coins are not credited twice. Successful jobs are refused since every item is already credited, and so
+// are dates without a job since the multiplier of the next job already covers them.
+func (s *Storage) ResetJob(date time.Time) (*models.Job, error) {
+	tx := s.db.Begin()
+	if tx.Error != nil {
+		return nil, fmt.Errorf("failed to start tx: %w", tx.Error)
+	}
+	var job models.Job
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("job_date = ?", date.Format(time.DateOnly)).First(&job).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to get job of %s: %w", date.Format(time.DateOnly), err)
+	}
+	if job.IsSuccess {
+		tx.Rollback()
+		return nil, ErrJobSucceeded
+	}
+	if err := tx.Model(&job).Update("is_cancelled", false).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to reset job %d: %w", job.ID, err)
+	}
+	if err := tx.Unscoped().Where("job_id = ?", job.ID).Delete(&models.JobShard{}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to delete shards of job %d: %w", job.ID, err)
+	}
+	if err := tx.Unscoped().Where("job_id = ?", job.ID).Delete(&models.JobPhase{}).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to delete phases of job %d: %w", job.ID, err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("failed to commit job %d: %w", job.ID, err)
+	}
+	return &job, nil
+}
+
+// GetUnfinishedJob returns the oldest job that neither succeeded nor was cancelled, or nil. A job of an
+// earlier day is unfinished while it is rerun.
+func (s *Storage) GetUnfinishedJob() (*models.Job, error) {
+	var job models.Job
+	err := s.db.Where("is_success = ? AND is_cancelled = ?", false, false).Order("job_date").First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get unfinished job: %w", err)
+	}
+	return &job, nil
+}
+
 // UpdateVaultRanks recalculates and updates the rank for all vaults with join_airdrop = 1,
 // ensuring ranks are consecutive and sorted by total_points in descending order.
 func (s *Storage) UpdateVaultRanks() error {
@@ -119,12 +235,11 @@ func (s *Storage) UpdateVaultBalance() error {
 	return s.db.Exec(sql).Error
 }
 
-func (s *Storage) UpdateVaultTotalPoints() error {
-	sql := `
-        UPDATE vaults
-        SET 
-            total_points = total_points + SQRT(total_vault_value),
-            total_vault_value = 0
-    `
-	return s.db.Exec(sql).Error
+// AddVaultTotalPoints adds the points of the value the vault accrued and resets the accrued value
+func (s *Storage) AddVaultTotalPoints(vaultID uint, points float64) error {
+	qry := `UPDATE vaults SET total_points = total_points + ?, total_vault_value = 0 WHERE id = ?`
+	if err := s.db.Exec(qry, points, vaultID).Error; err != nil {
+		return fmt.Errorf("failed to update vault total points: %w", err)
+	}
+	return nil
 }
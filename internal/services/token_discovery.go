@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// parseDiscoveryAllowlist parses the chain:contract entries of the discovery allowlist
+func parseDiscoveryAllowlist(entries []string) (map[models.TokenKey]bool, error) {
+	allowlist := make(map[models.TokenKey]bool, len(entries))
+	for _, entry := range entries {
+		chainName, contract, ok := strings.Cut(entry, ":")
+		if !ok || contract == "" {
+			return nil, fmt.Errorf("invalid discovery allowlist entry %q, expected chain:contract", entry)
+		}
+		chain, err := common.ParseChain(chainName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid discovery allowlist entry %q: %w", entry, err)
+		}
+		allowlist[models.NewTokenKey(chain, contract)] = true
+	}
+	return allowlist, nil
+}
+
+// discoverTokens adds the tokens found on the addresses of the vaults in the shard to their coins,
+// it returns false when interrupted
+func (p *PointWorker) discoverTokens(ctx context.Context, job *models.Job, shard *models.JobShard, leaseLost <-chan struct{}) bool {
+	if !p.cfg.Worker.Discovery.Enabled || len(p.discoveryServices) == 0 {
+		return true
+	}
+	vaultChan := make(chan models.Vault)
+	workerWg := p.runWorkers(int(p.cfg.Worker.Concurrency), func(idx int) {
+		defer p.wg.Done()
+		for vault := range vaultChan {
+			if err := p.discoverVaultTokens(ctx, job, &vault); err != nil {
+				p.logger.Errorf("failed to discover tokens of vault %d: %v", vault.ID, err)
+				p.countError("discovery")
+			}
+		}
+	})
+	completed := p.provideDiscoveryVaults(shard, vaultChan, leaseLost)
+	close(vaultChan)
+	workerWg.Wait()
+	return completed
+}
+
+func (p *PointWorker) provideDiscoveryVaults(shard *models.JobShard, vaultChan chan<- models.Vault, leaseLost <-chan struct{}) bool {
+	currentVaultId := shard.StartID
+	for {
+		if p.interrupted(leaseLost) {
+			return false
+		}
+		vaults, err := p.storage.GetVaultsWithPage(currentVaultId, 1000)
+		if err != nil {
+			p.logger.Errorf("failed to get vaults: %v", err)
+			continue
+		}
+		if len(vaults) == 0 || !shard.Contains(vaults[0].ID) {
+			p.logger.Infof("no more vaults to discover tokens of in shard %d", shard.ID)
+			return true
+		}
+		for _, vault := range vaults {
+			if !shard.Contains(vault.ID) {
+				return true
+			}
+			currentVaultId = vault.ID
+			shard.CurrentID = vault.ID
+			if !vault.JoinAirdrop {
+				continue
+			}
+			if !p.waitIfPaused(leaseLost) {
+				return false
+			}
+			select {
+			case vaultChan <- vault:
+			case <-p.stopChan:
+				return false
+			case <-leaseLost:
+				return false
+			}
+		}
+	}
+}
+
+// discoveredToken is a token found on a vault address that can be added to the vault
+type discoveredToken struct {
+	coin        models.CoinBase
+	allowlisted bool
+}
+
+// discoverVaultTokens discovers the tokens on the addresses the vault is credited for, and adds the
+// allowed ones the vault neither has nor had discovered before
+func (p *PointWorker) discoverVaultTokens(ctx context.Context, job *models.Job, vault *models.Vault) error {
+	addresses, err := p.storage.GetVaultAddresses(vault.ID)
+	if err != nil {
+		return err
+	}
+	var keys []models.AddressKey
+	for _, derived := range addresses {
+		if _, ok := p.discoveryServices[derived.Chain]; ok {
+			keys = append(keys, models.AddressKey{Chain: derived.Chain, Address: derived.Address})
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	owners, err := p.storage.GetAddressOwners(keys)
+	if err != nil {
+		return err
+	}
+	coins, err := p.storage.GetCoins(vault.ID)
+	if err != nil {
+		return err
+	}
+	previous, err := p.storage.GetDiscoveredCoins(vault.ID)
+	if err != nil {
+		return err
+	}
+	known := make(map[models.TokenKey]bool, len(coins)+len(previous))
+	for _, coin := range coins {
+		known[models.NewTokenKey(coin.Chain, coin.ContractAddress)] = true
+	}
+	for _, discovered := range previous {
+		known[models.NewTokenKey(discovered.Chain, discovered.ContractAddress)] = true
+	}
+
+	var found []discoveredToken
+	publicKeys := make(map[common.Chain]string, len(addresses))
+	for _, derived := range addresses {
+		service, ok := p.discoveryServices[derived.Chain]
+		if !ok {
+			continue
+		}
+		if owner, ok := owners[models.AddressKey{Chain: derived.Chain, Address: derived.Address}]; ok && owner != vault.ID {
+			continue
+		}
+		publicKeys[derived.Chain] = derived.ChildPublicKey
+		tokens, err := service.Discover(ctx, derived.Address, derived.Chain)
+		if err != nil {
+			p.logger.Errorf("failed to discover tokens of vault %d on %s: %v", vault.ID, derived.Chain, err)
+			p.countError("discovery")
+			continue
+		}
+		for _, token := range tokens {
+			key := models.NewTokenKey(token.Chain, token.ContractAddress)
+			if token.ContractAddress == "" || known[key] {
+				continue
+			}
+			known[key] = true
+			candidate := discoveredToken{coin: token, allowlisted: p.discoveryAllowlist[key]}
+			if asset, ok := p.assets.Load().Lookup(token.Chain, token.ContractAddress); ok {
+				if !asset.Enabled || asset.Kind != models.AssetKindToken {
+					continue
+				}
+				candidate.allowlisted = true
+				if asset.Ticker != "" {
+					candidate.coin.Ticker = asset.Ticker
+				}
+				candidate.coin.Decimals = asset.Decimals
+				candidate.coin.CMCId = asset.CMCId
+				candidate.coin.PriceProviderID = asset.PriceProviderID
+			}
+			if candidate.coin.CMCId == 0 || candidate.coin.Decimals == 0 {
+				resolved, err := service.Search(ctx, token)
+				if err != nil {
+					p.logger.Debugf("failed to resolve token %s on %s: %v", token.ContractAddress, token.Chain, err)
+					continue
+				}
+				candidate.coin.CMCId = resolved.CMCId
+				candidate.coin.Decimals = resolved.Decimals
+			}
+			if candidate.coin.CMCId == 0 || candidate.coin.Decimals == 0 {
+				continue
+			}
+			found = append(found, candidate)
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+
+	cmcIDs := make([]int, len(found))
+	for i, candidate := range found {
+		cmcIDs[i] = candidate.coin.CMCId
+	}
+	quotes, err := p.priceResolver.GetTokenQuotes(ctx, cmcIDs)
+	if err != nil {
+		return err
+	}
+	policy := models.DiscoveryPolicy{
+		MinValue:     p.cfg.Worker.Discovery.MinValue,
+		MinVolume:    p.cfg.Worker.Discovery.MinVolume,
+		MinMarketCap: p.cfg.Worker.Discovery.MinMarketCap,
+	}
+	for _, candidate := range found {
+		quote := quotes[candidate.coin.CMCId]
+		amount := tokenAmount(candidate.coin.Balance, candidate.coin.Decimals)
+		if !candidate.allowlisted && !policy.Allows(amount, quote) {
+			continue
+		}
+		coin := candidate.coin
+		if coin.Ticker == "" {
+			coin.Ticker = quote.Symbol
+		}
+		if coin.Ticker == "" {
+			continue
+		}
+		coin.HexPublicKey = publicKeys[coin.Chain]
+		coin.Balance = strconv.FormatFloat(amount, 'f', -1, 64)
+		coin.PriceUSD = strconv.FormatFloat(quote.Price, 'f', -1, 64)
+		coin.USDValue = strconv.FormatFloat(amount*quote.Price, 'f', -1, 64)
+		dbCoin := models.CoinDBModel{CoinBase: coin, VaultID: vault.ID}
+		discovered := models.DiscoveredCoin{
+			JobID:           job.ID,
+			VaultID:         vault.ID,
+			Chain:           coin.Chain,
+			ContractAddress: strings.ToLower(coin.ContractAddress),
+			Address:         coin.Address,
+			Ticker:          coin.Ticker,
+			CMCId:           coin.CMCId,
+			Decimals:        coin.Decimals,
+			PriceUSD:        quote.Price,
+			USDValue:        amount * quote.Price,
+			Allowlisted:     candidate.allowlisted,
+		}
+		added, err := p.storage.AddDiscoveredCoin(&dbCoin, &discovered)
+		if err != nil {
+			return err
+		}
+		if added {
+			p.logger.Infof("discovered %s on %s for vault %d", coin.Ticker, coin.Chain, vault.ID)
+		}
+	}
+	return nil
+}
+
+// tokenAmount converts the balance in the smallest unit of the token to tokens
+func tokenAmount(balance string, decimals int) float64 {
+	raw, ok := new(big.Float).SetString(balance)
+	if !ok {
+		return 0
+	}
+	divisor := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	amount, _ := new(big.Float).Quo(raw, divisor).Float64()
+	return amount
+}
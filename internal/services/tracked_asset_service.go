@@ -0,0 +1,51 @@
+package services
+
+import (
+	"fmt"
+
+	"gorm.io/gorm/clause"
+
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// GetTrackedAssets returns every tracked asset, disabled ones included
+func (s *Storage) GetTrackedAssets() ([]models.TrackedAsset, error) {
+	var assets []models.TrackedAsset
+	if err := s.db.Order("id").Find(&assets).Error; err != nil {
+		return nil, fmt.Errorf("failed to get tracked assets: %w", err)
+	}
+	return assets, nil
+}
+
+// GetAssetRegistry returns the registry of the tracked assets
+func (s *Storage) GetAssetRegistry() (*models.AssetRegistry, error) {
+	assets, err := s.GetTrackedAssets()
+	if err != nil {
+		return nil, err
+	}
+	return models.NewAssetRegistry(assets), nil
+}
+
+// SaveTrackedAsset stores the asset, replacing the tracked asset of its chain and contract
+func (s *Storage) SaveTrackedAsset(asset *models.TrackedAsset) error {
+	err := s.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "chain"}, {Name: "contract_address"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "ticker", "kind", "decimals", "cmc_id", "price_source",
+			"price_provider_id", "enabled", "boosted", "multiplier"}),
+	}).Create(asset).Error
+	if err != nil {
+		return fmt.Errorf("failed to save tracked asset %s on %s: %w", asset.ContractAddress, asset.Chain, err)
+	}
+	return nil
+}
+
+// SeedTrackedAssets adds the assets that are not tracked yet, tracked assets keep their settings
+func (s *Storage) SeedTrackedAssets(assets []models.TrackedAsset) error {
+	if len(assets) == 0 {
+		return nil
+	}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&assets).Error; err != nil {
+		return fmt.Errorf("failed to seed tracked assets: %w", err)
+	}
+	return nil
+}
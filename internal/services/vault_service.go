@@ -52,15 +52,6 @@ func (s *Storage) UpdateVault(vault *models.Vault) error {
 	return nil
 }
 
-// Increase current season points of vault (called during the season)
-func (s *Storage) IncreaseVaultTotalValue(id uint, newValue float64) error {
-	qry := `UPDATE vaults SET total_vault_value = total_vault_value + ? WHERE id = ?`
-	if err := s.db.Exec(qry, newValue, id).Error; err != nil {
-		return fmt.Errorf("failed to update vault total points: %w", err)
-	}
-	return nil
-}
-
 func (s *Storage) CommitSeasonPoints(v models.Vault, newSeasonId uint) error {
 	tx := s.db.Begin()
 	if tx.Error != nil {
@@ -117,6 +108,37 @@ func (s *Storage) UpdateNFTValue(id uint, nftValue int64) error {
 	return nil
 }
 
+// UpdatePositionFetchedAt records that the lp and nft values of the vault were both fetched
+func (s *Storage) UpdatePositionFetchedAt(id uint, fetchedAt time.Time) error {
+	qry := `UPDATE vaults SET last_fetched_at = ?, failure_streak = 0  WHERE id = ?`
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.db.WithContext(ctx).Exec(qry, fetchedAt, id).Error; err != nil {
+		return fmt.Errorf("failed to update vault: %w", err)
+	}
+	return nil
+}
+
+// IncrementVaultFailureStreak records a failed lp or nft fetch of the vault
+func (s *Storage) IncrementVaultFailureStreak(id uint) error {
+	qry := `UPDATE vaults SET failure_streak = failure_streak + 1  WHERE id = ?`
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.db.WithContext(ctx).Exec(qry, id).Error; err != nil {
+		return fmt.Errorf("failed to update vault: %w", err)
+	}
+	return nil
+}
+
+// GetPositionFreshness returns when the lp and nft values of the vault were last fetched
+func (s *Storage) GetPositionFreshness(id uint) (models.Freshness, error) {
+	var freshness models.Freshness
+	if err := s.db.Model(&models.Vault{}).Where("id = ?", id).Select("last_fetched_at, failure_streak").Scan(&freshness).Error; err != nil {
+		return freshness, fmt.Errorf("failed to get vault freshness: %w", err)
+	}
+	return freshness, nil
+}
+
 func (s *Storage) GetLPValue(id uint) (int64, error) {
 	var lpValue int64
 	if err := s.db.Model(&models.Vault{}).Where("id = ?", id).Select("lp_value").Scan(&lpValue).Error; err != nil {
@@ -143,6 +165,19 @@ func (s *Storage) DeleteVault(ecdsa, eddsa string) error {
 	if err := s.db.Exec("delete from vault_share_appearances where vault_id in (select id from vaults where ecdsa = ? and eddsa = ?)", ecdsa, eddsa).Error; err != nil {
 		return fmt.Errorf("fail to delete vault_share_appearances in vault,err: %w", err)
 	}
+	if err := s.db.Exec("delete from vault_addresses where vault_id in (select id from vaults where ecdsa = ? and eddsa = ?)", ecdsa, eddsa).Error; err != nil {
+		return fmt.Errorf("fail to delete vault_addresses of vault,err: %w", err)
+	}
+	// the addresses go to the next vault deriving them once it is processed
+	if err := s.db.Exec("delete from address_owners where vault_id in (select id from vaults where ecdsa = ? and eddsa = ?)", ecdsa, eddsa).Error; err != nil {
+		return fmt.Errorf("fail to delete address_owners of vault,err: %w", err)
+	}
+	if err := s.db.Exec("delete from address_collisions where vault_id in (select id from vaults where ecdsa = ? and eddsa = ?)", ecdsa, eddsa).Error; err != nil {
+		return fmt.Errorf("fail to delete address_collisions of vault,err: %w", err)
+	}
+	if err := s.db.Exec("delete from discovered_coins where vault_id in (select id from vaults where ecdsa = ? and eddsa = ?)", ecdsa, eddsa).Error; err != nil {
+		return fmt.Errorf("fail to delete discovered_coins of vault,err: %w", err)
+	}
 	if err := s.db.Where("ecdsa = ? AND eddsa = ?", ecdsa, eddsa).Unscoped().Delete(&models.Vault{}).Error; err != nil {
 		return fmt.Errorf("failed to delete vault with ECDSA %s and EDDSA %s: %w", ecdsa, eddsa, err)
 	}
@@ -332,3 +367,12 @@ func (s *Storage) UpdateVaultMilestone(vaultId uint, milestoneId int, prize floa
 	}
 	return nil
 }
+
+// SetVaultScore overwrites the season points and next milestone of the vault
+func (s *Storage) SetVaultScore(vaultId uint, totalPoints float64, nextMilestoneID int) error {
+	qry := `UPDATE vaults SET total_points = ?, next_milestone_id = ? WHERE id = ?`
+	if err := s.db.Exec(qry, totalPoints, nextMilestoneID, vaultId).Error; err != nil {
+		return fmt.Errorf("failed to set vault score: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/vultisig/airdrop-registry/internal/endpoints"
+	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/replay"
+	"github.com/vultisig/airdrop-registry/internal/upstream"
+)
+
+var (
+	ErrJobInProgress    = errors.New("a job is in progress on this instance")
+	ErrNoJobInProgress  = errors.New("no job is in progress")
+	ErrNoCurrentSeason  = errors.New("no season is running")
+	ErrJobNotFound      = errors.New("no job on the date")
+	ErrJobSucceeded     = errors.New("the job of the date succeeded")
+	ErrLedgerIncomplete = errors.New("jobs of the season have no point events")
+	ErrJobUnfinished    = errors.New("a job is unfinished")
+)
+
+// WorkerStatus is what the admin api reports about a worker instance
+type WorkerStatus struct {
+	InstanceID string               `json:"instance_id"`
+	Paused     bool                 `json:"paused"`
+	Working    bool                 `json:"working"`       // whether this instance works on the job
+	Job        *models.JobProgress  `json:"job,omitempty"` // job of this instance, or the last job while idle
+	Errors     map[string]int64     `json:"errors"`        // errors of this instance since it started by kind
+	Upstreams  []upstream.HostStats `json:"upstreams"`     // calls of this instance to third party apis by host
+	Endpoints  []endpoints.Stats    `json:"endpoints"`     // balance calls of this instance by chain endpoint
+}
+
+// VaultScore compares the stored points of a vault with the points replayed from its point events
+type VaultScore struct {
+	VaultID             uint    `json:"vault_id"`
+	Jobs                int     `json:"jobs"`
+	TotalPoints         float64 `json:"total_points"`
+	NextMilestoneID     int     `json:"next_milestone_id"`
+	RescoredPoints      float64 `json:"rescored_points"`
+	RescoredMilestoneID int     `json:"rescored_milestone_id"`
+	Applied             bool    `json:"applied"`
+}
+
+// Serve runs the handler on addr until the worker stops
+func (p *PointWorker) Serve(addr string, handler http.Handler) {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	p.wg.Add(2)
+	go func() {
+		defer p.wg.Done()
+		p.logger.Infof("admin api listening on %s", addr)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			p.logger.Errorf("admin api stopped: %v", err)
+		}
+	}()
+	go func() {
+		defer p.wg.Done()
+		<-p.stopChan
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			p.logger.Errorf("failed to shutdown admin api: %v", err)
+		}
+	}()
+}
+
+// countError records an error of the given kind for the admin status
+func (p *PointWorker) countError(kind string) {
+	p.controlMu.Lock()
+	defer p.controlMu.Unlock()
+	p.errorCounts[kind]++
+}
+
+// waitIfPaused blocks while the worker is paused, it returns false when interrupted in the meantime
+func (p *PointWorker) waitIfPaused(leaseLost <-chan struct{}) bool {
+	p.controlMu.Lock()
+	resumed := p.resumed
+	p.controlMu.Unlock()
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-p.stopChan:
+		return false
+	case <-leaseLost:
+		return false
+	}
+}
+
+// Pause stops the instance before its next vault, coin or shard. It keeps the lease of the shard it works on.
+func (p *PointWorker) Pause() {
+	p.controlMu.Lock()
+	defer p.controlMu.Unlock()
+	if p.resumed == nil {
+		p.resumed = make(chan struct{})
+		p.logger.Info("worker paused")
+	}
+}
+
+func (p *PointWorker) Resume() {
+	p.controlMu.Lock()
+	defer p.controlMu.Unlock()
+	if p.resumed != nil {
+		close(p.resumed)
+		p.resumed = nil
+		p.logger.Info("worker resumed")
+	}
+}
+
+// CancelJob cancels the job this instance works on, or the last job when it is unfinished. Other
+// instances stop once they fail to renew their lease.
+func (p *PointWorker) CancelJob() (*models.Job, error) {
+	p.controlMu.Lock()
+	job := p.currentJob
+	p.controlMu.Unlock()
+	if job == nil {
+		lastJob, err := p.storage.GetLastJob()
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrNoJobInProgress
+			}
+			return nil, fmt.Errorf("failed to get last job: %w", err)
+		}
+		if lastJob.IsSuccess || lastJob.IsCancelled {
+			return nil, ErrNoJobInProgress
+		}
+		job = lastJob
+	}
+	if err := p.storage.CancelJob(job); err != nil {
+		return nil, err
+	}
+	p.controlMu.Lock()
+	if p.currentJob != nil && p.currentJob.ID == job.ID && p.jobCancelled != nil {
+		close(p.jobCancelled)
+		p.jobCancelled = nil
+	}
+	p.controlMu.Unlock()
+	p.logger.Infof("job %d cancelled", job.ID)
+	return job, nil
+}
+
+// RerunJob runs the unfinished job of the date again on this instance, see Storage.ResetJob. Other
+// instances resume it once they are idle.
+func (p *PointWorker) RerunJob(date time.Time) (*models.Job, error) {
+	p.controlMu.Lock()
+	busy := p.currentJob != nil
+	p.controlMu.Unlock()
+	if busy {
+		return nil, ErrJobInProgress
+	}
+	job, err := p.storage.ResetJob(date)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.startJob(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Status reports the progress of the job and the errors of this instance
+func (p *PointWorker) Status() (WorkerStatus, error) {
+	p.controlMu.Lock()
+	status := WorkerStatus{
+		InstanceID: p.instanceID,
+		Paused:     p.resumed != nil,
+		Working:    p.currentJob != nil,
+		Errors:     make(map[string]int64, len(p.errorCounts)),
+		Upstreams:  upstream.Default().Stats(),
+		Endpoints:  p.balanceResolver.EndpointStats(),
+	}
+	for kind, count := range p.errorCounts {
+		status.Errors[kind] = count
+	}
+	var jobID uint
+	if p.currentJob != nil {
+		jobID = p.currentJob.ID
+	}
+	p.controlMu.Unlock()
+
+	var job *models.Job
+	var err error
+	if jobID != 0 {
+		job, err = p.storage.GetJob(jobID)
+	} else {
+		job, err = p.storage.GetLastJob()
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status, nil
+		}
+		return status, fmt.Errorf("failed to get job: %w", err)
+	}
+	progress, err := p.storage.GetJobProgress(job)
+	if err != nil {
+		return status, err
+	}
+	status.Job = &progress
+	return status, nil
+}
+
+// RescoreVault replays the point events the successful jobs of the current season recorded for the
+// vault under the season formula and adds the points of the quests it completed in the season. The
+// replayed points replace the stored ones when apply is set. Jobs run before the point events were
+// recorded are not part of the replay, so apply is refused while a job of the season has no events. An
+// unfinished job may have added its points already without being part of the replay, so apply is refused
+// while one runs as well.
+func (p *PointWorker) RescoreVault(vault *models.Vault, apply bool) (VaultScore, error) {
+	season := p.cfg.GetCurrentSeason()
+	if season.ID == 0 {
+		return VaultScore{}, ErrNoCurrentSeason
+	}
+	formula, err := p.formula()
+	if err != nil {
+		return VaultScore{}, err
+	}
+	jobs, err := p.storage.GetSuccessfulJobs(season.Start, season.End)
+	if err != nil {
+		return VaultScore{}, err
+	}
+	jobIDs := make([]uint, len(jobs))
+	for i, job := range jobs {
+		jobIDs[i] = job.ID
+	}
+	if apply {
+		unfinished, err := p.storage.GetUnfinishedJob()
+		if err != nil {
+			return VaultScore{}, err
+		}
+		if unfinished != nil {
+			return VaultScore{}, fmt.Errorf("%w: job %d", ErrJobUnfinished, unfinished.ID)
+		}
+		missing, err := p.storage.GetJobsWithoutPointEvents(jobIDs)
+		if err != nil {
+			return VaultScore{}, err
+		}
+		if len(missing) > 0 {
+			return VaultScore{}, fmt.Errorf("%w: %d jobs", ErrLedgerIncomplete, len(missing))
+		}
+	}
+	events, err := p.storage.GetVaultPointEventsByJobs(vault.ID, jobIDs)
+	if err != nil {
+		return VaultScore{}, err
+	}
+	eventsByJob := make(map[uint][]models.PointEvent)
+	for _, event := range events {
+		eventsByJob[event.JobID] = append(eventsByJob[event.JobID], event)
+	}
+	scenario := replay.Scenario{Formula: formula}
+	r := replay.NewReplay(scenario, scenario)
+	for _, job := range jobs {
+		r.AddJob(eventsByJob[job.ID], nil)
+	}
+	score := VaultScore{
+		VaultID:         vault.ID,
+		Jobs:            r.Jobs(),
+		TotalPoints:     vault.TotalPoints,
+		NextMilestoneID: vault.NextMilestoneID,
+	}
+	score.RescoredPoints, score.RescoredMilestoneID = r.Score(vault.ID)
+	questPoints, err := p.storage.GetVaultQuestPoints(vault.ID, season.Start, season.End)
+	if err != nil {
+		return VaultScore{}, err
+	}
+	score.RescoredPoints += questPoints
+	if apply {
+		if err := p.storage.SetVaultScore(vault.ID, score.RescoredPoints, score.RescoredMilestoneID); err != nil {
+			return score, err
+		}
+		score.Applied = true
+		p.logger.Infof("vault %d rescored from %f to %f points", vault.ID, score.TotalPoints, score.RescoredPoints)
+	}
+	return score, nil
+}
@@ -1,6 +1,7 @@
 package tokens
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -61,16 +62,16 @@ func NewCMCService() (*CMCService, error) {
 		cachedData:    cache.New(10*time.Hour, 1*time.Hour),
 		nativeCoinIds: map[string]int{},
 	}
-	if err := cmcService.initialise(); err != nil {
+	if err := cmcService.initialise(context.Background()); err != nil {
 		return nil, err
 	}
 	return &cmcService, nil
 }
-func (c *CMCService) initialise() error {
+func (c *CMCService) initialise(ctx context.Context) error {
 
 	start, limit := 1, 5000
 	for {
-		dataMap, err := c.fetchCMCMap(start, limit)
+		dataMap, err := c.fetchCMCMap(ctx, start, limit)
 		if err != nil {
 			return err
 		}
@@ -89,10 +90,10 @@ func (c *CMCService) initialise() error {
 	return nil
 }
 
-func (c *CMCService) fetchCMCMap(start, limit int) ([]mainData, error) {
+func (c *CMCService) fetchCMCMap(ctx context.Context, start, limit int) ([]mainData, error) {
 	var cmcMainModel mainModel
 	url := fmt.Sprintf("%s/map?sort=cmc_rank&limit=%d&start=%d", c.baseURL, limit, start)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		c.logger.Errorf("error fetching cmc id from %s: %v", url, err)
 		return nil, err
@@ -109,7 +110,7 @@ func (c *CMCService) fetchCMCMap(start, limit int) ([]mainData, error) {
 	return cmcMainModel.Data, nil
 }
 
-func (c *CMCService) GetCMCID(chain common.Chain, coin models.Coin) (int, error) {
+func (c *CMCService) GetCMCID(ctx context.Context, chain common.Chain, coin models.Coin) (int, error) {
 	if coin.ContractAddress == "" { // is native coin
 		if cmcID, ok := c.nativeCoinIds[cmcChainMap[chain]]; ok {
 			return cmcID, nil
@@ -117,10 +118,10 @@ func (c *CMCService) GetCMCID(chain common.Chain, coin models.Coin) (int, error)
 			return -1, fmt.Errorf("failed to get cmc id for native coin: %s", cmcChainMap[chain])
 		}
 	}
-	return c.GetCMCIDByContract(cmcChainMap[chain], coin.ContractAddress)
+	return c.GetCMCIDByContract(ctx, cmcChainMap[chain], coin.ContractAddress)
 }
 
-func (c *CMCService) GetCMCIDByContract(chainName, contract string) (int, error) {
+func (c *CMCService) GetCMCIDByContract(ctx context.Context, chainName, contract string) (int, error) {
 	if cachedData, found := c.cachedData.Get(c.getCacheKey(chainName, contract)); found {
 		if cmcID, ok := cachedData.(int); ok {
 			return cmcID, nil
@@ -140,7 +141,7 @@ func (c *CMCService) GetCMCIDByContract(chainName, contract string) (int, error)
 	}
 
 	url := fmt.Sprintf("%s/info?address=%s&skip_invalid=true&aux=status", c.baseURL, contract)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		return -1, err
 	}
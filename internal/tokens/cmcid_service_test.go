@@ -1,6 +1,7 @@
 package tokens
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -69,7 +70,7 @@ func TestCMCIDService_GetCMCID(t *testing.T) {
 	}
 
 	for _, cmc := range cmcVals {
-		cmcid, err := cmcService.GetCMCID(cmc.chain, cmc.asset)
+		cmcid, err := cmcService.GetCMCID(context.Background(), cmc.chain, cmc.asset)
 		assert.NoError(t, err)
 		assert.Equal(t, cmc.expectedCMCID, cmcid)
 	}
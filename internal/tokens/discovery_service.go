@@ -1,11 +1,37 @@
 package tokens
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/vultisig/airdrop-registry/internal/common"
 	"github.com/vultisig/airdrop-registry/internal/models"
 )
 
 type AutoDiscoveryService interface {
-	Discover(address string, chain common.Chain) ([]models.CoinBase, error)
-	Search(coin models.CoinBase) (models.CoinBase, error)
+	Discover(ctx context.Context, address string, chain common.Chain) ([]models.CoinBase, error)
+	Search(ctx context.Context, coin models.CoinBase) (models.CoinBase, error)
+}
+
+// NewAutoDiscoveryServices returns the discovery service of each chain tokens can be discovered on,
+// the 1inch token lists of the EVM chains are loaded up front
+func NewAutoDiscoveryServices(ctx context.Context, cmcService *CMCService) (map[common.Chain]AutoDiscoveryService, error) {
+	oneInchService, err := NewOneInchService()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oneInch service: %w", err)
+	}
+	services := map[common.Chain]AutoDiscoveryService{
+		common.Tron:   NewTRC20DiscoveryService(common.Tron, cmcService),
+		common.Solana: NewSPLDiscoveryService(cmcService),
+	}
+	for _, chain := range common.EVMChains {
+		if !oneInchService.IsChainSupported(chain) {
+			continue
+		}
+		if err := oneInchService.LoadOneInchTokens(ctx, chain); err != nil {
+			return nil, fmt.Errorf("failed to load oneInch tokens of %s: %w", chain, err)
+		}
+		services[chain] = NewERC20DiscoveryService(oneInchService, cmcService)
+	}
+	return services, nil
 }
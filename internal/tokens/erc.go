@@ -1,6 +1,7 @@
 package tokens
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/vultisig/airdrop-registry/internal/common"
 	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/utils"
 )
 
 const ethereum string = "0xeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
@@ -41,7 +43,7 @@ func NewERC20DiscoveryService(oneInchService *oneInchService, cmcService *CMCSer
 	}
 }
 
-func (e *ercDiscoveryService) Discover(address string, chain common.Chain) ([]models.CoinBase, error) {
+func (e *ercDiscoveryService) Discover(ctx context.Context, address string, chain common.Chain) ([]models.CoinBase, error) {
 	// Validate inputs
 	if address == "" {
 		return nil, fmt.Errorf("empty address provided")
@@ -52,7 +54,7 @@ func (e *ercDiscoveryService) Discover(address string, chain common.Chain) ([]mo
 		return nil, fmt.Errorf("unsupported chain: %v", chain)
 	}
 	url := fmt.Sprintf("%s/balance/v1.2/%d/balances/%s", e.baseAddress, chainID, address)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		e.logger.WithFields(logrus.Fields{
 			"error": err,
@@ -100,13 +102,13 @@ func (e *ercDiscoveryService) Discover(address string, chain common.Chain) ([]mo
 	}
 
 	for i, coin := range coins {
-		tokenDetails, err := e.oneInchService.GetTokenDetailsByContract(chain, coin.ContractAddress)
+		tokenDetails, err := e.oneInchService.GetTokenDetailsByContract(ctx, chain, coin.ContractAddress)
 		if err != nil {
 			e.logger.WithError(err).Error("Failed to fetch token details")
 			return nil, fmt.Errorf("failed to fetch token details: %w", err)
 		}
 
-		cmcId, err := e.cmcService.GetCMCIDByContract(chain.String(), coin.ContractAddress)
+		cmcId, err := e.cmcService.GetCMCIDByContract(ctx, chain.String(), coin.ContractAddress)
 		if err != nil {
 			e.logger.WithError(err).Error("Failed to fetch cmc id")
 			return nil, fmt.Errorf("failed to fetch cmc id: %w", err)
@@ -120,17 +122,17 @@ func (e *ercDiscoveryService) Discover(address string, chain common.Chain) ([]mo
 	return coins, nil
 }
 
-func (e *ercDiscoveryService) Search(coin models.CoinBase) (models.CoinBase, error) {
+func (e *ercDiscoveryService) Search(ctx context.Context, coin models.CoinBase) (models.CoinBase, error) {
 	chainName, exists := cmcChainMap[coin.Chain]
 	if !exists {
 		return models.CoinBase{}, fmt.Errorf("unsupported chain: %v", coin.Chain)
 	}
-	cmcId, err := e.cmcService.GetCMCIDByContract(chainName, coin.ContractAddress)
+	cmcId, err := e.cmcService.GetCMCIDByContract(ctx, chainName, coin.ContractAddress)
 	if err != nil {
 		e.logger.WithError(err).Error("Failed to fetch cmc id")
 		return models.CoinBase{}, fmt.Errorf("failed to fetch cmc id: %w", err)
 	}
-	oneInchCoin, err := e.oneInchService.GetTokenDetailsByContract(coin.Chain, coin.ContractAddress)
+	oneInchCoin, err := e.oneInchService.GetTokenDetailsByContract(ctx, coin.Chain, coin.ContractAddress)
 	if err != nil {
 		e.logger.WithError(err).Error("Failed to fetch token details")
 		return models.CoinBase{}, fmt.Errorf("failed to fetch token details: %w", err)
@@ -1,6 +1,7 @@
 package tokens
 
 import (
+	"context"
 	_ "embed"
 	"log"
 	"net/http"
@@ -69,7 +70,7 @@ func TestErcDiscovery(t *testing.T) {
 		ContractAddress: "0xdac17f958d2ee523a2206206994597c13d831ec7",
 	})
 
-	res, err := dicoveryService.Discover("0x14F6Ed6CBb27b607b0E2A48551A988F1a19c89B6", common.Ethereum)
+	res, err := dicoveryService.Discover(context.Background(), "0x14F6Ed6CBb27b607b0E2A48551A988F1a19c89B6", common.Ethereum)
 	if err != nil {
 		t.Errorf("oneInchEVMBase failed: %v", err)
 	}
@@ -167,7 +168,7 @@ func Test_ercDiscoveryService_Search(t *testing.T) {
 	// Run tests
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := discovery.Search(tc.input)
+			result, err := discovery.Search(context.Background(), tc.input)
 			if err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
@@ -1,6 +1,7 @@
 package tokens
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/vultisig/airdrop-registry/internal/common"
 	"github.com/vultisig/airdrop-registry/internal/models"
+	"github.com/vultisig/airdrop-registry/internal/utils"
 )
 
 type token struct {
@@ -63,7 +65,7 @@ func (o *oneInchService) IsChainSupported(chain common.Chain) bool {
 	return false
 }
 
-func (o *oneInchService) LoadOneInchTokens(chain common.Chain) error {
+func (o *oneInchService) LoadOneInchTokens(ctx context.Context, chain common.Chain) error {
 	if _, ok := chainIDs[chain]; !ok {
 		return fmt.Errorf("chain: %s is not supported", chain)
 	}
@@ -76,7 +78,7 @@ func (o *oneInchService) LoadOneInchTokens(chain common.Chain) error {
 		}
 	}
 	url := fmt.Sprintf("%s/swap/v6.0/%d/tokens", o.oneInchBaseURL, chainIDs[chain])
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		o.logger.Error(err)
 		return fmt.Errorf("fail to get tokens from, err %s: %w", url, err)
@@ -107,7 +109,7 @@ func (o *oneInchService) LoadOneInchTokens(chain common.Chain) error {
 	return nil
 }
 
-func (o *oneInchService) GetTokenDetailsByContract(chain common.Chain, contract string) (models.CoinBase, error) {
+func (o *oneInchService) GetTokenDetailsByContract(ctx context.Context, chain common.Chain, contract string) (models.CoinBase, error) {
 	chainID, ok := chainIDs[chain]
 	if !ok {
 		return models.CoinBase{}, fmt.Errorf("chain: %s is not supported", chain)
@@ -117,7 +119,7 @@ func (o *oneInchService) GetTokenDetailsByContract(chain common.Chain, contract
 		return cachedData, nil
 	}
 	url := fmt.Sprintf("%s/token-details/v1.0/details/%d/%s", o.oneInchBaseURL, chainID, contract)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		o.logger.WithFields(logrus.Fields{
 			"error":        err,
@@ -1,6 +1,7 @@
 package tokens
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"fmt"
@@ -27,7 +28,7 @@ func NewPredefinedTokenService() AutoDiscoveryService {
 	}
 }
 
-func (p *PredefinedTokenDiscoveryService) Search(coin models.CoinBase) (models.CoinBase, error) {
+func (p *PredefinedTokenDiscoveryService) Search(ctx context.Context, coin models.CoinBase) (models.CoinBase, error) {
 	for _, token := range p.predefinedTokens {
 		if token.Chain == coin.Chain && token.ContractAddress == coin.ContractAddress {
 			return token, nil
@@ -36,6 +37,6 @@ func (p *PredefinedTokenDiscoveryService) Search(coin models.CoinBase) (models.C
 	return models.CoinBase{}, fmt.Errorf("token not found: chain=%s, address=%s", coin.Chain, coin.ContractAddress)
 }
 
-func (p *PredefinedTokenDiscoveryService) Discover(address string, chain common.Chain) ([]models.CoinBase, error) {
+func (p *PredefinedTokenDiscoveryService) Discover(ctx context.Context, address string, chain common.Chain) ([]models.CoinBase, error) {
 	return nil, fmt.Errorf("Discover method not implemented for PredefinedTokenDiscoveryService")
 }
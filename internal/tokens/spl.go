@@ -2,6 +2,7 @@ package tokens
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -32,12 +33,12 @@ func NewSPLDiscoveryService(cmcService *CMCService) AutoDiscoveryService {
 	}
 }
 
-func (s *splDiscoveryService) Discover(address string, chain common.Chain) ([]models.CoinBase, error) {
+func (s *splDiscoveryService) Discover(ctx context.Context, address string, chain common.Chain) ([]models.CoinBase, error) {
 	if address == "" {
 		return nil, fmt.Errorf("empty address provided")
 	}
 
-	tokens, err := s.fetchTokenAccounts(address)
+	tokens, err := s.fetchTokenAccounts(ctx, address)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch token accounts: %w", err)
 	}
@@ -45,7 +46,7 @@ func (s *splDiscoveryService) Discover(address string, chain common.Chain) ([]mo
 	return tokens, nil
 }
 
-func (s *splDiscoveryService) fetchTokenAccounts(address string) ([]models.CoinBase, error) {
+func (s *splDiscoveryService) fetchTokenAccounts(ctx context.Context, address string) ([]models.CoinBase, error) {
 
 	requestBody := map[string]any{
 		"jsonrpc": jsonRPCVersion,
@@ -67,7 +68,7 @@ func (s *splDiscoveryService) fetchTokenAccounts(address string) ([]models.CoinB
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	resp, err := http.Post(s.baseAddress, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := utils.HTTPPost(ctx, s.baseAddress, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
 		s.logger.WithFields(logrus.Fields{
 			"error": err,
@@ -95,7 +96,7 @@ func (s *splDiscoveryService) fetchTokenAccounts(address string) ([]models.CoinB
 			continue
 		}
 
-		cmcid, err := s.cmcService.GetCMCIDByContract("Solana", info.Mint)
+		cmcid, err := s.cmcService.GetCMCIDByContract(ctx, "Solana", info.Mint)
 		if err != nil {
 			s.logger.WithError(err).WithField("contract", info.Mint).
 				Error("failed to get CMCID for contract")
@@ -151,18 +152,18 @@ type (
 	}
 )
 
-func (s *splDiscoveryService) Search(coin models.CoinBase) (models.CoinBase, error) {
+func (s *splDiscoveryService) Search(ctx context.Context, coin models.CoinBase) (models.CoinBase, error) {
 	chainName, exists := cmcChainMap[coin.Chain]
 	if !exists {
 		return models.CoinBase{}, fmt.Errorf("unsupported chain: %v", coin.Chain)
 	}
-	cmcId, err := s.cmcService.GetCMCIDByContract(chainName, coin.ContractAddress)
+	cmcId, err := s.cmcService.GetCMCIDByContract(ctx, chainName, coin.ContractAddress)
 	if err != nil {
 		s.logger.WithError(err).WithField("contract", coin.ContractAddress).
 			Error("failed to get CMCID for contract")
 		return models.CoinBase{}, err
 	}
-	decimal, err := s.getCoinDecimal(coin.ContractAddress)
+	decimal, err := s.getCoinDecimal(ctx, coin.ContractAddress)
 	if err != nil {
 		s.logger.WithError(err).WithField("contract", coin.ContractAddress).
 			Error("failed to get decimal for contract")
@@ -173,7 +174,7 @@ func (s *splDiscoveryService) Search(coin models.CoinBase) (models.CoinBase, err
 	return coin, nil
 }
 
-func (s *splDiscoveryService) getCoinDecimal(address string) (int, error) {
+func (s *splDiscoveryService) getCoinDecimal(ctx context.Context, address string) (int, error) {
 	parmas := []interface{}{
 		address,
 		map[string]string{
@@ -185,7 +186,7 @@ func (s *splDiscoveryService) getCoinDecimal(address string) (int, error) {
 	if err != nil {
 		return 0, fmt.Errorf("error marshalling RPC request: %w", err)
 	}
-	resp, err := http.Post(s.baseAddress, "application/json", bytes.NewBuffer(buf))
+	resp, err := utils.HTTPPost(ctx, s.baseAddress, "application/json", bytes.NewBuffer(buf))
 	if err != nil {
 		return 0, fmt.Errorf("error fetching balance of address %s: %w", address, err)
 	}
@@ -1,6 +1,7 @@
 package tokens
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"net/http"
@@ -47,7 +48,7 @@ func TestSolDiscoveryService_processTokenAccounts(t *testing.T) {
 	}
 
 	testAddr := "CG4V2eoUXnwJSDsmr1fNdbR9r63XHLKD9gA2xpCRdRby"
-	results, err := service.fetchTokenAccounts(testAddr)
+	results, err := service.fetchTokenAccounts(context.Background(), testAddr)
 
 	// Assertions
 	if err != nil {
@@ -154,7 +155,7 @@ func TestSolDiscoveryService_Search(t *testing.T) {
 	// Run tests
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := discovery.Search(tc.input)
+			result, err := discovery.Search(context.Background(), tc.input)
 			if (err != nil) != tc.wantErr {
 				t.Errorf("Search() error = %v", err)
 				return
@@ -0,0 +1,44 @@
+package tokens
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/vultisig/airdrop-registry/internal/common"
+	"github.com/vultisig/airdrop-registry/internal/models"
+)
+
+// PredefinedTrackedAssets returns the tracked assets seeded from predefined_tokens.json, with the NFT
+// collections valued by the point worker. Tokens on chains the registry does not know are left out.
+func PredefinedTrackedAssets() ([]models.TrackedAsset, error) {
+	var tokens []models.CoinBase
+	if err := json.Unmarshal([]byte(predefinedTokens), &tokens); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal predefined tokens: %w", err)
+	}
+	assets := make([]models.TrackedAsset, 0, len(tokens)+1)
+	for _, token := range tokens {
+		if token.Chain == common.Undefined {
+			continue
+		}
+		assets = append(assets, models.TrackedAsset{
+			Chain:           token.Chain,
+			ContractAddress: token.ContractAddress,
+			Ticker:          token.Ticker,
+			Kind:            models.AssetKindToken,
+			Decimals:        token.Decimals,
+			CMCId:           token.CMCId,
+			PriceSource:     models.PriceSourceCMC,
+			Enabled:         true,
+		})
+	}
+	assets = append(assets, models.TrackedAsset{
+		Chain:           common.Ethereum,
+		ContractAddress: "0xa98b29a8f5a247802149c268ecf860b8308b7291",
+		Ticker:          "THORGUARDS",
+		Kind:            models.AssetKindNFT,
+		PriceSource:     models.PriceSourceOpenSea,
+		PriceProviderID: "thorguards",
+		Enabled:         true,
+	})
+	return assets, nil
+}
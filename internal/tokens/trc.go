@@ -2,6 +2,7 @@ package tokens
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/big"
@@ -30,7 +31,7 @@ func NewTRC20DiscoveryService(chain common.Chain, cmcService *CMCService) AutoDi
 	}
 }
 
-func (trc *trcDiscoveryService) Discover(address string, chain common.Chain) ([]models.CoinBase, error) {
+func (trc *trcDiscoveryService) Discover(ctx context.Context, address string, chain common.Chain) ([]models.CoinBase, error) {
 	// Validate inputs
 	if address == "" {
 		return nil, fmt.Errorf("empty address provided")
@@ -40,7 +41,7 @@ func (trc *trcDiscoveryService) Discover(address string, chain common.Chain) ([]
 	}
 
 	url := fmt.Sprintf("%s/v1/accounts/%s", trc.tronBaseURL, address)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		trc.logger.WithError(err).Errorf("failed to fetch account from %s", url)
 		return nil, fmt.Errorf("failed to get account: %w", err)
@@ -63,7 +64,7 @@ func (trc *trcDiscoveryService) Discover(address string, chain common.Chain) ([]
 		return nil, fmt.Errorf("unsuccessful response from TRC API")
 	}
 
-	coins, err := trc.processAccounts(address, accountResponse.Data)
+	coins, err := trc.processAccounts(ctx, address, accountResponse.Data)
 	if err != nil {
 		trc.logger.Warn("failed to process accounts")
 		return nil, fmt.Errorf("failed to process accounts: %w", err)
@@ -103,10 +104,10 @@ type symbolDecimalModel struct {
 	} `json:"transaction"`
 }
 
-func (trc *trcDiscoveryService) processAccounts(address string, accounts []trcAccount) ([]models.CoinBase, error) {
+func (trc *trcDiscoveryService) processAccounts(ctx context.Context, address string, accounts []trcAccount) ([]models.CoinBase, error) {
 	coins := make([]models.CoinBase, 0)
 	for _, account := range accounts {
-		coinBases, err := trc.processTRC20Tokens(address, account.Trc20)
+		coinBases, err := trc.processTRC20Tokens(ctx, address, account.Trc20)
 		if err != nil {
 			trc.logger.WithError(err).Warn("error processing TRC20 tokens")
 			continue
@@ -116,11 +117,11 @@ func (trc *trcDiscoveryService) processAccounts(address string, accounts []trcAc
 	return coins, nil
 }
 
-func (trc *trcDiscoveryService) processTRC20Tokens(address string, tokens []map[string]string) ([]models.CoinBase, error) {
+func (trc *trcDiscoveryService) processTRC20Tokens(ctx context.Context, address string, tokens []map[string]string) ([]models.CoinBase, error) {
 	coins := make([]models.CoinBase, 0)
 	for _, tokenMap := range tokens {
 		for contract, balanceStr := range tokenMap {
-			coin, err := trc.processToken(address, contract, balanceStr)
+			coin, err := trc.processToken(ctx, address, contract, balanceStr)
 			if err != nil {
 				trc.logger.WithError(err).WithField("contract", contract).Warn("error processing token")
 				continue
@@ -133,7 +134,7 @@ func (trc *trcDiscoveryService) processTRC20Tokens(address string, tokens []map[
 	return coins, nil
 }
 
-func (trc *trcDiscoveryService) processToken(address, contract, balanceStr string) (*models.CoinBase, error) {
+func (trc *trcDiscoveryService) processToken(ctx context.Context, address, contract, balanceStr string) (*models.CoinBase, error) {
 	balance := new(big.Int)
 	if _, ok := balance.SetString(balanceStr, 10); !ok {
 		return nil, fmt.Errorf("invalid balance: %s", balanceStr)
@@ -143,17 +144,17 @@ func (trc *trcDiscoveryService) processToken(address, contract, balanceStr strin
 		return nil, nil
 	}
 
-	cmcid, err := trc.cmcService.GetCMCIDByContract("TRON", contract)
+	cmcid, err := trc.cmcService.GetCMCIDByContract(ctx, "TRON", contract)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get CMCID: %w", err)
 	}
 
-	symbol, err := trc.fetchTokenData(address, contract, "symbol()")
+	symbol, err := trc.fetchTokenData(ctx, address, contract, "symbol()")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get symbol: %w", err)
 	}
 
-	decimalsHex, err := trc.fetchTokenData(address, contract, "decimals()")
+	decimalsHex, err := trc.fetchTokenData(ctx, address, contract, "decimals()")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get decimals: %w", err)
 	}
@@ -174,7 +175,7 @@ func (trc *trcDiscoveryService) processToken(address, contract, balanceStr strin
 	}, nil
 }
 
-func (trc *trcDiscoveryService) fetchTokenData(address, contract, selector string) (string, error) {
+func (trc *trcDiscoveryService) fetchTokenData(ctx context.Context, address, contract, selector string) (string, error) {
 	hexContract, err := utils.DecodeBase58ToHex(contract)
 	if err != nil {
 		return "", fmt.Errorf("failed to decode contract hex: %w", err)
@@ -187,7 +188,7 @@ func (trc *trcDiscoveryService) fetchTokenData(address, contract, selector strin
 	url := fmt.Sprintf("%s/wallet/triggerconstantcontract", trc.tronBaseURL)
 	payload := fmt.Sprintf(`{"contract_address": "%s","function_selector": "%s","owner_address": "%s"}`, hexContract, selector, hexAddress)
 
-	resp, err := http.Post(url, "application/json", strings.NewReader(payload))
+	resp, err := utils.HTTPPost(ctx, url, "application/json", strings.NewReader(payload))
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch data: %w", err)
 	}
@@ -220,22 +221,22 @@ func (trc *trcDiscoveryService) fetchTokenData(address, contract, selector strin
 	return result, nil
 }
 
-func (trc *trcDiscoveryService) Search(coin models.CoinBase) (models.CoinBase, error) {
+func (trc *trcDiscoveryService) Search(ctx context.Context, coin models.CoinBase) (models.CoinBase, error) {
 	chainName, exists := cmcChainMap[coin.Chain]
 	if !exists {
 		return models.CoinBase{}, fmt.Errorf("unsupported chain: %v", coin.Chain)
 	}
-	cmcId, err := trc.cmcService.GetCMCIDByContract(chainName, coin.ContractAddress)
+	cmcId, err := trc.cmcService.GetCMCIDByContract(ctx, chainName, coin.ContractAddress)
 	if err != nil {
 		trc.logger.WithError(err).Error("Failed to fetch cmc id")
 		return models.CoinBase{}, fmt.Errorf("failed to fetch cmc id: %w", err)
 	}
-	symbol, err := trc.fetchTokenData(coin.Address, coin.ContractAddress, "symbol()")
+	symbol, err := trc.fetchTokenData(ctx, coin.Address, coin.ContractAddress, "symbol()")
 	if err != nil {
 		return models.CoinBase{}, fmt.Errorf("failed to get symbol: %w", err)
 	}
 
-	decimalsHex, err := trc.fetchTokenData(coin.ContractAddress, coin.ContractAddress, "decimals()")
+	decimalsHex, err := trc.fetchTokenData(ctx, coin.ContractAddress, coin.ContractAddress, "decimals()")
 	if err != nil {
 		return models.CoinBase{}, fmt.Errorf("failed to get decimals: %w", err)
 	}
@@ -1,6 +1,7 @@
 package tokens
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"net/http"
@@ -68,7 +69,7 @@ func TestTrcAutoDiscovery(t *testing.T) {
 	trc.cmcService.cachedData.Set(trc.cmcService.getCacheKey("Tron", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"), 825, cache.DefaultExpiration)
 
 	// Call method
-	coins, err := trc.Discover("TS98H6jSx6uv1gG1vx6CJZMeYGkMZXgQ7K", common.Tron)
+	coins, err := trc.Discover(context.Background(), "TS98H6jSx6uv1gG1vx6CJZMeYGkMZXgQ7K", common.Tron)
 
 	// Check results
 	if test.expectedError {
@@ -144,7 +145,7 @@ func TestFetchTokenData(t *testing.T) {
 			address := "TS98H6jSx6uv1gG1vx6CJZMeYGkMZXgQ7K"
 			contract := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
 			selector := "symbol()"
-			symbol, err := td.fetchTokenData(address, contract, selector)
+			symbol, err := td.fetchTokenData(context.Background(), address, contract, selector)
 
 			// Check results
 			if tt.expectedError {
@@ -227,7 +228,7 @@ func Test_trcDiscoveryService_Search(t *testing.T) {
 	// Run tests
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			result, err := discovery.Search(tc.input)
+			result, err := discovery.Search(context.Background(), tc.input)
 			assert.NoError(t, err)
 			assert.Equal(t, tc.expected, result)
 		})
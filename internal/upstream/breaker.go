@@ -0,0 +1,97 @@
+package upstream
+
+import (
+	"sync"
+	"time"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"    // requests go through
+	BreakerOpen     BreakerState = "open"      // requests are rejected until the open period ends
+	BreakerHalfOpen BreakerState = "half_open" // a single request probes whether the host recovered
+)
+
+// breaker stops sending requests to a host after threshold consecutive failures
+type breaker struct {
+	mu        sync.Mutex
+	threshold int // 0 disables the breaker
+	openFor   time.Duration
+	state     BreakerState
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func newBreaker(threshold int, openFor time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		openFor:   openFor,
+		state:     BreakerClosed,
+	}
+}
+
+// allow reports whether a request may be sent, the caller has to report its outcome with record or release
+func (b *breaker) allow(now time.Time) bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case BreakerOpen:
+		if now.Before(b.openUntil) {
+			return false
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record reports whether a request succeeded
+func (b *breaker) record(now time.Time, ok bool) {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if ok {
+		b.state = BreakerClosed
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.state = BreakerOpen
+		b.openUntil = now.Add(b.openFor)
+	}
+}
+
+// release reports a request that never reached the host, e.g. because its context was cancelled
+func (b *breaker) release() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) snapshot() (BreakerState, time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerClosed {
+		return b.state, time.Time{}
+	}
+	return b.state, b.openUntil
+}
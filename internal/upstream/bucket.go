@@ -0,0 +1,59 @@
+package upstream
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket paces the requests to a host. Every request takes a token, tokens refill at rate per
+// second up to burst, and a request without a token waits until its token is refilled.
+type tokenBucket struct {
+	mu          sync.Mutex
+	rate        float64 // tokens per second, 0 for no limit
+	burst       float64
+	tokens      float64 // negative while requests wait for their token
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(max(1, burst)),
+		tokens: float64(max(1, burst)),
+	}
+}
+
+// reserve takes a token and returns how long the caller has to wait before sending its request
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	start := now
+	if b.pausedUntil.After(start) {
+		start = b.pausedUntil
+	}
+	if b.rate <= 0 {
+		return start.Sub(now)
+	}
+	if start.After(b.last) {
+		if !b.last.IsZero() {
+			b.tokens = min(b.burst, b.tokens+start.Sub(b.last).Seconds()*b.rate)
+		}
+		b.last = start
+	}
+	b.tokens--
+	wait := start.Sub(now)
+	if b.tokens < 0 {
+		wait += time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	return wait
+}
+
+// pause holds every request until the given time, e.g. after the host answered with 429
+func (b *tokenBucket) pause(until time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if until.After(b.pausedUntil) {
+		b.pausedUntil = until
+	}
+}
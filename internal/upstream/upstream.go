@@ -0,0 +1,292 @@
+// Package upstream paces the http calls to third party apis. Every host gets a token bucket, a cap on the
+// requests in flight and a circuit breaker, so a slow or failing provider only stalls the calls to itself.
+package upstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vultisig/airdrop-registry/config"
+)
+
+// ErrCircuitOpen is returned without calling the host while its circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// HostStats is what a limiter reports about a host since it was configured
+type HostStats struct {
+	Host        string       `json:"host"`
+	State       BreakerState `json:"state"`
+	OpenUntil   *time.Time   `json:"open_until,omitempty"`
+	InFlight    int64        `json:"in_flight"`
+	Requests    int64        `json:"requests"`
+	Failures    int64        `json:"failures"`     // network errors, 429 and 5xx responses
+	RateLimited int64        `json:"rate_limited"` // 429 responses
+	Rejected    int64        `json:"rejected"`     // calls rejected while the circuit was open
+}
+
+type host struct {
+	name           string
+	bucket         *tokenBucket
+	breaker        *breaker
+	slots          chan struct{} // nil without a concurrency cap
+	rateLimitedFor time.Duration
+
+	inFlight    atomic.Int64
+	requests    atomic.Int64
+	failures    atomic.Int64
+	rateLimited atomic.Int64
+	rejected    atomic.Int64
+}
+
+// Limiter holds the limits of every host it has seen
+type Limiter struct {
+	cfg   config.Upstream
+	mu    sync.Mutex
+	hosts map[string]*host
+	now   func() time.Time
+}
+
+func NewLimiter(cfg config.Upstream) *Limiter {
+	return &Limiter{
+		cfg:   cfg,
+		hosts: make(map[string]*host),
+		now:   time.Now,
+	}
+}
+
+var defaultLimiter atomic.Pointer[Limiter]
+
+func init() {
+	defaultLimiter.Store(NewLimiter(config.Upstream{}))
+}
+
+// Configure replaces the limiter used by Transport, until then calls are not limited
+func Configure(cfg config.Upstream) {
+	defaultLimiter.Store(NewLimiter(cfg))
+}
+
+// Default returns the limiter set by Configure
+func Default() *Limiter {
+	return defaultLimiter.Load()
+}
+
+// Transport returns a round tripper sending the requests through the limiter set by Configure at the
+// time of each request
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+// Transport returns a round tripper sending the requests through the limiter
+func (l *Limiter) Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base, limiter: l}
+}
+
+type transport struct {
+	base    http.RoundTripper
+	limiter *Limiter // nil for the default limiter
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	l := t.limiter
+	if l == nil {
+		l = Default()
+	}
+	return l.roundTrip(t.base, req)
+}
+
+// hostConfig returns the limits of the host, fields it leaves unset fall back to the default
+func (l *Limiter) hostConfig(name string) config.UpstreamHost {
+	cfg := l.cfg.Default
+	for _, h := range l.cfg.Hosts {
+		if !strings.EqualFold(h.Host, name) {
+			continue
+		}
+		if h.RequestsPerSecond != 0 {
+			cfg.RequestsPerSecond = h.RequestsPerSecond
+		}
+		if h.Burst != 0 {
+			cfg.Burst = h.Burst
+		}
+		if h.MaxConcurrent != 0 {
+			cfg.MaxConcurrent = h.MaxConcurrent
+		}
+		if h.FailureThreshold != 0 {
+			cfg.FailureThreshold = h.FailureThreshold
+		}
+		if h.OpenSeconds != 0 {
+			cfg.OpenSeconds = h.OpenSeconds
+		}
+		if h.RateLimitedSeconds != 0 {
+			cfg.RateLimitedSeconds = h.RateLimitedSeconds
+		}
+	}
+	cfg.Host = name
+	return cfg
+}
+
+func (l *Limiter) host(name string) *host {
+	name = strings.ToLower(name)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if h, ok := l.hosts[name]; ok {
+		return h
+	}
+	cfg := l.hostConfig(name)
+	h := &host{
+		name:           name,
+		bucket:         newTokenBucket(cfg.RequestsPerSecond, cfg.Burst),
+		breaker:        newBreaker(cfg.FailureThreshold, time.Duration(cfg.OpenSeconds)*time.Second),
+		rateLimitedFor: time.Duration(cfg.RateLimitedSeconds) * time.Second,
+	}
+	if cfg.MaxConcurrent > 0 {
+		h.slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	l.hosts[name] = h
+	return h
+}
+
+func (l *Limiter) roundTrip(base http.RoundTripper, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	h := l.host(req.URL.Hostname())
+	if !h.breaker.allow(l.now()) {
+		h.rejected.Add(1)
+		return nil, fmt.Errorf("%s: %w", h.name, ErrCircuitOpen)
+	}
+	if err := h.acquire(ctx); err != nil {
+		h.breaker.release()
+		return nil, err
+	}
+	if err := wait(ctx, h.bucket.reserve(l.now())); err != nil {
+		h.releaseSlot()
+		h.breaker.release()
+		return nil, err
+	}
+	h.requests.Add(1)
+	resp, err := base.RoundTrip(req)
+	now := l.now()
+	if err != nil {
+		h.releaseSlot()
+		if errors.Is(ctx.Err(), context.Canceled) {
+			// the caller gave up, the host is not to blame
+			h.breaker.release()
+		} else {
+			h.fail(now)
+		}
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		h.rateLimited.Add(1)
+		h.bucket.pause(now.Add(retryAfter(resp.Header.Get("Retry-After"), now, h.rateLimitedFor)))
+		h.fail(now)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		h.fail(now)
+	default:
+		h.breaker.record(now, true)
+	}
+	// the slot is held until the body is closed
+	resp.Body = &slotBody{ReadCloser: resp.Body, host: h}
+	return resp, nil
+}
+
+func (h *host) acquire(ctx context.Context) error {
+	if h.slots != nil {
+		select {
+		case h.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	h.inFlight.Add(1)
+	return nil
+}
+
+func (h *host) releaseSlot() {
+	h.inFlight.Add(-1)
+	if h.slots != nil {
+		<-h.slots
+	}
+}
+
+func (h *host) fail(now time.Time) {
+	h.failures.Add(1)
+	h.breaker.record(now, false)
+}
+
+type slotBody struct {
+	io.ReadCloser
+	host *host
+	once sync.Once
+}
+
+func (b *slotBody) Close() error {
+	b.once.Do(b.host.releaseSlot)
+	return b.ReadCloser.Close()
+}
+
+// retryAfter reads the Retry-After header in seconds or as a date, falling back to the given duration
+func retryAfter(header string, now time.Time, fallback time.Duration) time.Duration {
+	if header == "" {
+		return fallback
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return date.Sub(now)
+	}
+	return fallback
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Stats reports every host the limiter has seen, sorted by name
+func (l *Limiter) Stats() []HostStats {
+	l.mu.Lock()
+	hosts := make([]*host, 0, len(l.hosts))
+	for _, h := range l.hosts {
+		hosts = append(hosts, h)
+	}
+	l.mu.Unlock()
+	sort.Slice(hosts, func(i, j int) bool {
+		return hosts[i].name < hosts[j].name
+	})
+	stats := make([]HostStats, len(hosts))
+	for i, h := range hosts {
+		state, openUntil := h.breaker.snapshot()
+		stats[i] = HostStats{
+			Host:        h.name,
+			State:       state,
+			InFlight:    h.inFlight.Load(),
+			Requests:    h.requests.Load(),
+			Failures:    h.failures.Load(),
+			RateLimited: h.rateLimited.Load(),
+			Rejected:    h.rejected.Load(),
+		}
+		if !openUntil.IsZero() {
+			stats[i].OpenUntil = &openUntil
+		}
+	}
+	return stats
+}
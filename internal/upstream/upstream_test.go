@@ -0,0 +1,185 @@
+package upstream
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vultisig/airdrop-registry/config"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	bucket := newTokenBucket(2, 2)
+	assert.Equal(t, time.Duration(0), bucket.reserve(now))
+	assert.Equal(t, time.Duration(0), bucket.reserve(now))
+	assert.Equal(t, 500*time.Millisecond, bucket.reserve(now))
+	assert.Equal(t, time.Second, bucket.reserve(now))
+	// the waiting requests used the tokens refilled in the meantime
+	assert.Equal(t, 500*time.Millisecond, bucket.reserve(now.Add(time.Second)))
+
+	// the bucket refills while the host is paused
+	bucket.pause(now.Add(time.Minute))
+	assert.Equal(t, time.Minute, bucket.reserve(now))
+
+	unlimited := newTokenBucket(0, 0)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, time.Duration(0), unlimited.reserve(now))
+	}
+	unlimited.pause(now.Add(time.Second))
+	assert.Equal(t, time.Second, unlimited.reserve(now))
+}
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newBreaker(2, time.Minute)
+	assert.True(t, b.allow(now))
+	b.record(now, false)
+	assert.True(t, b.allow(now))
+	b.record(now, false)
+	assert.False(t, b.allow(now))
+	state, openUntil := b.snapshot()
+	assert.Equal(t, BreakerOpen, state)
+	assert.Equal(t, now.Add(time.Minute), openUntil)
+
+	// a single probe once the circuit was open long enough
+	later := now.Add(time.Minute)
+	assert.True(t, b.allow(later))
+	assert.False(t, b.allow(later))
+	b.release()
+	assert.True(t, b.allow(later))
+	b.record(later, false)
+	assert.False(t, b.allow(later))
+
+	later = later.Add(time.Minute)
+	assert.True(t, b.allow(later))
+	b.record(later, true)
+	assert.True(t, b.allow(later))
+	assert.True(t, b.allow(later))
+	state, _ = b.snapshot()
+	assert.Equal(t, BreakerClosed, state)
+
+	disabled := newBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		disabled.record(now, false)
+	}
+	assert.True(t, disabled.allow(now))
+}
+
+func TestHostConfig(t *testing.T) {
+	l := NewLimiter(config.Upstream{
+		Default: config.UpstreamHost{RequestsPerSecond: 10, Burst: 5, FailureThreshold: 3, OpenSeconds: 60},
+		Hosts: []config.UpstreamHost{
+			{Host: "Midgard.NineRealms.com", RequestsPerSecond: 1, MaxConcurrent: 2},
+		},
+	})
+	assert.Equal(t, config.UpstreamHost{
+		Host:              "api.etherscan.io",
+		RequestsPerSecond: 10,
+		Burst:             5,
+		FailureThreshold:  3,
+		OpenSeconds:       60,
+	}, l.hostConfig("api.etherscan.io"))
+	assert.Equal(t, config.UpstreamHost{
+		Host:              "midgard.ninerealms.com",
+		RequestsPerSecond: 1,
+		Burst:             5,
+		MaxConcurrent:     2,
+		FailureThreshold:  3,
+		OpenSeconds:       60,
+	}, l.hostConfig("midgard.ninerealms.com"))
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 30*time.Second, retryAfter("", now, 30*time.Second))
+	assert.Equal(t, 5*time.Second, retryAfter("5", now, 30*time.Second))
+	assert.Equal(t, 10*time.Second, retryAfter(now.Add(10*time.Second).Format(http.TimeFormat), now, 30*time.Second))
+	assert.Equal(t, 30*time.Second, retryAfter("soon", now, 30*time.Second))
+}
+
+func TestLimiterCircuitBreaker(t *testing.T) {
+	var calls atomic.Int64
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer mockServer.Close()
+	l := NewLimiter(config.Upstream{
+		Default: config.UpstreamHost{FailureThreshold: 2, OpenSeconds: 60},
+	})
+	client := &http.Client{Transport: l.Transport(http.DefaultTransport)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(mockServer.URL)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	}
+	_, err := client.Get(mockServer.URL)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, int64(2), calls.Load())
+
+	stats := l.Stats()
+	assert.Len(t, stats, 1)
+	assert.Equal(t, "127.0.0.1", stats[0].Host)
+	assert.Equal(t, BreakerOpen, stats[0].State)
+	assert.Equal(t, int64(0), stats[0].InFlight)
+	assert.Equal(t, int64(2), stats[0].Requests)
+	assert.Equal(t, int64(2), stats[0].Failures)
+	assert.Equal(t, int64(1), stats[0].Rejected)
+}
+
+func TestLimiterMaxConcurrent(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockServer.Close()
+	l := NewLimiter(config.Upstream{
+		Default: config.UpstreamHost{MaxConcurrent: 1},
+	})
+	client := &http.Client{Transport: l.Transport(http.DefaultTransport)}
+	resp, err := client.Get(mockServer.URL)
+	assert.NoError(t, err)
+
+	// the slot is held until the body of the first response is closed
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mockServer.URL, nil)
+	assert.NoError(t, err)
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	assert.NoError(t, resp.Body.Close())
+	resp, err = client.Get(mockServer.URL)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+}
+
+func TestLimiterRateLimited(t *testing.T) {
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer mockServer.Close()
+	l := NewLimiter(config.Upstream{})
+	client := &http.Client{Transport: l.Transport(http.DefaultTransport)}
+	resp, err := client.Get(mockServer.URL)
+	assert.NoError(t, err)
+	assert.NoError(t, resp.Body.Close())
+
+	// the host is paused, the next request waits until the caller gives up
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mockServer.URL, nil)
+	assert.NoError(t, err)
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	stats := l.Stats()
+	assert.Equal(t, int64(1), stats[0].Requests)
+	assert.Equal(t, int64(1), stats[0].RateLimited)
+}
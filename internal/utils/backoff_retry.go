@@ -1,10 +1,14 @@
 package utils
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/airdrop-registry/internal/upstream"
 )
 
 type BackoffRetry struct {
@@ -23,20 +27,27 @@ func NewBackoffRetry(maxRetries int) *BackoffRetry {
 
 // RetryWithBackoff attempts to execute the provided function `fn` up to `maxRetries` times.
 // If `fn` fails, it waits for a delay that increases exponentially after each attempt.
-func (b *BackoffRetry) RetryWithBackoff(fn func(string) (float64, error), arg string) (float64, error) {
+// It stops waiting and returns the error of ctx once ctx is done, and does not retry while the circuit
+// breaker of the upstream host is open.
+func (b *BackoffRetry) RetryWithBackoff(ctx context.Context, fn func(context.Context, string) (float64, error), arg string) (float64, error) {
 	var result float64
 	var err error
 	backoffDuration := b.initialBackoff
 	for attempt := 1; attempt <= b.maxRetries; attempt++ {
-		result, err = fn(arg)
+		result, err = fn(ctx, arg)
 		if err == nil {
 			return result, nil
 		}
+		if errors.Is(err, upstream.ErrCircuitOpen) {
+			return 0, err
+		}
 
 		backoffDuration += time.Duration(attempt)
 		// Log attempt and error
 		b.logger.Warnf("Attempt %d failed with error: %v. Retrying in %s...\n", attempt, err, backoffDuration)
-		time.Sleep(backoffDuration)
+		if err := Sleep(ctx, backoffDuration); err != nil {
+			return 0, fmt.Errorf("retry cancelled after attempt %d: %w", attempt, err)
+		}
 	}
 
 	// Return the error after exhausting all attempts
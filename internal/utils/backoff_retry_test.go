@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -20,7 +21,7 @@ func TestRetryWithBackoff_Success(t *testing.T) {
 
 	// Mock function to succeed on the second attempt
 	attemptCount := 0
-	mockFn := func(arg string) (float64, error) {
+	mockFn := func(_ context.Context, arg string) (float64, error) {
 		attemptCount++
 		if attemptCount == 2 {
 			val = arg
@@ -30,7 +31,7 @@ func TestRetryWithBackoff_Success(t *testing.T) {
 	}
 
 	// Act
-	result, err := backoff.RetryWithBackoff(mockFn, "param")
+	result, err := backoff.RetryWithBackoff(context.Background(), mockFn, "param")
 
 	// Assert
 	assert.NoError(t, err, "Expected no error after successful retry")
@@ -48,13 +49,13 @@ func TestRetryWithBackoff_Failure(t *testing.T) {
 	backoff.logger = logger
 
 	// Mock function to always fail
-	mockFn := func(arg string) (float64, error) {
+	mockFn := func(_ context.Context, arg string) (float64, error) {
 		return 0, errors.New("persistent error")
 	}
 
 	// Act
 	startTime := time.Now()
-	result, err := backoff.RetryWithBackoff(mockFn, "")
+	result, err := backoff.RetryWithBackoff(context.Background(), mockFn, "")
 	elapsedTime := time.Since(startTime)
 
 	// Assert
@@ -63,3 +64,23 @@ func TestRetryWithBackoff_Failure(t *testing.T) {
 	assert.Equal(t, 0.0, result, "Expected result to be zero after failure")
 	assert.GreaterOrEqual(t, elapsedTime, time.Duration(retries)*backoff.initialBackoff, "Expected total retry time to exceed cumulative backoff delay")
 }
+
+// TestRetryWithBackoff_Cancelled tests that a cancelled context stops the retries without waiting.
+func TestRetryWithBackoff_Cancelled(t *testing.T) {
+	backoff := NewBackoffRetry(5)
+	backoff.initialBackoff = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	attemptCount := 0
+	mockFn := func(_ context.Context, arg string) (float64, error) {
+		attemptCount++
+		cancel()
+		return 0, errors.New("temporary error")
+	}
+
+	startTime := time.Now()
+	_, err := backoff.RetryWithBackoff(ctx, mockFn, "")
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, attemptCount)
+	assert.Less(t, time.Since(startTime), time.Second)
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/vultisig/airdrop-registry/internal/upstream"
+)
+
+// RequestTimeout bounds a single http call including reading its response, callers can pass a context
+// with a shorter deadline
+const RequestTimeout = 30 * time.Second
+
+// httpClient sends every request through the per host limits of the upstream package
+var httpClient = &http.Client{
+	Timeout:   RequestTimeout,
+	Transport: upstream.Transport(http.DefaultTransport),
+}
+
+// HTTPGet sends a GET request that is cancelled together with ctx
+func HTTPGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return httpClient.Do(req)
+}
+
+// HTTPPost sends a POST request that is cancelled together with ctx
+func HTTPPost(ctx context.Context, url, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return httpClient.Do(req)
+}
+
+// HTTPDo sends the request with ctx, for requests that need their own headers
+func HTTPDo(ctx context.Context, req *http.Request) (*http.Response, error) {
+	return httpClient.Do(req.WithContext(ctx))
+}
+
+// Sleep waits for d unless ctx is done first, in which case it returns the error of ctx
+func Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
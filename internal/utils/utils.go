@@ -4,7 +4,6 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"math"
 	"math/big"
 	"regexp"
 	"strings"
@@ -93,31 +92,3 @@ func EIP55Checksum(address string) (string, error) {
 	addr := common.HexToAddress(address)
 	return addr.Hex(), nil
 }
-
-// MIN(2,1+(LOG(1+referralCount)/LOG(1+500)))
-func GetReferralMultiplier(referralCount int64) float64 {
-	// Convert referralCount to float64 for math operations
-	rc := float64(referralCount)
-
-	// Compute numerator and denominator
-	numerator := math.Log(1 + rc)
-	denominator := math.Log(1 + 500)
-
-	// Calculate multiplier
-	multiplier := 1 + (numerator / denominator)
-
-	// Apply MIN(2, multiplier)
-	if multiplier > 2 {
-		multiplier = 2
-	}
-
-	return multiplier
-}
-
-// =1+0.02*SQRT(swapVolume)
-func GetSwapVolumeMultiplier(swapVolume float64) float64 {
-	// Calculate the multiplier
-	multiplier := 1 + 0.002*math.Sqrt(swapVolume)
-
-	return multiplier
-}
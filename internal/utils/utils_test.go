@@ -1,7 +1,6 @@
 package utils
 
 import (
-	"fmt"
 	"testing"
 )
 
@@ -118,42 +117,3 @@ func TestEIP55(t *testing.T) {
 		})
 	}
 }
-
-func TestGetReferralMultiplier(t *testing.T) {
-	testCases := []struct {
-		input          int64
-		expectedOutput float64
-	}{
-		{0, 1},
-		{1, 1.1114992922647913},
-		{10, 1.3857241771164996},
-		{500, 2},
-	}
-	for _, tc := range testCases {
-		result := GetReferralMultiplier(tc.input)
-		if result != tc.expectedOutput {
-			t.Errorf("Expected %f for input %d, but got %f", tc.expectedOutput, tc.input, result)
-		}
-	}
-}
-
-func TestGetSwapVolumeMultiplier(t *testing.T) {
-	testCases := []struct {
-		input          float64
-		expectedOutput float64
-	}{
-		{0, 1},
-		{400, 1.04},
-		{900, 1.06},
-		{1600, 1.08},
-		{2500, 1.1},
-		{1000000, 3},
-	}
-	for _, tc := range testCases {
-		result := GetSwapVolumeMultiplier(tc.input)
-		if result != tc.expectedOutput {
-			t.Errorf("Expected %f for input %f, but got %f", tc.expectedOutput, tc.input, result)
-			fmt.Println(result, tc.expectedOutput)
-		}
-	}
-}
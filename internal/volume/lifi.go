@@ -1,6 +1,7 @@
 package volume
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -8,6 +9,8 @@ import (
 	"regexp"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/airdrop-registry/internal/utils"
 )
 
 type lifiVolumeTracker struct {
@@ -27,13 +30,13 @@ func (l *lifiVolumeTracker) SafeClose(closer io.Closer) {
 		l.logger.Error(err)
 	}
 }
-func (l *lifiVolumeTracker) FetchVolume(from, to int64, affiliate string) (map[string]float64, error) {
+func (l *lifiVolumeTracker) FetchVolume(ctx context.Context, from, to int64, affiliate string) (map[string]float64, error) {
 	res := make(map[string]float64)
 	if !l.isValidAffiliate(affiliate) {
 		return res, nil
 	}
 	url := fmt.Sprintf("%s/analytics/transfers?integrator=%s&fromTimestamp=%d&toTimestamp=%d", l.baseUrl, affiliate, from, to)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		l.logger.WithError(err).Error("error making GET request")
 		return nil, fmt.Errorf("error making GET request: %w", err)
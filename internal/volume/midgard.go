@@ -1,14 +1,16 @@
 package volume
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
-	"time"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/vultisig/airdrop-registry/internal/utils"
 )
 
 type midgardTracker struct {
@@ -33,12 +35,11 @@ func (v *midgardTracker) SafeClose(closer io.Closer) {
 	}
 }
 
-func (v *midgardTracker) FetchVolume(from, to int64, affiliate string) (map[string]float64, error) {
-	return v.processVolumeWithToken(from, to, affiliate, "")
+func (v *midgardTracker) FetchVolume(ctx context.Context, from, to int64, affiliate string) (map[string]float64, error) {
+	return v.processVolumeWithToken(ctx, from, to, affiliate, "")
 }
 
-func (v *midgardTracker) processVolumeWithToken(from, to int64, affiliate, nextPageToken string) (map[string]float64, error) {
-	time.Sleep(1 * time.Second) // to avoid hitting rate limits
+func (v *midgardTracker) processVolumeWithToken(ctx context.Context, from, to int64, affiliate, nextPageToken string) (map[string]float64, error) {
 	url := fmt.Sprintf("%s?affiliate=%s&type=swap&timestamp=%d", v.baseUrl, affiliate, to)
 	if nextPageToken != "" {
 		url = fmt.Sprintf("%s?affiliate=%s&type=swap&nextPageToken=%s", v.baseUrl, affiliate, nextPageToken)
@@ -50,18 +51,20 @@ func (v *midgardTracker) processVolumeWithToken(from, to int64, affiliate, nextP
 	if v.clientIdHeader != "" {
 		req.Header.Set("X-Client-ID", v.clientIdHeader)
 	}
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := utils.HTTPDo(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("error making GET request: %w", err)
 	}
-	defer v.SafeClose(resp.Body)
-
+	// the body is closed before requesting the next page, so the page does not hold on to its connection
 	if resp.StatusCode == http.StatusTooManyRequests {
-		time.Sleep(30 * time.Second)
-		return v.processVolumeWithToken(from, to, affiliate, nextPageToken)
+		v.SafeClose(resp.Body)
+		// the upstream limiter holds the next request until midgard accepts requests again
+		return v.processVolumeWithToken(ctx, from, to, affiliate, nextPageToken)
 	}
 	var volRes tcVolumeModel
-	if err := json.NewDecoder(resp.Body).Decode(&volRes); err != nil {
+	err = json.NewDecoder(resp.Body).Decode(&volRes)
+	v.SafeClose(resp.Body)
+	if err != nil {
 		return nil, fmt.Errorf("error decoding response: %w", err)
 	}
 	res := make(map[string]float64)
@@ -84,7 +87,7 @@ func (v *midgardTracker) processVolumeWithToken(from, to int64, affiliate, nextP
 		}
 	}
 	if volRes.Meta.NextPageToken != "" {
-		nextRes, err := v.processVolumeWithToken(from, to, affiliate, volRes.Meta.NextPageToken)
+		nextRes, err := v.processVolumeWithToken(ctx, from, to, affiliate, volRes.Meta.NextPageToken)
 		if err != nil {
 			return nil, err
 		}
@@ -1,12 +1,12 @@
 package volume
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"math/big"
-	"net/http"
 
 	"github.com/sirupsen/logrus"
 	"github.com/vultisig/airdrop-registry/internal/utils"
@@ -36,7 +36,7 @@ func (o *oneInchVolumeTracker) SafeClose(closer io.Closer) {
 }
 
 // TODO: add from/to filter to etherscan request
-func (o *oneInchVolumeTracker) FetchVolume(from, to int64, affiliate string) (map[string]float64, error) {
+func (o *oneInchVolumeTracker) FetchVolume(ctx context.Context, from, to int64, affiliate string) (map[string]float64, error) {
 	res := make(map[string]float64)
 	//ignore invalid affiliate
 	if !o.isValidAffiliate(affiliate) {
@@ -44,7 +44,7 @@ func (o *oneInchVolumeTracker) FetchVolume(from, to int64, affiliate string) (ma
 	}
 	// #TODO check api for from & to parameters
 	url := fmt.Sprintf("%s/v2/api?chainid=1&module=account&action=txlistinternal&address=%s&apikey=%s", o.etherscanbaseUrl, affiliate, o.etherscanApiKey)
-	resp, err := http.Get(url)
+	resp, err := utils.HTTPGet(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("error making GET request: %w", err)
 	}
@@ -71,7 +71,7 @@ func (o *oneInchVolumeTracker) FetchVolume(from, to int64, affiliate string) (ma
 
 	for _, tx := range txHashes {
 		url = fmt.Sprintf("%s/getTxInfo/%s?apiKey=%s", o.ethplorerBaseUrl, tx, o.ethplorerApiKey)
-		resp, err := http.Get(url)
+		resp, err := utils.HTTPGet(ctx, url)
 		if err != nil {
 			return nil, fmt.Errorf("error making GET request: %w", err)
 		}
@@ -1,6 +1,7 @@
 package volume
 
 import (
+	"context"
 	"io"
 
 	"github.com/vultisig/airdrop-registry/config"
@@ -8,7 +9,7 @@ import (
 
 type IVolumeTracker interface {
 	SafeClose(closer io.Closer)
-	FetchVolume(from, to int64, affiliate string) (map[string]float64, error)
+	FetchVolume(ctx context.Context, from, to int64, affiliate string) (map[string]float64, error)
 }
 
 type VolumeResolver struct {
@@ -31,11 +32,11 @@ func NewVolumeResolver(cfg *config.Config) (*VolumeResolver, error) {
 	return pr, nil
 }
 
-func (v *VolumeResolver) LoadVolume(from, to int64) error {
+func (v *VolumeResolver) LoadVolume(ctx context.Context, from, to int64) error {
 	res := make(map[string]float64)
 	for _, aff := range v.affiliate {
 		for _, tracker := range v.trackers {
-			vol, err := tracker.FetchVolume(from, to, aff)
+			vol, err := tracker.FetchVolume(ctx, from, to, aff)
 			if err != nil {
 				return err
 			}